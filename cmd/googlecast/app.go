@@ -15,6 +15,9 @@ type app struct {
 	gopi.Logger
 	gopi.CastService
 	gopi.PingService
+
+	// Restart device discovery when it fails
+	gopi.CastManager `gopi:"restart=on-failure,critical=false"`
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -17,13 +17,17 @@ type app struct {
 	// Registered services
 	gopi.PingService
 	gopi.RotelService
+	gopi.EventsService
+
+	// Restart the amplifier connection when it fails
+	gopi.RotelManager `gopi:"restart=on-failure,critical=false"`
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 // LIFECYCLE
 
 func (this *app) New(cfg gopi.Config) error {
-	this.Require(this.Logger, this.PingService, this.RotelService)
+	this.Require(this.Logger, this.PingService, this.RotelService, this.EventsService)
 	return nil
 }
 
@@ -4,6 +4,7 @@ import (
 	_ "github.com/djthorpe/gopi/v3/pkg/dev/rotel"
 	_ "github.com/djthorpe/gopi/v3/pkg/event"
 	_ "github.com/djthorpe/gopi/v3/pkg/mdns"
+	_ "github.com/djthorpe/gopi/v3/pkg/rpc/events"
 	_ "github.com/djthorpe/gopi/v3/pkg/rpc/ping"
 	_ "github.com/djthorpe/gopi/v3/pkg/rpc/rotel"
 	_ "github.com/djthorpe/gopi/v3/pkg/rpc/server"
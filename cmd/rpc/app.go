@@ -20,6 +20,8 @@ type app struct {
 	gopi.Command
 	Chromecast
 	Rotel
+	Events
+	Metrics
 
 	service *string
 }
@@ -38,10 +40,18 @@ const (
 func (this *app) Define(cfg gopi.Config) error {
 	this.Chromecast.Define(cfg)
 	this.Rotel.Define(cfg)
+	this.Events.Define(cfg)
+	this.Metrics.Define(cfg)
 
 	// Global flags
 	this.service = cfg.FlagString("srv", "", "name, service:name or host:port")
 
+	// Complete chromecast names and rotel sources
+	for _, name := range []string{"cast connect", "cast disconnect", "cast vol", "cast mute", "cast unmute", "cast app", "cast media"} {
+		cfg.Complete(name, this.CompleteCast)
+	}
+	cfg.Complete("rotel source", this.CompleteRotelSource)
+
 	// Return success
 	return nil
 }
@@ -68,6 +78,10 @@ func (this *app) Run(ctx context.Context) error {
 		name = "gopi.rotel.Manager"
 	case strings.HasPrefix(name, "cast"):
 		name = "gopi.chromecast.Manager"
+	case strings.HasPrefix(name, "events"):
+		name = "gopi.events.Events"
+	case strings.HasPrefix(name, "metrics"):
+		name = "gopi.metrics.Metrics"
 	}
 	if stub, err := this.GetStub(name); err != nil {
 		return err
@@ -104,6 +118,47 @@ func (this *app) GetStub(name string) (gopi.ServiceStub, error) {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// COMPLETION
+
+// CompleteCast returns chromecast names for the first argument, and
+// application names or media commands for the second argument
+func (this *app) CompleteCast(ctx context.Context, args []string) ([]string, error) {
+	switch len(args) {
+	case 0:
+		stub, err := this.GetStub("gopi.chromecast.Manager")
+		if err != nil {
+			return nil, err
+		}
+		casts, err := stub.(gopi.CastStub).List(ctx, time.Second)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(casts))
+		for _, cast := range casts {
+			names = append(names, cast.Name())
+		}
+		return names, nil
+	case 1:
+		switch this.Command.Name() {
+		case "cast app":
+			return []string{"backdrop", "mutablemedia", "default"}, nil
+		case "cast media":
+			return []string{"connect", "disconnect"}, nil
+		}
+	}
+	return nil, nil
+}
+
+// CompleteRotelSource returns the input sources for the amplifier
+func (this *app) CompleteRotelSource(ctx context.Context, args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{"cd", "coax1", "coax2", "opt1", "opt2", "aux1", "aux2", "tuner", "photo", "usb", "bluetooth"}, nil
+	} else {
+		return nil, nil
+	}
+}
+
 /*
 
 	// Set flags for cast functions
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	// Modules
+	gopi "github.com/djthorpe/gopi/v3"
+
+	// Dependencies
+	_ "github.com/djthorpe/gopi/v3/pkg/rpc/events"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type Events struct {
+	types *string
+}
+
+///////////////////////////////////////////////////////////////////////
+// METHODS
+
+func (this *Events) GetStub(ctx context.Context) gopi.EventsStub {
+	return ctx.Value(KeyStub).(gopi.EventsStub)
+}
+
+func (this *Events) GetArgs(ctx context.Context) []string {
+	return ctx.Value(KeyArgs).([]string)
+}
+
+func (this *Events) Define(cfg gopi.Config) {
+	this.types = cfg.FlagString("type", "", "Comma-separated event types", "events")
+
+	cfg.Command("events", "Watch for remote events, filtered by name", func(ctx context.Context) error {
+		stub := this.GetStub(ctx)
+		ch := make(chan gopi.Event)
+		go func() {
+			fmt.Println("Watching for events, press CTRL+C to end")
+			for evt := range ch {
+				fmt.Println(evt)
+			}
+		}()
+		err := stub.Stream(ctx, this.GetArgs(ctx), splitTypes(*this.types), ch)
+		close(ch)
+		return err
+	})
+	cfg.Command("events types", "List remote event types", func(ctx context.Context) error {
+		if types, err := this.GetStub(ctx).Types(ctx); err != nil {
+			return err
+		} else {
+			for _, t := range types {
+				fmt.Println(t)
+			}
+		}
+		return nil
+	})
+}
+
+func splitTypes(value string) []string {
+	result := []string{}
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			result = append(result, t)
+		}
+	}
+	return result
+}
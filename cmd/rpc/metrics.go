@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	// Modules
+	data "github.com/djthorpe/data"
+	table "github.com/djthorpe/data/pkg/table"
+	gopi "github.com/djthorpe/gopi/v3"
+	metrics "github.com/djthorpe/gopi/v3/pkg/metrics"
+
+	// Dependencies
+	_ "github.com/djthorpe/gopi/v3/pkg/rpc/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type Metrics struct {
+	watch   *bool
+	since   *time.Duration
+	tags    *string
+	history *uint
+}
+
+// watcher retains recent measurements for each series, which is
+// a measurement name and set of tag values
+type watcher struct {
+	history uint
+	series  map[string][]gopi.Measurement
+}
+
+///////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	watchRefresh = 500 * time.Millisecond
+	watchClear   = "\x1b[H\x1b[2J"
+)
+
+///////////////////////////////////////////////////////////////////////
+// METHODS
+
+func (this *Metrics) GetStub(ctx context.Context) gopi.MetricsStub {
+	return ctx.Value(KeyStub).(gopi.MetricsStub)
+}
+
+func (this *Metrics) GetArgs(ctx context.Context) []string {
+	return ctx.Value(KeyArgs).([]string)
+}
+
+func (this *Metrics) Define(cfg gopi.Config) {
+	this.watch = cfg.FlagBool("watch", false, "Watch for measurements", "metrics")
+	this.since = cfg.FlagDuration("since", 0, "Show recent measurements retained by the server", "metrics")
+	this.tags = cfg.FlagString("tag", "", "Comma-separated tag filters, in the form name=value", "metrics")
+	this.history = cfg.FlagUint("history", 5, "Number of recent measurements shown for each series when watching", "metrics")
+
+	cfg.Command("metrics", "List measurements, or show recent and watch for measurements filtered by name", func(ctx context.Context) error {
+		stub := this.GetStub(ctx)
+		name, err := this.GetName(ctx)
+		if err != nil {
+			return err
+		}
+		tags, err := splitTags(*this.tags)
+		if err != nil {
+			return err
+		}
+
+		// List measurements when not watching or querying
+		if *this.watch == false && *this.since == 0 {
+			measurements, err := stub.List(ctx)
+			if err != nil {
+				return err
+			}
+			table := table.NewTable("Name", "Field", "Kind")
+			for _, m := range measurements {
+				if name != "" && m.Name() != name {
+					continue
+				}
+				for _, field := range m.Tags() {
+					table.Append(m.Name(), field.Name(), "tag")
+				}
+				for _, field := range m.Metrics() {
+					table.Append(m.Name(), field.Name(), field.Kind())
+				}
+			}
+			return table.Write(os.Stdout, table.OptHeader(), table.OptAscii(80, data.BorderLines))
+		}
+
+		// Retrieve recent measurements
+		var recent []gopi.Measurement
+		if *this.since != 0 {
+			if recent, err = stub.Query(ctx, name, tags, time.Now().Add(-*this.since), time.Time{}); err != nil {
+				return err
+			}
+		}
+
+		// Show recent measurements when not watching, or when the output is
+		// not a terminal, print each measurement on a line
+		if *this.watch == false || isTerminal(os.Stdout) == false {
+			for _, m := range recent {
+				fmt.Println(formatMeasurement(m))
+			}
+		}
+
+		// Watch for measurements
+		if *this.watch {
+			ch := make(chan gopi.Measurement)
+			if isTerminal(os.Stdout) {
+				go this.watchTable(ch, recent)
+			} else {
+				go func() {
+					fmt.Println("Watching for measurements, press CTRL+C to end")
+					for m := range ch {
+						fmt.Println(formatMeasurement(m))
+					}
+				}()
+			}
+			err := stub.Stream(ctx, name, tags, ch)
+			close(ch)
+			return err
+		}
+
+		// Return success
+		return nil
+	})
+}
+
+// GetName returns the measurement name from the arguments, or
+// an empty string for all measurements
+func (this *Metrics) GetName(ctx context.Context) (string, error) {
+	switch args := this.GetArgs(ctx); len(args) {
+	case 0:
+		return "", nil
+	case 1:
+		return args[0], nil
+	default:
+		return "", gopi.ErrBadParameter.WithPrefix("metrics")
+	}
+}
+
+// watchTable redraws a table of the recent measurements for each series
+// as measurements are received, until the channel is closed
+func (this *Metrics) watchTable(ch <-chan gopi.Measurement, recent []gopi.Measurement) {
+	w := newWatcher(*this.history)
+	for _, m := range recent {
+		w.Add(m)
+	}
+
+	ticker := time.NewTicker(watchRefresh)
+	defer ticker.Stop()
+
+	w.Write(os.Stdout)
+	modified := false
+	for {
+		select {
+		case m, ok := <-ch:
+			if ok == false {
+				return
+			}
+			w.Add(m)
+			modified = true
+		case <-ticker.C:
+			if modified {
+				w.Write(os.Stdout)
+				modified = false
+			}
+		}
+	}
+}
+
+// newWatcher returns a watcher which retains up to history
+// measurements for each series
+func newWatcher(history uint) *watcher {
+	if history == 0 {
+		history = 1
+	}
+	return &watcher{history, make(map[string][]gopi.Measurement)}
+}
+
+// Add a measurement to the series, discarding the oldest measurement
+// when the history is full
+func (this *watcher) Add(m gopi.Measurement) {
+	key := m.Name()
+	for _, field := range m.Tags() {
+		key += "\x00" + field.Name() + "=" + fmt.Sprint(field.Value())
+	}
+	series := append([]gopi.Measurement{m}, this.series[key]...)
+	if uint(len(series)) > this.history {
+		series = series[:this.history]
+	}
+	this.series[key] = series
+}
+
+// Write clears the terminal and writes the series ordered by name and
+// tags, with the most recent measurement first
+func (this *watcher) Write(w io.Writer) error {
+	keys := make([]string, 0, len(this.series))
+	for key := range this.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	table := table.NewTable("Time", "Name", "Tags", "Metrics")
+	for _, key := range keys {
+		for _, m := range this.series[key] {
+			tags, values := formatFields(m)
+			table.Append(m.Time().Format("15:04:05"), m.Name(), tags, values)
+		}
+	}
+	if _, err := fmt.Fprint(w, watchClear); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "Watching for measurements, press CTRL+C to end")
+	return table.Write(w, table.OptHeader(), table.OptAscii(120, data.BorderLines))
+}
+
+// isTerminal returns true if the file is a character device
+func isTerminal(f *os.File) bool {
+	if stat, err := f.Stat(); err != nil {
+		return false
+	} else {
+		return stat.Mode()&os.ModeCharDevice != 0
+	}
+}
+
+// splitTags returns tag filters from name=value pairs
+func splitTags(value string) ([]gopi.Field, error) {
+	result := []gopi.Field{}
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		} else if kv := strings.SplitN(pair, "=", 2); len(kv) != 2 {
+			return nil, gopi.ErrBadParameter.WithPrefix("tag: ", pair)
+		} else if field := metrics.NewField(strings.TrimSpace(kv[0]), kv[1]); field == nil {
+			return nil, gopi.ErrBadParameter.WithPrefix("tag: ", pair)
+		} else {
+			result = append(result, field)
+		}
+	}
+	return result, nil
+}
+
+// formatMeasurement returns a measurement as a single line
+func formatMeasurement(m gopi.Measurement) string {
+	str := m.Time().Format(time.RFC3339) + " " + m.Name()
+	if tags, values := formatFields(m); tags != "" {
+		str += " " + tags + " " + values
+	} else {
+		str += " " + values
+	}
+	return str
+}
+
+// formatFields returns the tags and metrics of a measurement
+func formatFields(m gopi.Measurement) (string, string) {
+	tags := make([]string, 0, len(m.Tags()))
+	for _, field := range m.Tags() {
+		tags = append(tags, fmt.Sprintf("%v=%q", field.Name(), fmt.Sprint(field.Value())))
+	}
+	values := make([]string, 0, len(m.Metrics()))
+	for _, field := range m.Metrics() {
+		values = append(values, fmt.Sprintf("%v=%v", field.Name(), field.Value()))
+	}
+	return strings.Join(tags, " "), strings.Join(values, " ")
+}
@@ -3,6 +3,7 @@ module github.com/djthorpe/gopi/v3
 go 1.13
 
 require (
+	github.com/BurntSushi/toml v0.4.1
 	github.com/djthorpe/data v0.0.1
 	github.com/go-ocf/go-coap v0.0.0-20200511140640-db6048acfdd3
 	github.com/golang/protobuf v1.5.0
@@ -25,5 +26,5 @@ require (
 	google.golang.org/grpc/cmd/protoc-gen-go-grpc v1.1.0 // indirect
 	google.golang.org/protobuf v1.26.0
 	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200605160147-a5ece683394c // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
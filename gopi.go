@@ -3,6 +3,7 @@ package gopi
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"testing"
@@ -32,6 +33,10 @@ type Config interface {
 	// Get command from provided arguments
 	GetCommand([]string) (Command, error)
 
+	// Define dynamic shell completion for a command's arguments
+	// or for a flag value, where the flag name is prefixed by "-"
+	Complete(string, CompleteFunc) error
+
 	// Get flag values
 	GetString(string) string
 	GetBool(string) bool
@@ -44,6 +49,10 @@ type Config interface {
 // CommandFunc is the function signature for running a command
 type CommandFunc func(context.Context) error
 
+// CompleteFunc returns candidates for shell completion, given the
+// arguments which follow the command
+type CompleteFunc func(context.Context, []string) ([]string, error)
+
 // Command is determined from parsed arguments
 type Command interface {
 	Name() string              // Return command name
@@ -69,11 +78,49 @@ type Logger interface {
 	T() *testing.T                          // When testing, provides testing context
 }
 
+// LevelLogger outputs messages at a level with key-value fields, which
+// are pairs of arguments where the key is a string
+type LevelLogger interface {
+	Logger
+
+	Log(LogLevel, string, ...interface{}) // Output message at a level
+	Error(string, ...interface{})         // Output error message
+	Warn(string, ...interface{})          // Output warning message
+	Info(string, ...interface{})          // Output information message
+	Trace(string, ...interface{})         // Output trace message
+	IsLevel(LogLevel) bool                // Returns true if messages at a level are output
+	With(...interface{}) LevelLogger      // Return a logger which adds key-value fields
+	Named(string) LevelLogger             // Return a logger with a name prefix
+}
+
 // Event is an emitted event
 type Event interface {
 	Name() string // Return name of the event
 }
 
+// RestartEvent is emitted when a unit Run function ends and is
+// restarted, or when the unit gives up restarting
+type RestartEvent interface {
+	Event
+
+	Err() error           // Error returned from Run, or nil
+	Attempt() uint        // Number of restarts so far
+	Delay() time.Duration // Delay before the Run function is restarted
+	Stopped() bool        // True when the unit will not be restarted
+}
+
+// HealthChecker is optionally implemented by units which can report
+// whether they are working. Health returns nil when the unit is healthy
+type HealthChecker interface {
+	Health(context.Context) error
+}
+
+// Reloader is optionally implemented by units which can apply changes
+// to their configuration without restarting the process
+type Reloader interface {
+	Reload(Config) error
+}
+
 // Publisher emits events and allows for subscribing to emitted events
 type Publisher interface {
 	// Emit an event, which can block if second argument is true
@@ -82,14 +129,45 @@ type Publisher interface {
 	// Subscribe to events
 	Subscribe() <-chan Event
 
+	// SubscribeName subscribes to events with any of the given names
+	SubscribeName(...string) <-chan Event
+
+	// SubscribeType subscribes to events with the type of any of the
+	// given values. A nil pointer to an interface, for example
+	// (*gopi.CastEvent)(nil), matches events which implement it
+	SubscribeType(...interface{}) <-chan Event
+
 	// Unsubscribe from events
 	Unsubscribe(<-chan Event)
 }
 
+// EventRecorder writes events emitted by the Publisher to a stream,
+// and replays recorded events into the Publisher
+type EventRecorder interface {
+	// Record writes events until the context is cancelled
+	Record(context.Context, io.Writer) error
+
+	// Replay emits recorded events, with the interval between events
+	// divided by the speed, or without delay when the speed is zero
+	Replay(context.Context, io.Reader, float64) error
+}
+
 // Promises runs chains of events in the background
 type Promises interface {
 	// Create a promise with a function
 	Do(context.Context, func(context.Context, interface{}) (interface{}, error), interface{}) Promise
+
+	// All runs promises in parallel and resolves with a []interface{} of
+	// their values in order. The first error cancels the other promises
+	All(context.Context, ...Promise) Promise
+
+	// Any runs promises in parallel and resolves with the value of the first
+	// to succeed, cancelling the others. It fails if all promises fail
+	Any(context.Context, ...Promise) Promise
+
+	// Race runs promises in parallel and resolves or fails with the first
+	// to complete, cancelling the others
+	Race(context.Context, ...Promise) Promise
 }
 
 // Promise is run in a chain, and finally calls given function
@@ -97,9 +175,126 @@ type Promise interface {
 	// Chain a function to a promise
 	Then(func(context.Context, interface{}) (interface{}, error)) Promise
 
+	// Timeout sets a deadline for each call of the last function in the chain
+	Timeout(time.Duration) Promise
+
+	// Retry calls the last function in the chain again when it fails
+	Retry(PromiseRetry) Promise
+
 	// Finally runs the promise in the background and optionally waits for it to complete
-	// then returns any error if not running in background
+	// then returns any error if not running in background. A promise can only be run once
 	Finally(func(interface{}, error) error, bool) error
+
+	// Cancel stops a running promise, which then finally fails with
+	// context.Canceled
+	Cancel()
+}
+
+// PromiseRetry is the policy for retrying a function in a promise chain. The
+// delay between attempts doubles after each attempt up to MaxDelay
+type PromiseRetry struct {
+	Attempts uint             // Maximum number of attempts, or zero for no limit
+	Delay    time.Duration    // Delay before the first retry, at least 10ms when Attempts is zero
+	MaxDelay time.Duration    // Maximum delay between attempts, or zero for no limit
+	Retry    func(error) bool // Return false for errors which are not retried, or nil to retry all errors
+}
+
+// Scheduler emits events or calls functions at scheduled times. When
+// the function is nil, a SchedulerEvent with the name of the job is
+// emitted on the Publisher instead
+type Scheduler interface {
+	// Cron schedules a job with a cron expression of five fields (minute,
+	// hour, day of month, month and day of week) or a descriptor such
+	// as @daily
+	Cron(string, string, SchedulerFunc) error
+
+	// Interval schedules a job with an interval, and a random delay of up
+	// to the jitter duration added to each interval
+	Interval(string, time.Duration, time.Duration, SchedulerFunc) error
+
+	// Solar schedules a job each day at a solar event for the configured
+	// location, offset by a duration
+	Solar(string, SolarEvent, time.Duration, SchedulerFunc) error
+
+	// Remove a job by name
+	Remove(string) error
+
+	// Next returns the time a job runs next, or zero time if
+	// the job does not exist or never runs
+	Next(string) time.Time
+}
+
+// SchedulerFunc is called when a job is run
+type SchedulerFunc func(context.Context, SchedulerEvent) error
+
+// SchedulerEvent is emitted or passed to a function when a job is run
+type SchedulerEvent interface {
+	Event
+
+	Time() time.Time // Time returns the time the job was scheduled to run
+	Missed() bool    // Missed returns true when a job missed while not running is caught up
+}
+
+/////////////////////////////////////////////////////////////////////
+// LOG LEVELS
+
+// LogLevel is the severity of a log message
+type LogLevel uint
+
+const (
+	LOG_NONE LogLevel = iota
+	LOG_ERROR
+	LOG_WARN
+	LOG_INFO
+	LOG_DEBUG
+	LOG_TRACE
+)
+
+func (v LogLevel) String() string {
+	switch v {
+	case LOG_NONE:
+		return "none"
+	case LOG_ERROR:
+		return "error"
+	case LOG_WARN:
+		return "warn"
+	case LOG_INFO:
+		return "info"
+	case LOG_DEBUG:
+		return "debug"
+	case LOG_TRACE:
+		return "trace"
+	default:
+		return "[?? Invalid LogLevel value]"
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// SOLAR EVENTS
+
+// SolarEvent is a daily event determined by the position of the sun
+type SolarEvent uint
+
+const (
+	SOLAR_SUNRISE    SolarEvent = iota // Sun appears above the horizon
+	SOLAR_SUNSET                       // Sun disappears below the horizon
+	SOLAR_CIVIL_DAWN                   // Sun is six degrees below the horizon before sunrise
+	SOLAR_CIVIL_DUSK                   // Sun is six degrees below the horizon after sunset
+)
+
+func (v SolarEvent) String() string {
+	switch v {
+	case SOLAR_SUNRISE:
+		return "SOLAR_SUNRISE"
+	case SOLAR_SUNSET:
+		return "SOLAR_SUNSET"
+	case SOLAR_CIVIL_DAWN:
+		return "SOLAR_CIVIL_DAWN"
+	case SOLAR_CIVIL_DUSK:
+		return "SOLAR_CIVIL_DUSK"
+	default:
+		return "[?? Invalid SolarEvent value]"
+	}
 }
 
 /////////////////////////////////////////////////////////////////////
@@ -42,6 +42,13 @@ type Metrics interface {
 	EnvTag(string) Field
 }
 
+// MetricCollector periodically emits measurements for the host system,
+// such as load averages, memory, disk and network usage
+type MetricCollector interface {
+	// Collect emits measurements from the enabled collectors
+	Collect() error
+}
+
 // MetricWriter implements a database writing object
 type MetricWriter interface {
 	Ping() (time.Duration, error) // Ping the database and return latency
@@ -55,11 +62,34 @@ type MetricReader interface {
 	// NewQuery constructs a query with measurement name and the
 	// names of tags which are grouped. By default, all metrics are returned
 	NewQuery(string, ...string) MetricQuery
+
+	// Query executes a query and returns the data points
+	Query(MetricQuery) ([]Measurement, error)
 }
 
-// MetricQuery constructs queries which can be executed by the MetricReader,
-// TODO methods which modify the basic query
-type MetricQuery interface{}
+// MetricQuery constructs queries which can be executed by the MetricReader.
+// Each method modifies the query and returns it, so calls can be chained
+type MetricQuery interface {
+	// Select the names of metrics returned
+	Select(...string) MetricQuery
+
+	// Where returns data points where a tag has a value
+	Where(string, interface{}) MetricQuery
+
+	// Between returns data points from the first time until, but not
+	// including, the second time. A zero time is unbounded
+	Between(time.Time, time.Time) MetricQuery
+
+	// Since returns data points for a duration until now
+	Since(time.Duration) MetricQuery
+
+	// Aggregate metrics into intervals, or over the whole query when
+	// the interval is zero
+	Aggregate(MetricAggregate, time.Duration) MetricQuery
+
+	// Limit the number of data points returned for each group
+	Limit(uint) MetricQuery
+}
 
 // Measurement is a single data point
 type Measurement interface {
@@ -92,3 +122,90 @@ type Field interface {
 	// Copy returns a copy of the field
 	Copy() Field
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// MetricAggregate is a function which combines the values of a metric
+type MetricAggregate uint
+
+// MetricCounter is the value of a counter metric, which is a monotonic
+// total and the rate of change per second since it was last emitted
+type MetricCounter struct {
+	Total float64 `json:"total"`
+	Rate  float64 `json:"rate"`
+}
+
+// MetricHistogram is the value of a histogram metric. Buckets are
+// cumulative, and observations above the highest bound are only
+// included in the count
+type MetricHistogram struct {
+	Count   uint64         `json:"count"`
+	Sum     float64        `json:"sum"`
+	Buckets []MetricBucket `json:"buckets,omitempty"`
+}
+
+// MetricBucket is the number of observations less than or equal
+// to an upper bound
+type MetricBucket struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+// MetricSummary is the value of a summary metric. Quantiles are
+// calculated from recent observations, and are omitted when there
+// have been no observations
+type MetricSummary struct {
+	Count     uint64           `json:"count"`
+	Sum       float64          `json:"sum"`
+	Quantiles []MetricQuantile `json:"quantiles,omitempty"`
+}
+
+// MetricQuantile is the value for a quantile between zero and one
+type MetricQuantile struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	METRIC_AGGREGATE_NONE   MetricAggregate = iota // Return values without aggregation
+	METRIC_AGGREGATE_MEAN                          // Arithmetic mean of values
+	METRIC_AGGREGATE_MEDIAN                        // Middle value
+	METRIC_AGGREGATE_MIN                           // Lowest value
+	METRIC_AGGREGATE_MAX                           // Highest value
+	METRIC_AGGREGATE_SUM                           // Sum of values
+	METRIC_AGGREGATE_COUNT                         // Number of values
+	METRIC_AGGREGATE_FIRST                         // Value with the oldest timestamp
+	METRIC_AGGREGATE_LAST                          // Value with the most recent timestamp
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (v MetricAggregate) String() string {
+	switch v {
+	case METRIC_AGGREGATE_NONE:
+		return "METRIC_AGGREGATE_NONE"
+	case METRIC_AGGREGATE_MEAN:
+		return "METRIC_AGGREGATE_MEAN"
+	case METRIC_AGGREGATE_MEDIAN:
+		return "METRIC_AGGREGATE_MEDIAN"
+	case METRIC_AGGREGATE_MIN:
+		return "METRIC_AGGREGATE_MIN"
+	case METRIC_AGGREGATE_MAX:
+		return "METRIC_AGGREGATE_MAX"
+	case METRIC_AGGREGATE_SUM:
+		return "METRIC_AGGREGATE_SUM"
+	case METRIC_AGGREGATE_COUNT:
+		return "METRIC_AGGREGATE_COUNT"
+	case METRIC_AGGREGATE_FIRST:
+		return "METRIC_AGGREGATE_FIRST"
+	case METRIC_AGGREGATE_LAST:
+		return "METRIC_AGGREGATE_LAST"
+	default:
+		return "[?? Invalid MetricAggregate value]"
+	}
+}
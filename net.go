@@ -167,12 +167,54 @@ type MetricsStub interface {
 	// List returns the array of defined measurements
 	List(context.Context) ([]Measurement, error)
 
-	// Stream emits measurements defined by name filter on
+	// Query returns recent measurements retained by the server which
+	// match the name and tag values, from the first time until, but not
+	// including, the second time. An empty name matches all measurements
+	// and a zero time is unbounded
+	Query(context.Context, string, []Field, time.Time, time.Time) ([]Measurement, error)
+
+	// Stream emits measurements defined by name and tag filter on
 	// the provided channel until context is cancelled. Where
 	// the name filter is empty, all measurements are emitted
-	Stream(context.Context, string, chan<- Measurement) error
+	Stream(context.Context, string, []Field, chan<- Measurement) error
+}
+
+type EventsService interface {
+	Service
+}
+
+type EventsStub interface {
+	ServiceStub
+
+	// Types returns the names of event codecs supported by the server
+	Types(context.Context) ([]string, error)
+
+	// Stream emits remote events which match the name and codec type
+	// filters on the provided channel until context is cancelled. Where
+	// a filter is empty, all events are emitted
+	Stream(context.Context, []string, []string, chan<- Event) error
+
+	// Emit re-emits remote events which match the name and codec type
+	// filters on a local publisher until context is cancelled
+	Emit(context.Context, Publisher, []string, []string) error
+}
+
+// MQTTBridge publishes events and measurements to an MQTT broker,
+// and calls handlers for messages received on command topics
+type MQTTBridge interface {
+	// Publish sends a payload to a topic relative to the topic prefix,
+	// which is retained by the broker if the last argument is true
+	Publish(string, []byte, bool) error
+
+	// Handle registers a function which is called for messages on
+	// a topic relative to the topic prefix. The topic can include
+	// + and # wildcards
+	Handle(string, MQTTHandler) error
 }
 
+// MQTTHandler is called with the topic and payload of a message
+type MQTTHandler func(context.Context, string, []byte) error
+
 /////////////////////////////////////////////////////////////////////
 // HTTP SERVICES
 
@@ -206,6 +248,12 @@ type HttpLogger interface {
 	Log(string) error
 }
 
+// HttpMetrics renders measurements in Prometheus text format
+type HttpMetrics interface {
+	// Serve metrics with URL as "path", usually /metrics
+	Serve(string) error
+}
+
 // HttpRenderer returns content to process with template
 // for a request
 type HttpRenderer interface {
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// completion holds the words on the command line when the shell requests
+// completion candidates
+type completion struct {
+	words []string   // words before the word being completed
+	word  string     // the word being completed
+	flag  *flag.Flag // flag whose value is being completed, or nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// CompletionCommand is the hidden command which writes a completion
+	// script for a shell
+	CompletionCommand = "completion"
+
+	// completeCommand is the hidden command which is called by the
+	// completion script to return candidates
+	completeCommand = "__complete"
+)
+
+var (
+	reFuncName = regexp.MustCompile("[^A-Za-z0-9_]")
+)
+
+var scripts = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`# bash completion for {{ .Name }}
+_{{ .Func }}_complete() {
+	local IFS=$'\n'
+	COMPREPLY=($("${COMP_WORDS[0]}" ` + completeCommand + ` "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _{{ .Func }}_complete {{ .Name }}
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`#compdef {{ .Name }}
+_{{ .Func }}_complete() {
+	local -a candidates
+	candidates=("${(@f)$("${words[1]}" ` + completeCommand + ` "${(@)words[2,$CURRENT]}" 2>/dev/null)}")
+	if [[ -n "${candidates[1]}" ]]; then
+		compadd -- "${candidates[@]}"
+	else
+		_files
+	fi
+}
+compdef _{{ .Func }}_complete {{ .Name }}
+`)),
+	"fish": template.Must(template.New("fish").Parse(`# fish completion for {{ .Name }}
+function __{{ .Func }}_complete
+	set -l words (commandline -opc) (commandline -ct)
+	$words[1] ` + completeCommand + ` $words[2..-1] 2>/dev/null
+end
+complete -c {{ .Name }} -f -a '(__{{ .Func }}_complete)'
+`)),
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Complete defines a function which returns completion candidates for
+// the arguments of a command, or for the value of a flag when the name
+// is prefixed by "-"
+func (this *config) Complete(name string, fn gopi.CompleteFunc) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "-" || fn == nil {
+		return gopi.ErrBadParameter.WithPrefix("Complete")
+	}
+	if _, exists := this.hooks[name]; exists {
+		return gopi.ErrDuplicateEntry.WithPrefix("Complete ", name)
+	}
+	this.hooks[name] = fn
+	return nil
+}
+
+// WriteCompletionScript writes a completion script when the arguments
+// are "completion <shell>" and returns true, or returns false otherwise.
+// The command is not shown in usage, and is not available when a
+// "completion" command has been defined
+func (this *config) WriteCompletionScript(w io.Writer) (bool, error) {
+	args := this.FlagSet.Args()
+	if this.complete != nil || len(args) == 0 || args[0] != CompletionCommand {
+		return false, nil
+	} else if getCommand(CompletionCommand, this.commands.commands) != nil {
+		return false, nil
+	}
+
+	shells := make([]string, 0, len(scripts))
+	for shell := range scripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+
+	if len(args) != 2 {
+		return true, gopi.ErrBadParameter.WithPrefix(CompletionCommand, ": ", strings.Join(shells, ", "))
+	} else if tmpl, exists := scripts[args[1]]; exists == false {
+		return true, gopi.ErrBadParameter.WithPrefix(CompletionCommand, ": ", strings.Join(shells, ", "))
+	} else {
+		name := this.FlagSet.Name()
+		return true, tmpl.Execute(w, map[string]string{
+			"Name": name,
+			"Func": reFuncName.ReplaceAllString(name, "_"),
+		})
+	}
+}
+
+// WriteCompletions writes completion candidates, one per line, when called
+// from a completion script and returns true, or returns false otherwise.
+// Commands and flags are completed without calling setup. When a
+// function has been defined for the command arguments or flag value,
+// setup is called first so that the function can use units
+func (this *config) WriteCompletions(ctx context.Context, w io.Writer, setup func() error) (bool, error) {
+	if this.complete == nil {
+		return false, nil
+	}
+
+	// Gather candidates
+	candidates, hook, args := this.candidates()
+	if hook != nil {
+		if err := setup(); err != nil {
+			return true, err
+		} else if values, err := hook(ctx, args); err != nil {
+			return true, err
+		} else {
+			candidates = append(candidates, values...)
+		}
+	}
+
+	// Write candidates which match the word being completed
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, this.complete.word) {
+			if _, err := fmt.Fprintln(w, candidate); err != nil {
+				return true, err
+			}
+		}
+	}
+
+	// Return success
+	return true, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// newCompletion returns completion state when the arguments start with
+// the hidden complete command, or nil otherwise
+func newCompletion(args []string) *completion {
+	if len(args) == 0 || args[0] != completeCommand {
+		return nil
+	}
+	this := new(completion)
+	if args = args[1:]; len(args) > 0 {
+		this.words, this.word = args[:len(args)-1], args[len(args)-1]
+	} else {
+		this.words = []string{}
+	}
+	return this
+}
+
+// parseComplete parses the words before the word being completed, so
+// that flags are set and the command can be determined. Errors are
+// ignored since the command line is incomplete
+func (this *config) parseComplete() error {
+	words := this.complete.words
+	if n := len(words); n > 0 {
+		if f := this.lookupValueFlag(words[n-1]); f != nil {
+			this.complete.flag, words = f, words[:n-1]
+		}
+	}
+	this.args = words
+	this.FlagSet.SetOutput(ioutil.Discard)
+	this.setFromSources(this.FlagSet, this.sources)
+	this.FlagSet.Parse(words)
+	return nil
+}
+
+// lookupValueFlag returns a flag for an argument which requires a value
+// in the following argument, or nil otherwise
+func (this *config) lookupValueFlag(arg string) *flag.Flag {
+	if strings.HasPrefix(arg, "-") == false || strings.Contains(arg, "=") {
+		return nil
+	} else if f := this.FlagSet.Lookup(strings.TrimLeft(arg, "-")); f == nil {
+		return nil
+	} else if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return nil
+	} else {
+		return f
+	}
+}
+
+// candidates returns the static candidates for the word being completed,
+// and any completion function with the arguments to call it with
+func (this *config) candidates() ([]string, gopi.CompleteFunc, []string) {
+	// Complete flag value
+	if f := this.complete.flag; f != nil {
+		return nil, this.hooks["-"+f.Name], []string{}
+	}
+
+	// Determine command from arguments
+	cmd, path, args := this.commands, []string{}, this.FlagSet.Args()
+	for len(args) > 0 {
+		if child := getCommand(args[0], cmd.commands); child == nil {
+			break
+		} else {
+			cmd, path, args = child, append(path, child.name), args[1:]
+		}
+	}
+	name := strings.Join(path, " ")
+
+	// Complete flags for the command
+	if strings.HasPrefix(this.complete.word, "-") {
+		candidates := []string{}
+		this.FlagSet.VisitAll(func(f *flag.Flag) {
+			if this.flagIsGlobal(f) || (name != "" && this.flagIsLocal(f, name)) {
+				candidates = append(candidates, "-"+f.Name)
+			}
+		})
+		return candidates, nil, nil
+	}
+
+	// Complete subcommands and command arguments
+	candidates := []string{}
+	if len(args) == 0 {
+		for _, child := range cmd.commands {
+			candidates = append(candidates, child.name)
+		}
+	}
+	if name == "" {
+		return candidates, nil, nil
+	} else {
+		return candidates, this.hooks[name], args
+	}
+}
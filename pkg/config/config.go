@@ -4,8 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/djthorpe/gopi/v3"
@@ -16,10 +18,14 @@ import (
 // TYPES
 
 type config struct {
+	sync.RWMutex
 	*flag.FlagSet
 	args     []string
 	commands *command
 	flags    map[string][]string
+	sources  map[string]source
+	hooks    map[string]gopi.CompleteFunc
+	complete *completion
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -37,7 +43,14 @@ func New(name string, args []string) gopi.Config {
 	}
 	this.args = args
 	this.flags = make(map[string][]string)
+	this.sources = make(map[string]source)
+	this.hooks = make(map[string]gopi.CompleteFunc)
+	this.complete = newCompletion(args)
 	this.commands = NewCommand(name, "", "", args, nil)
+
+	// Define flag for configuration file
+	this.FlagString(ConfigFlag, "", "Configuration file (yaml, json or toml)")
+
 	return this
 }
 
@@ -54,15 +67,46 @@ func (this *config) Parse() error {
 		return nil
 	}
 
-	// Perform parse and translate error
-	if err := this.FlagSet.Parse(this.args); err == flag.ErrHelp {
-		return gopi.ErrHelp
-	} else if err != nil {
-		return err
+	// Set values and return any errors
+	return this.parse()
+}
+
+// Reload sets flags again from the configuration file, environment and
+// command-line arguments. Values are parsed into a new set of flags, which
+// replaces the current set only when every value parses. Values referenced
+// by pointers returned when flags were defined are never changed, so units
+// should read updated values from the returned configuration in their Reload
+// method, or with the Get methods, which are synchronised
+func (this *config) Reload() (gopi.Config, error) {
+	// Check for not parsed
+	if this.FlagSet.Parsed() == false {
+		return nil, gopi.ErrOutOfOrder.WithPrefix("Reload")
 	}
 
-	// Return success
-	return nil
+	// Parse values into new flags
+	flags := this.newFlagSet()
+	sources := make(map[string]source)
+	if err := this.parseFlags(flags, sources); err != nil {
+		return nil, err
+	}
+	flags.SetOutput(this.FlagSet.Output())
+	flags.Usage = this.FlagSet.Usage
+
+	// Swap in the new flags
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+	this.FlagSet = flags
+	this.sources = sources
+
+	// Return a snapshot of the new configuration
+	return &config{
+		FlagSet:  flags,
+		args:     this.args,
+		commands: this.commands,
+		flags:    this.flags,
+		sources:  sources,
+		hooks:    this.hooks,
+	}, nil
 }
 
 // Usage prints out the command usage instructions for all
@@ -189,6 +233,9 @@ func (this *config) FlagFloat(name string, value float64, usage string, cmds ...
 // GET PROPERTIES
 
 func (this *config) GetString(name string) string {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
 	if flag := this.FlagSet.Lookup(name); flag == nil {
 		return ""
 	} else {
@@ -197,6 +244,9 @@ func (this *config) GetString(name string) string {
 }
 
 func (this *config) GetBool(name string) bool {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
 	if flag := this.FlagSet.Lookup(name); flag == nil {
 		return false
 	} else if value_, err := strconv.ParseBool(flag.Value.String()); err != nil {
@@ -207,6 +257,9 @@ func (this *config) GetBool(name string) bool {
 }
 
 func (this *config) GetUint(name string) uint {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
 	if flag := this.FlagSet.Lookup(name); flag == nil {
 		return 0
 	} else if value_, err := strconv.ParseUint(flag.Value.String(), 0, 64); err != nil {
@@ -217,6 +270,9 @@ func (this *config) GetUint(name string) uint {
 }
 
 func (this *config) GetFloat(name string) float64 {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
 	if flag := this.FlagSet.Lookup(name); flag == nil {
 		return 0
 	} else if value_, err := strconv.ParseFloat(flag.Value.String(), 64); err != nil {
@@ -227,6 +283,9 @@ func (this *config) GetFloat(name string) float64 {
 }
 
 func (this *config) GetInt(name string) int {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
 	if flag := this.FlagSet.Lookup(name); flag == nil {
 		return 0
 	} else if value_, err := strconv.ParseInt(flag.Value.String(), 0, 64); err != nil {
@@ -237,6 +296,9 @@ func (this *config) GetInt(name string) int {
 }
 
 func (this *config) GetDuration(name string) time.Duration {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
 	if flag := this.FlagSet.Lookup(name); flag == nil {
 		return 0
 	} else if value_, err := time.ParseDuration(flag.Value.String()); err != nil {
@@ -261,6 +323,74 @@ func (this *config) String() string {
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// parse sets values from the configuration file, environment and
+// command-line arguments
+func (this *config) parse() error {
+	// Parse the incomplete command line when completing
+	if this.complete != nil {
+		return this.parseComplete()
+	}
+
+	// Set values and record where they came from
+	return this.parseFlags(this.FlagSet, this.sources)
+}
+
+// parseFlags sets values in a set of flags from the configuration file,
+// environment and command-line arguments, and records where values which
+// are set came from
+func (this *config) parseFlags(flags *flag.FlagSet, sources map[string]source) error {
+	// Set values from configuration file and environment, which are
+	// overridden by command-line flags
+	if err := this.setFromSources(flags, sources); err != nil {
+		return err
+	}
+
+	// Perform parse and translate error
+	if err := flags.Parse(this.args); err == flag.ErrHelp {
+		return gopi.ErrHelp
+	} else if err != nil {
+		return err
+	}
+
+	// Record flags set on the command line
+	flags.Visit(func(f *flag.Flag) {
+		sources[f.Name] = source{"flag", ""}
+	})
+
+	// Return success
+	return nil
+}
+
+// newFlagSet returns a set of flags with the same names, types and
+// default values as the defined flags, for parsing values without
+// changing the defined flags
+func (this *config) newFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet(this.FlagSet.Name(), flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	this.FlagSet.VisitAll(func(f *flag.Flag) {
+		var value interface{}
+		if getter, ok := f.Value.(flag.Getter); ok {
+			value = getter.Get()
+		}
+		switch value.(type) {
+		case bool:
+			flags.Bool(f.Name, false, f.Usage)
+		case uint:
+			flags.Uint(f.Name, 0, f.Usage)
+		case int:
+			flags.Int(f.Name, 0, f.Usage)
+		case time.Duration:
+			flags.Duration(f.Name, 0, f.Usage)
+		case float64:
+			flags.Float64(f.Name, 0, f.Usage)
+		default:
+			flags.String(f.Name, "", f.Usage)
+		}
+		flags.Lookup(f.Name).Value.Set(f.DefValue)
+	})
+	return flags
+}
+
 func (this *config) usageAll() {
 	w := this.FlagSet.Output()
 	name := this.FlagSet.Name()
@@ -324,10 +454,13 @@ func (this *config) usageFlags(name string) {
 		fmt.Fprintf(w, "\nFlags for %q:\n", name)
 	}
 
-	// Print flags
+	// Print flags, and where the value came from when not the default
 	for _, flag := range flags {
 		arg, usage, def := flagUsage(flag)
 		fmt.Fprintf(w, "  -%v %v\n  \t%v %v\n", flag.Name, arg, usage, def)
+		if src, exists := this.sources[flag.Name]; exists {
+			fmt.Fprintf(w, "  \t(value %q from %v)\n", flag.Value.String(), src)
+		}
 	}
 }
 
@@ -1,7 +1,13 @@
 package config_test
 
 import (
+	"context"
 	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/djthorpe/gopi/v3"
@@ -193,3 +199,248 @@ func Test_Config_008(t *testing.T) {
 		t.Log(cmd)
 	}
 }
+
+func Test_Config_009(t *testing.T) {
+	files := map[string]string{
+		"gopi.yaml": "rotel:\n  tty: /dev/file\n  baud: 9600\nssl.cert: file.crt\nssl.key: file.key\n",
+		"gopi.json": `{ "rotel": { "tty": "/dev/file", "baud": 9600 }, "ssl.cert": "file.crt", "ssl.key": "file.key" }`,
+		"gopi.toml": "\"ssl.cert\" = \"file.crt\"\n\"ssl.key\" = \"file.key\"\n[rotel]\ntty = \"/dev/file\"\nbaud = 9600\n",
+	}
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+	os.Setenv("GOPI_SSL_KEY", "env.key")
+	defer os.Unsetenv("GOPI_SSL_KEY")
+	for name, data := range files {
+		path := filepath.Join(tempdir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg := config.New(t.Name(), []string{"-config", path, "-ssl.cert", "flag.crt"})
+		tty := cfg.FlagString("rotel.tty", "/dev/ttyUSB0", "TTY")
+		baud := cfg.FlagUint("rotel.baud", 115200, "Baud")
+		cfg.FlagString("ssl.cert", "", "Certificate")
+		cfg.FlagString("ssl.key", "", "Key")
+		cfg.FlagBool("debug", false, "Debug")
+		if err := cfg.Parse(); err != nil {
+			t.Fatal(name, err)
+		}
+		if *tty != "/dev/file" || *baud != 9600 {
+			t.Error(name, "Unexpected file values:", *tty, *baud)
+		}
+		if value := cfg.GetString("ssl.key"); value != "env.key" {
+			t.Error(name, "Unexpected env value:", value)
+		}
+		if value := cfg.GetString("ssl.cert"); value != "flag.crt" {
+			t.Error(name, "Unexpected flag value:", value)
+		}
+		if cfg.GetBool("debug") != false {
+			t.Error(name, "Unexpected default value")
+		}
+	}
+}
+
+func Test_Config_010(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+	path := filepath.Join(tempdir, "gopi.ini")
+	if err := ioutil.WriteFile(path, []byte("debug=true"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New(t.Name(), []string{"-config=" + path})
+	if err := cfg.Parse(); errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	}
+
+	os.Setenv("GOPI_RETRIES", "many")
+	defer os.Unsetenv("GOPI_RETRIES")
+	cfg = config.New(t.Name(), nil)
+	cfg.FlagUint("retries", 0, "Retries")
+	if err := cfg.Parse(); err == nil {
+		t.Error("Expected error for bad environment value")
+	}
+}
+
+func Test_Config_011(t *testing.T) {
+	type reloader interface {
+		Reload() (gopi.Config, error)
+	}
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+	path := filepath.Join(tempdir, "gopi.yaml")
+	if err := ioutil.WriteFile(path, []byte("rotel.tty: /dev/ttyUSB1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New(t.Name(), []string{"-config", path})
+	tty := cfg.FlagString("rotel.tty", "/dev/ttyUSB0", "TTY")
+	if _, err := cfg.(reloader).Reload(); errors.Is(err, gopi.ErrOutOfOrder) == false {
+		t.Error("Expected out of order error, got:", err)
+	} else if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	} else if *tty != "/dev/ttyUSB1" {
+		t.Error("Unexpected value:", *tty)
+	}
+
+	// Values which are removed from the file revert to defaults
+	if err := ioutil.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	} else if snapshot, err := cfg.(reloader).Reload(); err != nil {
+		t.Fatal(err)
+	} else if value := snapshot.GetString("rotel.tty"); value != "/dev/ttyUSB0" {
+		t.Error("Unexpected value:", value)
+	}
+}
+
+func Test_Config_012(t *testing.T) {
+	type completer interface {
+		WriteCompletionScript(io.Writer) (bool, error)
+		WriteCompletions(context.Context, io.Writer, func() error) (bool, error)
+	}
+	tests := []struct {
+		args   []string
+		expect string
+		setup  bool
+	}{
+		{[]string{"__complete", ""}, "cast\nrotel\n", false},
+		{[]string{"__complete", "r"}, "rotel\n", false},
+		{[]string{"__complete", "cast", ""}, "connect\nvol\n", false},
+		{[]string{"__complete", "cast", "connect", "l"}, "living room\n", true},
+		{[]string{"__complete", "cast", "connect", "living room", ""}, "", true},
+		{[]string{"__complete", "-"}, "-config\n-srv\n", false},
+		{[]string{"__complete", "cast", "vol", "-"}, "-config\n-srv\n", false},
+		{[]string{"__complete", "-srv", ""}, "a\nb\n", true},
+		{[]string{"__complete", "-srv", "a", "rotel", ""}, "", false},
+	}
+	for _, test := range tests {
+		setup := false
+		cfg := config.New(t.Name(), test.args)
+		cfg.FlagString("srv", "", "Service")
+		cfg.Command("cast", "Cast", nil)
+		cfg.Command("cast connect", "Connect", nil)
+		cfg.Command("cast vol", "Volume", nil)
+		cfg.Command("rotel", "Rotel", nil)
+		cfg.Complete("cast connect", func(_ context.Context, args []string) ([]string, error) {
+			if len(args) == 0 {
+				return []string{"living room", "kitchen"}, nil
+			}
+			return nil, nil
+		})
+		cfg.Complete("-srv", func(context.Context, []string) ([]string, error) {
+			return []string{"a", "b"}, nil
+		})
+		if err := cfg.Parse(); err != nil {
+			t.Error(err)
+		}
+		var buf strings.Builder
+		if done, err := cfg.(completer).WriteCompletionScript(&buf); done || err != nil {
+			t.Error("Unexpected completion script", test.args)
+		} else if done, err := cfg.(completer).WriteCompletions(context.Background(), &buf, func() error {
+			setup = true
+			return nil
+		}); done == false || err != nil {
+			t.Error("Unexpected return value", test.args, err)
+		} else if buf.String() != test.expect {
+			t.Errorf("%q: Unexpected candidates %q", test.args, buf.String())
+		} else if setup != test.setup {
+			t.Errorf("%q: Unexpected setup %v", test.args, setup)
+		}
+	}
+
+	// Write completion scripts
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var buf strings.Builder
+		cfg := config.New("rpc-tool", []string{"completion", shell})
+		if err := cfg.Parse(); err != nil {
+			t.Error(err)
+		} else if done, err := cfg.(completer).WriteCompletionScript(&buf); done == false || err != nil {
+			t.Error("Unexpected return value", shell, err)
+		} else if strings.Contains(buf.String(), "_rpc_tool_complete") == false {
+			t.Error("Unexpected script", buf.String())
+		}
+	}
+	cfg := config.New(t.Name(), []string{"completion", "csh"})
+	if err := cfg.Parse(); err != nil {
+		t.Error(err)
+	} else if done, err := cfg.(completer).WriteCompletionScript(ioutil.Discard); done == false || errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	}
+}
+
+func Test_Config_013(t *testing.T) {
+	type reloader interface {
+		Reload() (gopi.Config, error)
+	}
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+	path := filepath.Join(tempdir, "gopi.yaml")
+	if err := ioutil.WriteFile(path, []byte("rotel.tty: /dev/ttyUSB1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New(t.Name(), []string{"-config", path, "-srv", "host"})
+	srv := cfg.FlagString("srv", "", "Server")
+	tty := cfg.FlagString("rotel.tty", "/dev/ttyUSB0", "TTY")
+	if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Values are not changed when the file cannot be parsed
+	if err := ioutil.WriteFile(path, []byte("rotel: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	} else if _, err := cfg.(reloader).Reload(); err == nil {
+		t.Error("Expected error for bad configuration file")
+	} else if cfg.GetString("srv") != "host" || cfg.GetString("rotel.tty") != "/dev/ttyUSB1" {
+		t.Error("Unexpected values:", cfg.GetString("srv"), cfg.GetString("rotel.tty"))
+	}
+
+	// Values are changed when the file is valid, and flags override the file,
+	// but values referenced by pointers are not changed
+	if err := ioutil.WriteFile(path, []byte("rotel.tty: /dev/ttyUSB2\nsrv: other\n"), 0644); err != nil {
+		t.Fatal(err)
+	} else if snapshot, err := cfg.(reloader).Reload(); err != nil {
+		t.Fatal(err)
+	} else if snapshot.GetString("srv") != "host" || snapshot.GetString("rotel.tty") != "/dev/ttyUSB2" {
+		t.Error("Unexpected values:", snapshot.GetString("srv"), snapshot.GetString("rotel.tty"))
+	} else if cfg.GetString("rotel.tty") != "/dev/ttyUSB2" {
+		t.Error("Unexpected value:", cfg.GetString("rotel.tty"))
+	} else if *srv != "host" || *tty != "/dev/ttyUSB1" {
+		t.Error("Unexpected values:", *srv, *tty)
+	}
+}
+
+func Test_Config_014(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+	path := filepath.Join(tempdir, "gopi.yaml")
+	if err := ioutil.WriteFile(path, []byte("rotel.tty: /dev/ttyUSB1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-srv", "host", "-config", path},
+		{"-v", "-srv=host", "--config", path, "cmd"},
+		{"-srv", "-config", "-v", "-config=" + path},
+	} {
+		cfg := config.New(t.Name(), args)
+		cfg.FlagString("srv", "", "Server")
+		cfg.FlagBool("v", false, "Verbose")
+		tty := cfg.FlagString("rotel.tty", "/dev/ttyUSB0", "TTY")
+		if err := cfg.Parse(); err != nil {
+			t.Error(args, err)
+		} else if *tty != "/dev/ttyUSB1" {
+			t.Error(args, "Unexpected value:", *tty)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/djthorpe/gopi/v3"
+	"github.com/hashicorp/go-multierror"
+	"gopkg.in/yaml.v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// source is where the effective value of a flag came from
+type source struct {
+	name  string // flag, env or file
+	value string // name of environment variable or path of file
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// EnvPrefix is the prefix for environment variables which set
+	// flag values, for example GOPI_ROTEL_TTY sets -rotel.tty
+	EnvPrefix = "GOPI_"
+
+	// ConfigFlag is the flag which sets the path to a configuration file
+	ConfigFlag = "config"
+)
+
+var (
+	envReplacer = strings.NewReplacer(".", "_", "-", "_")
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// EnvName returns the environment variable name for a flag
+func EnvName(name string) string {
+	return EnvPrefix + strings.ToUpper(envReplacer.Replace(name))
+}
+
+// LookupValue returns the value for a name from the command-line arguments,
+// environment or configuration file, in that order of precedence, and
+// whether a value was found. The name does not need to be defined as a flag,
+// so values can be read before flags are defined and parsed
+func (this *config) LookupValue(name string) (string, bool, error) {
+	// Command-line arguments
+	if value, exists := this.argValue(name); exists {
+		return value, true, nil
+	}
+
+	// Environment
+	if value, exists := os.LookupEnv(EnvName(name)); exists {
+		return value, true, nil
+	}
+
+	// Configuration file
+	if path := this.configPath(); path == "" {
+		return "", false, nil
+	} else if values, err := readConfigFile(path); err != nil {
+		return "", false, err
+	} else {
+		value, exists := values[name]
+		return value, exists, nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// setFromSources sets flag values from a configuration file and then
+// from environment variables, so that environment variables take
+// precedence. Values are set before command-line flags are parsed,
+// so that flags take precedence over both
+func (this *config) setFromSources(flags *flag.FlagSet, sources map[string]source) error {
+	var result error
+
+	// Read configuration file
+	values := map[string]string{}
+	path := this.configPath()
+	if path != "" {
+		if values_, err := readConfigFile(path); err != nil {
+			return err
+		} else {
+			values = values_
+		}
+	}
+
+	// Set flags
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == ConfigFlag {
+			return
+		}
+		if value, exists := os.LookupEnv(EnvName(f.Name)); exists {
+			if err := f.Value.Set(value); err != nil {
+				result = multierror.Append(result, fmt.Errorf("%v: %w", EnvName(f.Name), err))
+			} else {
+				sources[f.Name] = source{"env", EnvName(f.Name)}
+			}
+		} else if value, exists := values[f.Name]; exists {
+			if err := f.Value.Set(value); err != nil {
+				result = multierror.Append(result, fmt.Errorf("%v: %v: %w", path, f.Name, err))
+			} else {
+				sources[f.Name] = source{"file", path}
+			}
+		}
+	})
+
+	// Return any errors
+	return result
+}
+
+// configPath returns the path to the configuration file from the
+// command-line arguments or else from the environment. The arguments
+// for flags which are not boolean are skipped
+func (this *config) configPath() string {
+	for i := 0; i < len(this.args); i++ {
+		arg := this.args[i]
+		if arg == "--" || arg == "-" || strings.HasPrefix(arg, "-") == false {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if strings.HasPrefix(name, ConfigFlag+"=") {
+			return strings.TrimPrefix(name, ConfigFlag+"=")
+		} else if strings.Contains(name, "=") {
+			continue
+		} else if name == ConfigFlag && i+1 < len(this.args) {
+			return this.args[i+1]
+		} else if f := this.FlagSet.Lookup(name); f != nil && isBoolFlag(f) == false {
+			i++
+		}
+	}
+	return os.Getenv(EnvName(ConfigFlag))
+}
+
+// argValue returns the value of a flag from the command-line arguments,
+// either as -name=value or -name value, where the last value is used
+func (this *config) argValue(name string) (string, bool) {
+	value, exists := "", false
+	for i := 0; i < len(this.args); i++ {
+		arg := this.args[i]
+		if arg == "--" {
+			break
+		}
+		key := strings.TrimLeft(arg, "-")
+		if key == arg {
+			continue
+		} else if strings.HasPrefix(key, name+"=") {
+			value, exists = strings.TrimPrefix(key, name+"="), true
+		} else if key == name && i+1 < len(this.args) {
+			value, exists = this.args[i+1], true
+			i++
+		}
+	}
+	return value, exists
+}
+
+// isBoolFlag returns true if a flag does not require an argument
+func isBoolFlag(f *flag.Flag) bool {
+	if value, ok := f.Value.(interface{ IsBoolFlag() bool }); ok {
+		return value.IsBoolFlag()
+	} else {
+		return false
+	}
+}
+
+// readConfigFile reads a YAML, JSON or TOML file, determined by the
+// file extension, and returns the values keyed by flag name. Nested
+// values are flattened so that rotel: { tty: ... } sets -rotel.tty
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".json":
+		err = json.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, gopi.ErrBadParameter.WithPrefix("Unsupported configuration file: ", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	result := make(map[string]string)
+	flatten(result, "", tree)
+	return result, nil
+}
+
+func flatten(result map[string]string, prefix string, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for k, v := range value {
+			flatten(result, join(prefix, k), v)
+		}
+	case map[interface{}]interface{}:
+		for k, v := range value {
+			flatten(result, join(prefix, fmt.Sprint(k)), v)
+		}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+		result[prefix] = strings.Join(values, ",")
+	case nil:
+		result[prefix] = ""
+	default:
+		result[prefix] = fmt.Sprint(value)
+	}
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	} else {
+		return prefix + "." + key
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (s source) String() string {
+	if s.value == "" {
+		return s.name
+	} else {
+		return s.name + " " + s.value
+	}
+}
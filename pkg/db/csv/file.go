@@ -10,6 +10,7 @@ import (
 	"time"
 
 	gopi "github.com/djthorpe/gopi/v3"
+	metrics "github.com/djthorpe/gopi/v3/pkg/metrics"
 	multierror "github.com/hashicorp/go-multierror"
 )
 
@@ -114,7 +115,7 @@ func (this *file) Write(metric gopi.Measurement) error {
 			row = append(row, fmt.Sprint(tag.Value()))
 		}
 	}
-	for _, metric := range metric.Metrics() {
+	for _, metric := range metrics.Expand(metric.Metrics()) {
 		header = append(header, metric.Name())
 		comment = append(comment, "metric["+metric.Kind()+"]")
 		if metric.IsNil() {
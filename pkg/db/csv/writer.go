@@ -86,7 +86,7 @@ func (this *Writer) Dispose() error {
 // RUN
 
 func (this *Writer) Run(ctx context.Context) error {
-	ch := this.Publisher.Subscribe()
+	ch := this.Publisher.SubscribeType((*gopi.Measurement)(nil))
 	defer this.Publisher.Unsubscribe(ch)
 
 	for {
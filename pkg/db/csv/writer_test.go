@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -47,12 +48,13 @@ func Test_Writer_002(t *testing.T) {
 		if _, err := app.Metrics.NewMeasurement("test", "m1,m5,m15 float64", app.Metrics.HostTag()); err != nil {
 			t.Error(err)
 		}
+		time.Sleep(100 * time.Millisecond)
 
 		// Write metrics
 		for i := 0; i < 10; i++ {
 			t.Log("Writing metric", i)
 			l1, l5, l15 := app.Platform.LoadAverages()
-			if err := app.Metrics.Emit("test", float64(l1), float64(l5), float64(l15)); err != nil {
+			if err := app.Metrics.Emit("test", nil, float64(l1), float64(l5), float64(l15)); err != nil {
 				t.Error(err)
 			}
 		}
@@ -81,12 +83,13 @@ func Test_Writer_003(t *testing.T) {
 		if _, err := app.Metrics.NewMeasurement("test", "m1,m5,m15 float64", app.Metrics.HostTag()); err != nil {
 			t.Error(err)
 		}
+		time.Sleep(100 * time.Millisecond)
 
 		// Write metrics
 		for i := 0; i < 10; i++ {
 			t.Log("Writing metric", i)
 			l1, l5, l15 := app.Platform.LoadAverages()
-			if err := app.Metrics.EmitTS("test", time.Now(), float64(l1), float64(l5), float64(l15)); err != nil {
+			if err := app.Metrics.EmitTS("test", time.Now(), nil, float64(l1), float64(l5), float64(l15)); err != nil {
 				t.Error(err)
 			}
 		}
@@ -107,12 +110,13 @@ func Test_Writer_003(t *testing.T) {
 		if _, err := app.Metrics.NewMeasurement("test", "m1,m5,m15 float64", app.Metrics.HostTag()); err != nil {
 			t.Error(err)
 		}
+		time.Sleep(100 * time.Millisecond)
 
 		// Write metrics
 		for i := 0; i < 10; i++ {
 			t.Log("Writing metric", i)
 			l1, l5, l15 := app.Platform.LoadAverages()
-			if err := app.Metrics.EmitTS("test", time.Now(), float64(l1), float64(l5), float64(l15)); err != nil {
+			if err := app.Metrics.EmitTS("test", time.Now(), nil, float64(l1), float64(l5), float64(l15)); err != nil {
 				t.Error(err)
 			}
 		}
@@ -128,3 +132,33 @@ func Test_Writer_003(t *testing.T) {
 		}
 	})
 }
+
+func Test_Writer_004(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	tool.Test(t, []string{"-csv.path", tempdir}, new(WriterApp), func(app *WriterApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "requests counter, latency histogram(0.1)"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if err := app.Metrics.Emit("test", nil, 1, 0.05); err != nil {
+			t.Error(err)
+		}
+		time.Sleep(500 * time.Millisecond)
+
+		// Counters and histograms are written as several columns
+		if data, err := ioutil.ReadFile(filepath.Join(tempdir, "test.csv")); err != nil {
+			t.Error(err)
+		} else if lines := strings.Split(string(data), "\n"); len(lines) < 3 {
+			t.Error("Unexpected data", string(data))
+		} else if lines[0] != "requests,requests_rate,latency_count,latency_sum,latency_le_0.1,latency_le_inf" {
+			t.Error("Unexpected header", lines[0])
+		} else if lines[2] != "1,0,1,0.05,1,1" {
+			t.Error("Unexpected row", lines[2])
+		}
+	})
+}
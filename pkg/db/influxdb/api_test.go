@@ -0,0 +1,122 @@
+package influxdb_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tool "github.com/djthorpe/gopi/v3/pkg/tool"
+)
+
+func Test_API_001(t *testing.T) {
+	tool.Test(t, []string{"-influxdb.url=influxdb2://rpi4b/metrics"}, new(WriterApp), func(app *WriterApp) {
+		if ep := app.Writer.Endpoint(); ep.String() != "http://rpi4b:8086/" {
+			t.Error("Unexpected endpoint", ep)
+		} else if api := app.Writer.API(); api != 2 {
+			t.Error("Unexpected API version", api)
+		} else if db := app.Writer.Database(); db != "metrics" {
+			t.Error("Unexpected bucket", db)
+		}
+	})
+	tool.Test(t, []string{"-influxdb.url=influxdb2s://rpi4b/metrics", "-influxdb.bucket=home"}, new(WriterApp), func(app *WriterApp) {
+		if ep := app.Writer.Endpoint(); ep.String() != "https://rpi4b:8086/" {
+			t.Error("Unexpected endpoint", ep)
+		} else if api := app.Writer.API(); api != 2 {
+			t.Error("Unexpected API version", api)
+		} else if db := app.Writer.Database(); db != "home" {
+			t.Error("Unexpected bucket", db)
+		}
+	})
+	tool.Test(t, []string{"-influxdb.url=rpi4b/metrics", "-influxdb.api=1"}, new(WriterApp), func(app *WriterApp) {
+		if api := app.Writer.API(); api != 1 {
+			t.Error("Unexpected API version", api)
+		}
+	})
+}
+
+func Test_API_002(t *testing.T) {
+	tests := []struct {
+		version string
+		token   string
+		api     uint
+	}{
+		{"1.8.10", "", 1},
+		{"v2.7.1", "", 2},
+		{"2.0.0", "", 2},
+		{"3.0.1", "", 2},
+		{"", "", 1},
+		{"", "secret", 2},
+		{"1.8.10", "secret", 1},
+	}
+	for i, test := range tests {
+		tool.Test(t, []string{"-influxdb.token=" + test.token}, new(BatchApp), func(app *BatchApp) {
+			app.MockEndpoint.SetVersion(test.version)
+			if _, err := app.Writer.Ping(); err != nil {
+				t.Error(err)
+			} else if api := app.Writer.API(); api != test.api {
+				t.Errorf("Test %v: Unexpected API version %v for %q", i, api, test.version)
+			}
+		})
+	}
+}
+
+func Test_API_003(t *testing.T) {
+	args := []string{"-influxdb.flush=100ms", "-influxdb.token=secret", "-influxdb.org=home", "-influxdb.precision=s"}
+	tool.Test(t, args, new(BatchApp), func(app *BatchApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "value float64"); err != nil {
+			t.Fatal(err)
+		}
+		app.MockEndpoint.SetVersion("v2.7.1")
+		if _, err := app.Writer.Ping(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Write to the v2 API with token, org, bucket and precision
+		ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		time.Sleep(100 * time.Millisecond)
+		if err := app.Metrics.EmitTS("test", ts, nil, 21.5); err != nil {
+			t.Error(err)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if req := app.MockEndpoint.LastWrite(); req == nil {
+			t.Fatal("Expected write")
+		} else if req.URL.Path != "/api/v2/write" {
+			t.Error("Unexpected path", req.URL.Path)
+		} else if params := req.URL.Query(); params.Get("org") != "home" || params.Get("bucket") != "metrics" || params.Get("precision") != "s" {
+			t.Error("Unexpected parameters", params)
+		} else if auth := req.Header.Get("Authorization"); auth != "Token secret" {
+			t.Error("Unexpected authorization", auth)
+		}
+		if lines := app.MockEndpoint.Lines(); len(lines) != 1 || strings.HasSuffix(lines[0], fmt.Sprint(" ", ts.Unix())) == false {
+			t.Error("Unexpected lines", lines)
+		}
+	})
+}
+
+func Test_API_004(t *testing.T) {
+	args := []string{"-influxdb.flush=100ms", "-influxdb.precision=ms"}
+	tool.Test(t, args, new(BatchApp), func(app *BatchApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "value float64"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Write to the v1 API with database and precision
+		ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		time.Sleep(100 * time.Millisecond)
+		if err := app.Metrics.EmitTS("test", ts, nil, 21.5); err != nil {
+			t.Error(err)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if req := app.MockEndpoint.LastWrite(); req == nil {
+			t.Fatal("Expected write")
+		} else if req.URL.Path != "/write" {
+			t.Error("Unexpected path", req.URL.Path)
+		} else if params := req.URL.Query(); params.Get("db") != "metrics" || params.Get("precision") != "ms" {
+			t.Error("Unexpected parameters", params)
+		}
+		if lines := app.MockEndpoint.Lines(); len(lines) != 1 || strings.HasSuffix(lines[0], fmt.Sprint(" ", ts.UnixNano()/int64(time.Millisecond))) == false {
+			t.Error("Unexpected lines", lines)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package influxdb_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	influxdb "github.com/djthorpe/gopi/v3/pkg/db/influxdb"
+	tool "github.com/djthorpe/gopi/v3/pkg/tool"
+)
+
+// MockEndpoint points the writer at a mock server, without writing
+// measurements itself
+type MockEndpoint struct {
+	gopi.Unit
+	gopi.Logger
+	*server
+	*influxdb.Writer
+}
+
+type BatchApp struct {
+	gopi.Unit
+	gopi.Metrics
+	*influxdb.Writer
+	*MockEndpoint
+}
+
+func (this *BatchApp) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (this *MockEndpoint) New(gopi.Config) error {
+	addr := fmt.Sprint("localhost:", UnusedPort())
+	if server, err := NewMockServer(this.Logger.T(), addr); err != nil {
+		return err
+	} else {
+		this.server = server
+		this.Writer.URL.Host = addr
+	}
+	return nil
+}
+
+func (this *MockEndpoint) Dispose() error {
+	return this.server.Close()
+}
+
+// emit measurements once the writer is running
+func emit(t *testing.T, app *BatchApp, n int) {
+	t.Helper()
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < n; i++ {
+		if err := app.Metrics.Emit("test", nil, float64(i)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func Test_Batch_001(t *testing.T) {
+	tool.Test(t, []string{"-influxdb.batch=5", "-influxdb.flush=500ms"}, new(BatchApp), func(app *BatchApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "value float64"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Two batches are written when full, and the remainder on flush
+		emit(t, app, 12)
+		time.Sleep(100 * time.Millisecond)
+		if n := len(app.MockEndpoint.Lines()); n != 10 {
+			t.Error("Unexpected number of lines written", n)
+		}
+		time.Sleep(500 * time.Millisecond)
+		if n := len(app.MockEndpoint.Lines()); n != 12 {
+			t.Error("Unexpected number of lines written", n)
+		}
+		if stats := app.Writer.Stats(); stats.Get("written") != uint64(12) || stats.Get("queued") != uint64(0) {
+			t.Error("Unexpected stats", stats)
+		} else {
+			t.Log(stats)
+		}
+	})
+}
+
+func Test_Batch_002(t *testing.T) {
+	tool.Test(t, []string{"-influxdb.flush=100ms"}, new(BatchApp), func(app *BatchApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "value float64"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Measurements are spooled when the endpoint is unavailable
+		app.MockEndpoint.SetStatus(http.StatusServiceUnavailable)
+		emit(t, app, 3)
+		time.Sleep(300 * time.Millisecond)
+		if n := len(app.MockEndpoint.Lines()); n != 0 {
+			t.Error("Unexpected number of lines written", n)
+		}
+		if stats := app.Writer.Stats(); stats.Get("queued") != uint64(3) {
+			t.Error("Unexpected stats", stats)
+		}
+
+		// Measurements are retried with backoff
+		app.MockEndpoint.SetStatus(0)
+		time.Sleep(1500 * time.Millisecond)
+		if n := len(app.MockEndpoint.Lines()); n != 3 {
+			t.Error("Unexpected number of lines written", n)
+		}
+		if stats := app.Writer.Stats(); stats.Get("queued") != uint64(0) || stats.Get("written") != uint64(3) {
+			t.Error("Unexpected stats", stats)
+		}
+	})
+}
+
+func Test_Batch_003(t *testing.T) {
+	tool.Test(t, []string{"-influxdb.flush=100ms"}, new(BatchApp), func(app *BatchApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "value float64"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Measurements rejected by the endpoint are dropped
+		app.MockEndpoint.SetStatus(http.StatusBadRequest)
+		emit(t, app, 2)
+		time.Sleep(300 * time.Millisecond)
+		if stats := app.Writer.Stats(); stats.Get("dropped") != uint64(2) || stats.Get("queued") != uint64(0) {
+			t.Error("Unexpected stats", stats)
+		}
+	})
+}
+
+func Test_Batch_004(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influxdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	args := []string{"-influxdb.flush=100ms", "-influxdb.spool=" + dir}
+
+	// Spool measurements to disk when the endpoint is unavailable
+	tool.Test(t, args, new(BatchApp), func(app *BatchApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "value float64"); err != nil {
+			t.Fatal(err)
+		}
+		app.MockEndpoint.SetStatus(http.StatusServiceUnavailable)
+		emit(t, app, 5)
+		time.Sleep(300 * time.Millisecond)
+	})
+	if files, err := ioutil.ReadDir(dir); err != nil {
+		t.Error(err)
+	} else if len(files) == 0 {
+		t.Error("Expected spooled measurements")
+	}
+
+	// Write spooled measurements when the writer is next run
+	tool.Test(t, args, new(BatchApp), func(app *BatchApp) {
+		time.Sleep(300 * time.Millisecond)
+		if n := len(app.MockEndpoint.Lines()); n != 5 {
+			t.Error("Unexpected number of lines written", n)
+		}
+	})
+	if files, err := ioutil.ReadDir(dir); err != nil {
+		t.Error(err)
+	} else if len(files) != 0 {
+		t.Error("Unexpected spooled measurements", files)
+	}
+}
+
+func Test_Batch_005(t *testing.T) {
+	tool.Test(t, []string{"-influxdb.batch=1"}, new(BatchApp), func(app *BatchApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "value float64"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Queries are not blocked by a slow write
+		app.MockEndpoint.SetDelay(time.Second)
+		emit(t, app, 1)
+		time.Sleep(100 * time.Millisecond)
+		start := time.Now()
+		if _, err := app.Writer.Query(app.Writer.NewQuery("test")); err != nil {
+			t.Error(err)
+		} else if d := time.Since(start); d > 500*time.Millisecond {
+			t.Error("Query was blocked by write for", d)
+		}
+		time.Sleep(time.Second)
+		if n := len(app.MockEndpoint.Lines()); n != 1 {
+			t.Error("Unexpected number of lines written", n)
+		}
+	})
+}
@@ -10,4 +10,7 @@ import (
 func init() {
 	// *influxdb.Writer -> gopi.MetricWriter
 	graph.RegisterUnit(reflect.TypeOf(&Writer{}), reflect.TypeOf((*gopi.MetricWriter)(nil)))
+
+	// *influxdb.Writer -> gopi.MetricReader
+	graph.RegisterUnit(reflect.TypeOf(&Writer{}), reflect.TypeOf((*gopi.MetricReader)(nil)))
 }
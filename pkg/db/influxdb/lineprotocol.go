@@ -7,13 +7,26 @@ import (
 	"time"
 
 	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/metrics"
 )
 
 // Ref:
 // https://docs.influxdata.com/influxdb/v1.8/write_protocols/line_protocol_tutorial/
 
-// QuoteMeasurement returns a line for a measurement
+// QuoteMeasurement returns a line for a measurement, with the
+// timestamp in nanoseconds
 func QuoteMeasurement(m gopi.Measurement) (string, error) {
+	return QuoteMeasurementPrecision(m, time.Nanosecond)
+}
+
+// QuoteMeasurementPrecision returns a line for a measurement, with the
+// timestamp truncated to a precision of a nanosecond, microsecond,
+// millisecond or second
+func QuoteMeasurementPrecision(m gopi.Measurement, precision time.Duration) (string, error) {
+	if precision <= 0 {
+		return "", gopi.ErrBadParameter.WithPrefix("precision")
+	}
+
 	str := ""
 	// Append name
 	if name := m.Name(); name == "" {
@@ -29,8 +42,8 @@ func QuoteMeasurement(m gopi.Measurement) (string, error) {
 		str += "," + tags
 	}
 
-	// Append metrics
-	if metrics, err := QuoteFields(m.Metrics()); err != nil {
+	// Append metrics, expanding counters, histograms and summaries
+	if metrics, err := QuoteFields(metrics.Expand(m.Metrics())); err != nil {
 		return "", err
 	} else if metrics == "" {
 		return "", gopi.ErrBadParameter.WithPrefix("metrics")
@@ -40,7 +53,7 @@ func QuoteMeasurement(m gopi.Measurement) (string, error) {
 
 	// Append timestamp
 	if ts := m.Time(); ts.IsZero() == false {
-		str += " " + fmt.Sprint(ts.UnixNano())
+		str += " " + fmt.Sprint(ts.UnixNano()/int64(precision))
 	}
 
 	// Return line
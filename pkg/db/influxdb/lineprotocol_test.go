@@ -108,7 +108,7 @@ func Test_LineProtocol_003(t *testing.T) {
 func Test_LineProtocol_004(t *testing.T) {
 	if m, err := metrics.NewMeasurement("test", "metric bool", metrics.NewField("tag", "tag")); err != nil {
 		t.Error(err)
-	} else if in, err := m.Clone(time.Time{}, true); err != nil {
+	} else if in, err := m.Clone(time.Time{}, nil, true); err != nil {
 		t.Error(err)
 	} else if out, err := influxdb.QuoteMeasurement(in); err != nil {
 		t.Error(err)
@@ -116,3 +116,16 @@ func Test_LineProtocol_004(t *testing.T) {
 		t.Log(out)
 	}
 }
+
+func Test_LineProtocol_005(t *testing.T) {
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if m, err := metrics.NewMeasurement("test", "requests counter, latency histogram(0.1,1), size summary(0.5)"); err != nil {
+		t.Error(err)
+	} else if in, err := m.Clone(ts, nil, 3, 0.5, 100); err != nil {
+		t.Error(err)
+	} else if out, err := influxdb.QuoteMeasurement(in); err != nil {
+		t.Error(err)
+	} else if out != "test requests=3,requests_rate=0,latency_count=1i,latency_sum=0.5,latency_le_0.1=0i,latency_le_1=1i,latency_le_inf=1i,size_count=1i,size_sum=100,size_p50=100 1577836800000000000" {
+		t.Error("Unexpected output", out)
+	}
+}
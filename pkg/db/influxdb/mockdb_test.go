@@ -1,9 +1,13 @@
 package influxdb_test
 
 import (
+	"compress/gzip"
 	"context"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -17,6 +21,19 @@ type server struct {
 	*testing.T
 
 	errs chan error
+
+	// Last query statement and the body of the response to queries
+	q, result string
+
+	// Lines written, and the status code and delay for writes
+	sync.Mutex
+	lines  []string
+	status int
+	delay  time.Duration
+
+	// Version returned, and the request for the last write
+	version string
+	write   *http.Request
 }
 
 func NewMockServer(t *testing.T, addr string) (*server, error) {
@@ -28,15 +45,22 @@ func NewMockServer(t *testing.T, addr string) (*server, error) {
 	}
 	this.errs = make(chan error)
 	this.T = t
+	this.version = "1.8.10"
 
 	// Setup handlers
 	mux.HandleFunc("/ping", this.Ping)
 	mux.HandleFunc("/write", this.Write)
+	mux.HandleFunc("/api/v2/write", this.Write)
+	mux.HandleFunc("/query", this.Query)
 
-	// Start serving in the background
+	// Listen, then start serving in the background
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
 	this.WaitGroup.Add(1)
 	go func() {
-		this.errs <- this.Server.ListenAndServe()
+		this.errs <- this.Server.Serve(listener)
 		this.WaitGroup.Done()
 	}()
 
@@ -71,19 +95,104 @@ func (this *server) Close() error {
 
 func (this *server) Ping(w http.ResponseWriter, r *http.Request) {
 	this.T.Log("Mock server got Ping")
-	w.Header().Set("X-Influxdb-Version", "9.9.9")
+	w.Header().Set("X-Influxdb-Version", this.Version())
 	http.Error(w, http.StatusText(http.StatusNoContent), http.StatusNoContent)
 }
 
 func (this *server) Write(w http.ResponseWriter, r *http.Request) {
 	this.T.Logf("Mock server got Write (%q)", r.RequestURI)
-	w.Header().Set("X-Influxdb-Version", "9.9.9")
+	w.Header().Set("X-Influxdb-Version", this.Version())
+	time.Sleep(this.Delay())
+
+	// Decompress the body
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		if reader, err := gzip.NewReader(r.Body); err != nil {
+			this.T.Error(err)
+		} else {
+			body = reader
+		}
+	}
 
-	if data, err := ioutil.ReadAll(r.Body); err != nil {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	this.write = r
+	if data, err := ioutil.ReadAll(body); err != nil {
 		this.T.Error(err)
+	} else if this.status != 0 {
+		this.T.Log("Mock server returning status ", this.status)
+		r.Body.Close()
+		http.Error(w, http.StatusText(this.status), this.status)
+		return
 	} else {
 		this.T.Log(string(data))
+		this.lines = append(this.lines, strings.Split(strings.TrimSpace(string(data)), "\n")...)
 	}
 	r.Body.Close()
 	http.Error(w, http.StatusText(http.StatusNoContent), http.StatusNoContent)
 }
+
+// SetStatus sets the status code returned for writes, or zero for success
+func (this *server) SetStatus(status int) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	this.status = status
+}
+
+// SetDelay sets the time taken to respond to writes
+func (this *server) SetDelay(delay time.Duration) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	this.delay = delay
+}
+
+// Delay returns the time taken to respond to writes
+func (this *server) Delay() time.Duration {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.delay
+}
+
+// SetVersion sets the version returned
+func (this *server) SetVersion(version string) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	this.version = version
+}
+
+// Version returns the version returned
+func (this *server) Version() string {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.version
+}
+
+// LastWrite returns the request for the last write, or nil
+func (this *server) LastWrite() *http.Request {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.write
+}
+
+// Lines returns the lines written
+func (this *server) Lines() []string {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return append([]string{}, this.lines...)
+}
+
+func (this *server) Query(w http.ResponseWriter, r *http.Request) {
+	this.T.Logf("Mock server got Query (%q)", r.RequestURI)
+	w.Header().Set("X-Influxdb-Version", this.Version())
+
+	this.q = r.URL.Query().Get("q")
+	if r.URL.Query().Get("epoch") != "ns" {
+		this.T.Error("Unexpected epoch parameter")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if this.result == "" {
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	} else {
+		w.Write([]byte(this.result))
+	}
+}
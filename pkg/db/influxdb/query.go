@@ -0,0 +1,204 @@
+package influxdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/metrics"
+)
+
+// Ref:
+// https://docs.influxdata.com/influxdb/v1.8/query_language/explore-data/
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// query is an InfluxQL statement for a measurement. Invalid parameters
+// are returned as an error when the query is executed
+type query struct {
+	metrics.Query
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	aggregates = map[gopi.MetricAggregate]string{
+		gopi.METRIC_AGGREGATE_MEAN:   "MEAN",
+		gopi.METRIC_AGGREGATE_MEDIAN: "MEDIAN",
+		gopi.METRIC_AGGREGATE_MIN:    "MIN",
+		gopi.METRIC_AGGREGATE_MAX:    "MAX",
+		gopi.METRIC_AGGREGATE_SUM:    "SUM",
+		gopi.METRIC_AGGREGATE_COUNT:  "COUNT",
+		gopi.METRIC_AGGREGATE_FIRST:  "FIRST",
+		gopi.METRIC_AGGREGATE_LAST:   "LAST",
+	}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// newQuery returns a query for a measurement, grouped by tags
+func newQuery(name string, tags ...string) *query {
+	return &query{metrics.NewQuery(name, tags...)}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *query) Select(fields ...string) gopi.MetricQuery {
+	this.SetFields(fields...)
+	return this
+}
+
+func (this *query) Where(tag string, value interface{}) gopi.MetricQuery {
+	this.SetTag(tag, value)
+	return this
+}
+
+func (this *query) Between(from, to time.Time) gopi.MetricQuery {
+	this.SetRange(from, to)
+	return this
+}
+
+func (this *query) Since(d time.Duration) gopi.MetricQuery {
+	this.SetPeriod(d)
+	return this
+}
+
+func (this *query) Aggregate(fn gopi.MetricAggregate, interval time.Duration) gopi.MetricQuery {
+	this.SetAggregate(fn, interval)
+	return this
+}
+
+func (this *query) Limit(limit uint) gopi.MetricQuery {
+	this.Max = limit
+	return this
+}
+
+// Statement returns the query as an InfluxQL statement
+func (this *query) Statement() (string, error) {
+	if this.Err != nil {
+		return "", this.Err
+	}
+
+	// Fields, which when aggregated retain the name of the field
+	str := "SELECT "
+	fn := aggregates[this.Fn]
+	if len(this.Fields) == 0 && fn == "" {
+		str += "*::field"
+	} else if len(this.Fields) == 0 {
+		str += fn + "(*)"
+	} else {
+		for i, field := range this.Fields {
+			if i > 0 {
+				str += ","
+			}
+			if fn == "" {
+				str += QuoteIdentifier(field)
+			} else {
+				str += fn + "(" + QuoteIdentifier(field) + ") AS " + QuoteIdentifier(field)
+			}
+		}
+	}
+	str += " FROM " + QuoteIdentifier(this.Name)
+
+	// Tags and time range
+	where := make([]string, 0, len(this.Tags)+2)
+	for _, tag := range this.Tags {
+		where = append(where, QuoteIdentifier(tag.Name)+" = "+QuoteString(tag.Value))
+	}
+	if this.Period != 0 {
+		where = append(where, "time >= now() - "+QuoteDuration(this.Period))
+	}
+	if this.From.IsZero() == false {
+		where = append(where, "time >= "+QuoteString(this.From.UTC().Format(time.RFC3339Nano)))
+	}
+	if this.To.IsZero() == false {
+		where = append(where, "time < "+QuoteString(this.To.UTC().Format(time.RFC3339Nano)))
+	}
+	if len(where) > 0 {
+		str += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	// Grouping by time and tags
+	group := []string{}
+	if this.Interval != 0 {
+		group = append(group, "time("+QuoteDuration(this.Interval)+")")
+	}
+	for _, tag := range this.Group {
+		group = append(group, QuoteIdentifier(tag))
+	}
+	if len(group) > 0 {
+		str += " GROUP BY " + strings.Join(group, ",")
+	}
+
+	// Omit intervals without data
+	if this.Interval != 0 {
+		str += " fill(none)"
+	}
+	if this.Max > 0 {
+		str += " LIMIT " + fmt.Sprint(this.Max)
+	}
+
+	// Return success
+	return str, nil
+}
+
+// prefix returns the prefix on names of fields when all fields
+// are aggregated, or empty string otherwise
+func (this *query) prefix() string {
+	if fn, exists := aggregates[this.Fn]; exists && len(this.Fields) == 0 {
+		return strings.ToLower(fn) + "_"
+	} else {
+		return ""
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *query) String() string {
+	str := "<query.influxdb"
+	if q, err := this.Statement(); err != nil {
+		str += " err=" + strconv.Quote(err.Error())
+	} else {
+		str += " q=" + strconv.Quote(q)
+	}
+	return str + ">"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// QUOTE
+
+// QuoteIdentifier returns a double-quoted identifier
+func QuoteIdentifier(value string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(value) + "\""
+}
+
+// QuoteString returns a single-quoted string literal
+func QuoteString(value string) string {
+	return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(value) + "'"
+}
+
+// QuoteDuration returns a duration literal in the largest whole unit
+func QuoteDuration(d time.Duration) string {
+	for _, unit := range []struct {
+		d      time.Duration
+		suffix string
+	}{
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+		{time.Millisecond, "ms"},
+		{time.Microsecond, "u"},
+	} {
+		if d%unit.d == 0 {
+			return fmt.Sprint(int64(d/unit.d), unit.suffix)
+		}
+	}
+	return fmt.Sprint(int64(d), "ns")
+}
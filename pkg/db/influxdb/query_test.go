@@ -0,0 +1,125 @@
+package influxdb_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	influxdb "github.com/djthorpe/gopi/v3/pkg/db/influxdb"
+	tool "github.com/djthorpe/gopi/v3/pkg/tool"
+)
+
+type ReaderApp struct {
+	gopi.Unit
+	gopi.MetricReader
+	*MockWriter
+}
+
+func Test_Query_001(t *testing.T) {
+	tool.Test(t, nil, new(WriterApp), func(app *WriterApp) {
+		ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		tests := []struct {
+			q   gopi.MetricQuery
+			out string
+		}{
+			{app.Writer.NewQuery("temp"), `SELECT *::field FROM "temp"`},
+			{app.Writer.NewQuery("temp", "host"), `SELECT *::field FROM "temp" GROUP BY "host"`},
+			{app.Writer.NewQuery("temp").Select("a", "b"), `SELECT "a","b" FROM "temp"`},
+			{app.Writer.NewQuery("temp").Where("room", "it's"), `SELECT *::field FROM "temp" WHERE "room" = 'it\'s'`},
+			{app.Writer.NewQuery("temp").Since(24 * time.Hour), `SELECT *::field FROM "temp" WHERE time >= now() - 24h`},
+			{app.Writer.NewQuery("temp").Between(ts, ts.Add(90*time.Second)), `SELECT *::field FROM "temp" WHERE time >= '2020-01-01T00:00:00Z' AND time < '2020-01-01T00:01:30Z'`},
+			{app.Writer.NewQuery("temp").Aggregate(gopi.METRIC_AGGREGATE_MEAN, 0), `SELECT MEAN(*) FROM "temp"`},
+			{app.Writer.NewQuery("temp", "host").Select("value").Aggregate(gopi.METRIC_AGGREGATE_MAX, 15*time.Minute).Limit(10), `SELECT MAX("value") AS "value" FROM "temp" GROUP BY time(15m),"host" fill(none) LIMIT 10`},
+		}
+		for i, test := range tests {
+			if str := fmt.Sprint(test.q); strings.Contains(str, strconv.Quote(test.out)) == false {
+				t.Errorf("Test %v: Unexpected query %v, expected %q", i, str, test.out)
+			}
+		}
+	})
+}
+
+func Test_Query_002(t *testing.T) {
+	tool.Test(t, nil, new(ReaderApp), func(app *ReaderApp) {
+		app.MockWriter.server.result = `{"results":[{"statement_id":0,"series":[
+			{"name":"temp","tags":{"room":"kitchen","host":"rpi4"},"columns":["time","mean_value","mean_label"],"values":[[1577836800000000000,21.5,"ok"],[1577837700000000000,22,null]]},
+			{"name":"temp","tags":{"room":"hall","host":"rpi4"},"columns":["time","mean_value","mean_label"],"values":[[1577836800000000000,18,"ok"]]}
+		]}]}`
+		q := app.MetricReader.NewQuery("temp", "room", "host").Aggregate(gopi.METRIC_AGGREGATE_MEAN, 15*time.Minute).Since(24 * time.Hour)
+		result, err := app.MetricReader.Query(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if app.MockWriter.server.q != `SELECT MEAN(*) FROM "temp" WHERE time >= now() - 24h GROUP BY time(15m),"room","host" fill(none)` {
+			t.Error("Unexpected query", app.MockWriter.server.q)
+		}
+		if len(result) != 3 {
+			t.Fatal("Unexpected number of data points", result)
+		}
+		for _, m := range result {
+			t.Log(m)
+		}
+		if m := result[0]; m.Name() != "temp" {
+			t.Error("Unexpected name", m.Name())
+		} else if m.Time().Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) == false {
+			t.Error("Unexpected time", m.Time())
+		} else if len(m.Tags()) != 2 || m.Tags()[0].Name() != "host" || m.Get("room") != "kitchen" {
+			t.Error("Unexpected tags", m.Tags())
+		} else if m.Get("value") != 21.5 || m.Get("label") != "ok" {
+			t.Error("Unexpected metrics", m.Metrics())
+		}
+		if m := result[1]; len(m.Metrics()) != 1 || m.Get("value") != float64(22) {
+			t.Error("Unexpected metrics", m.Metrics())
+		}
+		if m := result[2]; m.Get("room") != "hall" || m.Get("value") != float64(18) {
+			t.Error("Unexpected data point", m)
+		}
+	})
+}
+
+func Test_Query_003(t *testing.T) {
+	tool.Test(t, nil, new(ReaderApp), func(app *ReaderApp) {
+		// Invalid parameters are returned when the query is executed
+		if _, err := app.MetricReader.Query(app.MetricReader.NewQuery("temp").Aggregate(gopi.METRIC_AGGREGATE_NONE, time.Minute)); err == nil {
+			t.Error("Expected error")
+		}
+		if _, err := app.MetricReader.Query(app.MetricReader.NewQuery("")); err == nil {
+			t.Error("Expected error")
+		}
+		if _, err := app.MetricReader.Query(nil); err == nil {
+			t.Error("Expected error")
+		}
+
+		// No data points
+		if result, err := app.MetricReader.Query(app.MetricReader.NewQuery("temp")); err != nil {
+			t.Error(err)
+		} else if len(result) != 0 {
+			t.Error("Unexpected result", result)
+		}
+
+		// Error from the database
+		app.MockWriter.server.result = `{"results":[{"statement_id":0,"error":"database not found: metrics"}]}`
+		if _, err := app.MetricReader.Query(app.MetricReader.NewQuery("temp")); err == nil {
+			t.Error("Expected error")
+		} else {
+			t.Log(err)
+		}
+	})
+}
+
+func Test_Query_004(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:               "1h",
+		90 * time.Minute:        "90m",
+		1500 * time.Millisecond: "1500ms",
+		time.Nanosecond:         "1ns",
+	}
+	for d, out := range tests {
+		if str := influxdb.QuoteDuration(d); str != out {
+			t.Errorf("Unexpected duration %q for %v", str, d)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// response is the body returned from the /query endpoint
+type response struct {
+	Results []struct {
+		Series []series `json:"series"`
+		Error  string   `json:"error"`
+	} `json:"results"`
+	Error string `json:"error"`
+}
+
+type series struct {
+	Name    string            `json:"name"`
+	Tags    map[string]string `json:"tags"`
+	Columns []string          `json:"columns"`
+	Values  [][]interface{}   `json:"values"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// NewQuery returns a query for a measurement, grouped by tags
+func (this *Writer) NewQuery(name string, tags ...string) gopi.MetricQuery {
+	return newQuery(name, tags...)
+}
+
+// Query returns data points from the endpoint. Numeric values
+// are returned as float64
+func (this *Writer) Query(q gopi.MetricQuery) ([]gopi.Measurement, error) {
+	query, ok := q.(*query)
+	if ok == false || query == nil {
+		return nil, gopi.ErrBadParameter.WithPrefix("Query")
+	}
+	statement, err := query.Statement()
+	if err != nil {
+		return nil, err
+	}
+
+	client, e, _, err := this.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up request
+	ep := e.URL
+	ep.Path = "/query"
+	req, err := http.NewRequest(http.MethodGet, ep.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", err, ep.String())
+	}
+
+	// Set credentials
+	e.authorize(req)
+
+	// Set parameters, with timestamps in nanoseconds
+	params := req.URL.Query()
+	if db := e.db; db != "" {
+		params.Set("db", db)
+	}
+	params.Set("q", statement)
+	params.Set("epoch", "ns")
+	req.URL.RawQuery = params.Encode()
+
+	// Perform the request
+	this.Debug("Query: ", statement)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode the response, which contains an error when the status
+	// code is not OK
+	var body response
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, fmt.Errorf("%v: %q", resp.Status, ep.String())
+	} else if body.Error != "" {
+		return nil, fmt.Errorf("%v: %q", body.Error, ep.String())
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v: %q", resp.Status, ep.String())
+	}
+
+	// Return data points
+	result := []gopi.Measurement{}
+	for _, r := range body.Results {
+		if r.Error != "" {
+			return nil, fmt.Errorf("%v: %q", r.Error, ep.String())
+		}
+		for _, s := range r.Series {
+			if m, err := s.measurements(query.prefix()); err != nil {
+				return nil, err
+			} else {
+				result = append(result, m...)
+			}
+		}
+	}
+
+	// Return success
+	return result, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// measurements returns a data point for each row in a series, removing
+// the prefix from column names. Null values are omitted
+func (s series) measurements(prefix string) ([]gopi.Measurement, error) {
+	// Tags are the same for every row, ordered by name
+	keys := make([]string, 0, len(s.Tags))
+	for key := range s.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]gopi.Measurement, 0, len(s.Values))
+	for _, row := range s.Values {
+		if len(row) != len(s.Columns) {
+			return nil, gopi.ErrUnexpectedResponse.WithPrefix(s.Name)
+		}
+		var ts time.Time
+		tags, fields := []gopi.Field{}, []gopi.Field{}
+		for _, key := range keys {
+			if tag := metrics.NewField(key, s.Tags[key]); tag != nil {
+				tags = append(tags, tag)
+			}
+		}
+		for i, column := range s.Columns {
+			if column == "time" {
+				if ts_, err := parseTime(row[i]); err != nil {
+					return nil, err
+				} else {
+					ts = ts_
+				}
+			} else if value, err := parseValue(row[i]); err != nil {
+				return nil, fmt.Errorf("%v: %q: %w", s.Name, column, err)
+			} else if value != nil {
+				if metric := metrics.NewField(strings.TrimPrefix(column, prefix), value); metric != nil {
+					fields = append(fields, metric)
+				}
+			}
+		}
+		result = append(result, metrics.NewMeasurementWithFields(s.Name, ts, tags, fields))
+	}
+
+	// Return success
+	return result, nil
+}
+
+// parseTime returns a timestamp in nanoseconds
+func parseTime(value interface{}) (time.Time, error) {
+	if n, ok := value.(json.Number); ok == false {
+		return time.Time{}, gopi.ErrUnexpectedResponse.WithPrefix("time")
+	} else if ns, err := n.Int64(); err != nil {
+		return time.Time{}, err
+	} else {
+		return time.Unix(0, ns), nil
+	}
+}
+
+// parseValue returns a string, bool, float64 or nil value
+func parseValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	default:
+		return nil, gopi.ErrUnexpectedResponse.WithPrefix(v)
+	}
+}
@@ -0,0 +1,217 @@
+package influxdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// spool is a queue of batches of lines which have not been written, oldest
+// first. Batches are kept in memory, or in files in a directory when the path
+// is set. When the size of the spool exceeds the maximum, the oldest batches
+// are dropped
+type spool struct {
+	sync.Mutex
+	path    string
+	max     uint64
+	size    uint64
+	seq     uint64
+	batches []*batch
+}
+
+type batch struct {
+	file  string // Path to the file, or empty when in memory
+	data  []byte // Lines when in memory
+	size  uint64
+	count uint // Number of lines
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	spoolExt = ".lp"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// newSpool returns a spool, reading any batches in the directory
+// which were not written previously
+func newSpool(path string, max uint64) (*spool, error) {
+	this := &spool{path: path, max: max}
+	if path == "" {
+		return this, nil
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+	for _, file := range files {
+		var seq uint64
+		var count uint
+		if file.Mode().IsRegular() == false || filepath.Ext(file.Name()) != spoolExt {
+			continue
+		} else if _, err := fmt.Sscanf(strings.TrimSuffix(file.Name(), spoolExt), "%d-%d", &seq, &count); err != nil {
+			continue
+		}
+		this.batches = append(this.batches, &batch{
+			file:  filepath.Join(path, file.Name()),
+			size:  uint64(file.Size()),
+			count: count,
+		})
+		this.size += uint64(file.Size())
+		this.seq = seq
+	}
+
+	// Return success
+	return this, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Push appends a batch of lines and returns the number of lines
+// which were dropped to keep within the maximum size
+func (this *spool) Push(data []byte, count uint) (uint, error) {
+	this.Lock()
+	defer this.Unlock()
+
+	// Drop the batch when it can never fit
+	size := uint64(len(data))
+	if this.max > 0 && size > this.max {
+		return count, nil
+	}
+
+	// Drop the oldest batches
+	dropped := uint(0)
+	for this.max > 0 && len(this.batches) > 0 && this.size+size > this.max {
+		dropped += this.batches[0].count
+		if err := this.pop(); err != nil {
+			return dropped, err
+		}
+	}
+
+	// Write the batch to a file, or keep it in memory
+	b := &batch{size: size, count: count}
+	if this.path == "" {
+		b.data = data
+	} else if file, err := this.write(data, count); err != nil {
+		return dropped, err
+	} else {
+		b.file = file
+	}
+	this.batches = append(this.batches, b)
+	this.size += size
+
+	// Return success
+	return dropped, nil
+}
+
+// Peek returns the lines and number of lines in the oldest batch
+func (this *spool) Peek() ([]byte, uint, error) {
+	this.Lock()
+	defer this.Unlock()
+
+	if len(this.batches) == 0 {
+		return nil, 0, nil
+	} else if b := this.batches[0]; b.file == "" {
+		return b.data, b.count, nil
+	} else if data, err := ioutil.ReadFile(b.file); err != nil {
+		return nil, b.count, err
+	} else {
+		return data, b.count, nil
+	}
+}
+
+// Pop removes the oldest batch
+func (this *spool) Pop() error {
+	this.Lock()
+	defer this.Unlock()
+	return this.pop()
+}
+
+// Len returns the number of batches
+func (this *spool) Len() int {
+	this.Lock()
+	defer this.Unlock()
+	return len(this.batches)
+}
+
+// Count returns the number of lines
+func (this *spool) Count() uint {
+	this.Lock()
+	defer this.Unlock()
+	count := uint(0)
+	for _, b := range this.batches {
+		count += b.count
+	}
+	return count
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *spool) String() string {
+	str := "<spool"
+	if this.path != "" {
+		str += " path=" + strconv.Quote(this.path)
+	}
+	str += fmt.Sprint(" batches=", this.Len(), " count=", this.Count())
+	if this.max > 0 {
+		str += fmt.Sprint(" max=", this.max)
+	}
+	return str + ">"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *spool) pop() error {
+	if len(this.batches) == 0 {
+		return nil
+	}
+	b := this.batches[0]
+	this.batches = this.batches[1:]
+	this.size -= b.size
+	if b.file != "" {
+		if err := os.Remove(b.file); err != nil && os.IsNotExist(err) == false {
+			return err
+		}
+	}
+	return nil
+}
+
+// write a batch to a file, replacing any existing file atomically
+func (this *spool) write(data []byte, count uint) (string, error) {
+	this.seq++
+	path := filepath.Join(this.path, fmt.Sprintf("%020d-%d%s", this.seq, count, spoolExt))
+	tmp, err := ioutil.TempFile(this.path, "tmp.*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	} else if err := tmp.Close(); err != nil {
+		return "", err
+	} else if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -2,6 +2,7 @@ package influxdb
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"fmt"
@@ -17,6 +18,7 @@ import (
 	"time"
 
 	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/metrics"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -33,31 +35,94 @@ type Writer struct {
 	url        *string
 	skipverify *bool
 	timeout    *time.Duration
+	batch      *uint
+	flush      *time.Duration
+	gzip       *bool
+	spoolpath  *string
+	spoolsize  *uint
+	interval   *time.Duration
+	api        *uint
+	org        *string
+	bucket     *string
+	token      *string
+	precision  *string
 
 	// Instance variables
 	endpoint
 	version string
+	spool   *spool
+	stats   stats
+}
+
+// stats are counters for measurements
+type stats struct {
+	sync.Mutex
+	host    gopi.Field
+	queued  uint // Measurements in the buffer
+	written uint64
+	dropped uint64
+	latency time.Duration // Latency of the last request
 }
 
 type endpoint struct {
 	url.URL
 	db, user, password string
+	api                uint // API version, or zero to detect
+	org, token         string
+	precision          time.Duration
 }
 
 // TODO
 // params.Set("rp", bp.RetentionPolicy())
-// params.Set("precision", bp.Precision())
 
 ////////////////////////////////////////////////////////////////////////////////
 // GLOBALS
 
 const (
-	DefaultScheme   = "http"
-	DefaultEndpoint = DefaultScheme + "://localhost/metrics"
-	DefaultPort     = 8086
+	DefaultScheme    = "http"
+	DefaultEndpoint  = DefaultScheme + "://localhost/metrics"
+	DefaultPort      = 8086
+	DefaultBatch     = 100
+	DefaultFlush     = 5 * time.Second
+	DefaultSpoolSize = 10 // Megabytes
 
 	EnvUsername = "INFLUX_USERNAME"
 	EnvPassword = "INFLUX_PASSWORD"
+	EnvOrg      = "INFLUX_ORG"
+	EnvToken    = "INFLUX_TOKEN"
+
+	// Name of the measurement for writer statistics
+	StatsMeasurement = "influxdb_writer"
+)
+
+const (
+	// API versions. Version 2 is used by InfluxDB 2.x and later
+	// and version 1 by InfluxDB 1.x
+	apiV1 = 1
+	apiV2 = 2
+
+	// Schemes which select API version 2
+	schemeV2    = "influxdb2"
+	schemeV2TLS = "influxdb2s"
+)
+
+var (
+	// Precision of timestamps, and the parameter value for each API version
+	precisions = map[string]struct {
+		d      time.Duration
+		v1, v2 string
+	}{
+		"ns": {time.Nanosecond, "n", "ns"},
+		"us": {time.Microsecond, "u", "us"},
+		"ms": {time.Millisecond, "ms", "ms"},
+		"s":  {time.Second, "s", "s"},
+	}
+)
+
+const (
+	// Backoff when retrying spooled measurements
+	minBackoff = time.Second
+	maxBackoff = 2 * time.Minute
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -67,6 +132,17 @@ func (this *Writer) Define(cfg gopi.Config) error {
 	this.url = cfg.FlagString("influxdb.url", "", "Database URL")
 	this.skipverify = cfg.FlagBool("influxdb.skipverify", false, "Skip SSL certificate verification")
 	this.timeout = cfg.FlagDuration("influxdb.timeout", 15*time.Second, "Database connection timeout")
+	this.batch = cfg.FlagUint("influxdb.batch", DefaultBatch, "Maximum number of measurements in each write")
+	this.flush = cfg.FlagDuration("influxdb.flush", DefaultFlush, "Interval for writing measurements")
+	this.gzip = cfg.FlagBool("influxdb.gzip", true, "Compress measurements when writing")
+	this.spoolpath = cfg.FlagString("influxdb.spool", "", "Path to directory for measurements which could not be written")
+	this.spoolsize = cfg.FlagUint("influxdb.spoolsize", DefaultSpoolSize, "Maximum size of measurements which could not be written, in megabytes")
+	this.interval = cfg.FlagDuration("influxdb.stats", 0, "Interval for emitting writer statistics, or zero to disable")
+	this.api = cfg.FlagUint("influxdb.api", 0, "API version (1, 2) or zero to detect from the database version")
+	this.org = cfg.FlagString("influxdb.org", os.Getenv(EnvOrg), "Organization for API version 2")
+	this.bucket = cfg.FlagString("influxdb.bucket", "", "Bucket for API version 2, instead of the database in the URL")
+	this.token = cfg.FlagString("influxdb.token", os.Getenv(EnvToken), "Token for API version 2")
+	this.precision = cfg.FlagString("influxdb.precision", "ns", "Precision of timestamps (ns, us, ms, s)")
 	return nil
 }
 
@@ -78,6 +154,46 @@ func (this *Writer) New(cfg gopi.Config) error {
 		this.endpoint = endpoint
 	}
 
+	// Set API version, bucket and token
+	if *this.api > apiV2 {
+		return gopi.ErrBadParameter.WithPrefix("-influxdb.api")
+	} else if *this.api != 0 {
+		this.endpoint.api = *this.api
+	}
+	if *this.bucket != "" {
+		this.endpoint.db = *this.bucket
+	}
+	this.endpoint.org = *this.org
+	this.endpoint.token = *this.token
+
+	// Set precision of timestamps
+	if precision, exists := precisions[*this.precision]; exists == false {
+		return gopi.ErrBadParameter.WithPrefix("-influxdb.precision")
+	} else {
+		this.endpoint.precision = precision.d
+	}
+
+	// Check batching parameters
+	if *this.batch == 0 {
+		return gopi.ErrBadParameter.WithPrefix("-influxdb.batch")
+	} else if *this.flush <= 0 {
+		return gopi.ErrBadParameter.WithPrefix("-influxdb.flush")
+	} else if *this.interval < 0 {
+		return gopi.ErrBadParameter.WithPrefix("-influxdb.stats")
+	}
+
+	// Read measurements which could not be written previously
+	if spool, err := newSpool(*this.spoolpath, uint64(*this.spoolsize)<<20); err != nil {
+		return gopi.ErrBadParameter.WithPrefix("-influxdb.spool: ", err)
+	} else {
+		this.spool = spool
+	}
+
+	// Set host tag for statistics
+	if host, err := os.Hostname(); err == nil {
+		this.stats.host = metrics.NewField("host", host)
+	}
+
 	// Create transport
 	this.Client = &http.Client{
 		Timeout: *this.timeout,
@@ -109,21 +225,87 @@ func (this *Writer) Dispose() error {
 ////////////////////////////////////////////////////////////////////////////////
 // RUN
 
+// Run writes measurements emitted in batches, when the batch is full or
+// the flush interval has passed. Batches which could not be written are
+// spooled and retried with backoff
 func (this *Writer) Run(ctx context.Context) error {
-	ch := this.Publisher.Subscribe()
+	ch := this.Publisher.SubscribeType((*gopi.Measurement)(nil))
 	defer this.Publisher.Unsubscribe(ch)
 
+	flush := time.NewTicker(*this.flush)
+	defer flush.Stop()
+
+	// Emit statistics when the interval is set
+	var stats <-chan time.Time
+	if *this.interval > 0 {
+		ticker := time.NewTicker(*this.interval)
+		defer ticker.Stop()
+		stats = ticker.C
+	}
+
+	// Retry spooled measurements from a previous run
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+	healthy, delay := false, minBackoff
+
+	buffer := new(bytes.Buffer)
 	for {
 		select {
 		case evt := <-ch:
-			if m, ok := evt.(gopi.Measurement); ok {
-				if err := this.Write(m); err != nil {
+			if m, ok := evt.(gopi.Measurement); ok && m != nil {
+				if line, err := QuoteMeasurementPrecision(m, this.endpoint.precision); err != nil {
 					this.Print(err)
+					this.drop(1)
+				} else {
+					buffer.WriteString(line)
+					buffer.WriteByte('\n')
+					this.queue(1)
 				}
 			}
+			if this.queued() < *this.batch {
+				continue
+			}
+		case <-flush.C:
+		case <-stats:
+			if err := this.Publisher.Emit(this.Stats(), false); err != nil {
+				this.Print(err)
+			}
+			continue
+		case <-retry.C:
+			healthy = true
 		case <-ctx.Done():
+			// Write any buffered measurements, or spool them
+			if err := this.spoolBuffer(buffer); err != nil {
+				this.Print(err)
+			}
+			if healthy {
+				if err := this.drain(); err != nil {
+					this.Print(err)
+				}
+			}
+			if count := this.spool.Count(); count > 0 && this.spool.path == "" {
+				this.Print("Dropped ", count, " measurements which could not be written")
+				this.drop(count)
+			}
 			return nil
 		}
+
+		// Spool the buffer and write spooled measurements
+		if err := this.spoolBuffer(buffer); err != nil {
+			this.Print(err)
+		}
+		if healthy == false {
+			continue
+		}
+		if err := this.drain(); err != nil {
+			this.Print(err, " (retrying in ", delay, ")")
+			retry.Reset(delay)
+			if healthy, delay = false, delay*2; delay > maxBackoff {
+				delay = maxBackoff
+			}
+		} else {
+			delay = minBackoff
+		}
 	}
 }
 
@@ -142,16 +324,35 @@ func (this *Writer) Credentials() (string, string) {
 	return this.endpoint.user, this.endpoint.password
 }
 
+// Version returns the version of the database, or empty string
+// if not yet known
+func (this *Writer) Version() string {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.version
+}
+
+// API returns the API version used for writing, which is selected by
+// the -influxdb.api flag or URL scheme, or detected from the database
+// version once known
+func (this *Writer) API() uint {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.apiVersion()
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
 func (this *Writer) Ping() (time.Duration, error) {
-	this.Mutex.Lock()
-	defer this.Mutex.Unlock()
+	client, e, _, err := this.connection()
+	if err != nil {
+		return 0, err
+	}
 
 	// Set up request
 	now := time.Now()
-	ep := this.endpoint.URL
+	ep := e.URL
 	ep.Path = "/ping"
 	req, err := http.NewRequest(http.MethodGet, ep.String(), nil)
 	if err != nil {
@@ -159,12 +360,10 @@ func (this *Writer) Ping() (time.Duration, error) {
 	}
 
 	// Set credentials
-	if this.endpoint.user != "" {
-		req.SetBasicAuth(this.endpoint.user, this.endpoint.password)
-	}
+	e.authorize(req)
 
 	// Perform the request
-	resp, err := this.Client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return 0, fmt.Errorf("%w: %q", err, ep.String())
 	}
@@ -181,16 +380,25 @@ func (this *Writer) Ping() (time.Duration, error) {
 		return 0, fmt.Errorf("%v: %q", strings.TrimSpace(string(body)), ep.String())
 	}
 
-	// Set version
-	if version := resp.Header.Get("X-Influxdb-Version"); version != "" {
+	// Set version, which selects the API version unless set
+	version := resp.Header.Get("X-Influxdb-Version")
+	if version != "" {
 		this.Debug("X-Influxdb-Version: ", version)
-		this.version = version
 	}
+	this.Mutex.Lock()
+	this.version = version
+	this.Mutex.Unlock()
 
 	// Return success
 	return time.Since(now), nil
 }
 
+// Health returns an error if the database cannot be reached
+func (this *Writer) Health(context.Context) error {
+	_, err := this.Ping()
+	return err
+}
+
 // Write measurements to the endpoint
 func (this *Writer) Write(metrics ...gopi.Measurement) error {
 	// Return bad parameter if no metrics
@@ -198,69 +406,45 @@ func (this *Writer) Write(metrics ...gopi.Measurement) error {
 		return gopi.ErrBadParameter.WithPrefix("Write")
 	}
 
-	// Perform a ping if not already done
-	if this.version == "" {
-		if _, err := this.Ping(); err != nil {
-			return err
-		}
-	}
-
-	// Lock for write
-	this.Mutex.Lock()
-	defer this.Mutex.Unlock()
-
 	// Add measurements to the buffer
 	buffer := new(bytes.Buffer)
+	count := uint(0)
 	for _, metric := range metrics {
 		if metric == nil {
 			continue
 		}
-		if line, err := QuoteMeasurement(metric); err != nil {
+		if line, err := QuoteMeasurementPrecision(metric, this.endpoint.precision); err != nil {
 			return err
 		} else if _, err := io.WriteString(buffer, line); err != nil {
 			return err
 		} else if _, err := buffer.Write([]byte{'\n'}); err != nil {
 			return err
+		} else {
+			count++
 		}
 	}
 
-	// Set up request
-	ep := this.endpoint.URL
-	ep.Path = "/write"
-	req, err := http.NewRequest(http.MethodPost, ep.String(), buffer)
-	if err != nil {
-		return fmt.Errorf("%w: %q", err, ep.String())
-	}
-	req.Header.Set("Content-Type", "text/plain")
-
-	// Set credentials
-	if this.endpoint.user != "" {
-		req.SetBasicAuth(this.endpoint.user, this.endpoint.password)
-	}
-
-	// Set parameters
-	params := req.URL.Query()
-	if db := this.endpoint.db; db != "" {
-		params.Set("db", db)
-	}
-	req.URL.RawQuery = params.Encode()
+	// Write the measurements
+	_, err := this.post(buffer.Bytes(), count)
+	return err
+}
 
-	// Perform the request
-	resp, err := this.Client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+// Stats returns a measurement with the number of measurements queued,
+// written and dropped, and the latency of the last write in milliseconds
+func (this *Writer) Stats() gopi.Measurement {
+	this.stats.Lock()
+	defer this.stats.Unlock()
 
-	// Check status of request
-	if body, err := ioutil.ReadAll(resp.Body); err != nil {
-		return err
-	} else if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%v: %q", strings.TrimSpace(string(body)), ep.String())
+	var tags []gopi.Field
+	if this.stats.host != nil {
+		tags = []gopi.Field{this.stats.host.Copy()}
 	}
-
-	// Return success
-	return nil
+	return metrics.NewMeasurementWithFields(StatsMeasurement, time.Now(), tags, []gopi.Field{
+		metrics.NewField("queued", uint64(this.stats.queued+this.spool.Count())),
+		metrics.NewField("written", this.stats.written),
+		metrics.NewField("dropped", this.stats.dropped),
+		metrics.NewField("latency", this.stats.latency.Seconds()*1000),
+	})
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -272,6 +456,10 @@ func (this *Writer) String() string {
 	if this.version != "" {
 		str += " version=" + strconv.Quote(this.version)
 	}
+	str += " api=" + fmt.Sprint(this.apiVersion())
+	if this.spool != nil {
+		str += " spool=" + fmt.Sprint(this.spool)
+	}
 	return str + ">"
 }
 
@@ -287,12 +475,228 @@ func (e endpoint) String() string {
 	if e.password != "" {
 		str += " password=" + strings.Repeat("*", len(e.password))
 	}
+	if e.org != "" {
+		str += " org=" + strconv.Quote(e.org)
+	}
+	if e.token != "" {
+		str += " token=" + strings.Repeat("*", len(e.token))
+	}
 	return str + ">"
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// post writes lines to the endpoint and returns the status code, which
+// is zero if the endpoint could not be reached
+func (this *Writer) post(data []byte, count uint) (int, error) {
+	// Perform a ping to detect the API version if not already done
+	if this.endpoint.api == 0 && this.Version() == "" {
+		if _, err := this.Ping(); err != nil {
+			return 0, err
+		}
+	}
+
+	client, e, api, err := this.connection()
+	if err != nil {
+		return 0, err
+	}
+
+	// Compress the body
+	body := bytes.NewBuffer(data)
+	if *this.gzip {
+		body = new(bytes.Buffer)
+		w := gzip.NewWriter(body)
+		if _, err := w.Write(data); err != nil {
+			return 0, err
+		} else if err := w.Close(); err != nil {
+			return 0, err
+		}
+	}
+
+	// Set up request
+	ep := e.URL
+	if api == apiV2 {
+		ep.Path = "/api/v2/write"
+	} else {
+		ep.Path = "/write"
+	}
+	req, err := http.NewRequest(http.MethodPost, ep.String(), body)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", err, ep.String())
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	if *this.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	// Set credentials and parameters
+	e.authorize(req)
+	req.URL.RawQuery = e.params(api).Encode()
+
+	// Perform the request
+	now := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Check status of request
+	if body, err := ioutil.ReadAll(resp.Body); err != nil {
+		return resp.StatusCode, err
+	} else if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("%v: %q", strings.TrimSpace(string(body)), ep.String())
+	}
+
+	// Update statistics
+	this.stats.Lock()
+	this.stats.written += uint64(count)
+	this.stats.latency = time.Since(now)
+	this.stats.Unlock()
+
+	// Return success
+	return resp.StatusCode, nil
+}
+
+// drain writes spooled batches, oldest first. Batches which are rejected
+// by the endpoint are dropped, otherwise an error is returned and the
+// batches are retried later
+func (this *Writer) drain() error {
+	for this.spool.Len() > 0 {
+		data, count, err := this.spool.Peek()
+		if err == nil {
+			var status int
+			if status, err = this.post(data, count); err != nil && retryable(status) {
+				return err
+			}
+		}
+		if err != nil {
+			this.Print("Dropped ", count, " measurements: ", err)
+			this.drop(count)
+		}
+		if err := this.spool.Pop(); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// spoolBuffer moves buffered lines to the spool
+func (this *Writer) spoolBuffer(buffer *bytes.Buffer) error {
+	count := this.queued()
+	if count == 0 {
+		return nil
+	}
+	data := make([]byte, buffer.Len())
+	copy(data, buffer.Bytes())
+	buffer.Reset()
+	this.queue(-int(count))
+	dropped, err := this.spool.Push(data, count)
+	if dropped > 0 {
+		this.drop(dropped)
+	}
+	return err
+}
+
+// connection returns the client, endpoint and API version for a request.
+// The mutex is only held while these are read, so that a slow endpoint
+// does not block other requests
+func (this *Writer) connection() (*http.Client, endpoint, uint, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	if this.Client == nil {
+		return nil, endpoint{}, 0, gopi.ErrOutOfOrder.WithPrefix("Disposed")
+	}
+	return this.Client, this.endpoint, this.apiVersion(), nil
+}
+
+// apiVersion returns the API version selected, or the version detected
+// from the database. When the database version is not known, API version 2
+// is used when a token is set
+func (this *Writer) apiVersion() uint {
+	if this.endpoint.api != 0 {
+		return this.endpoint.api
+	} else if major := parseVersion(this.version); major >= apiV2 {
+		return apiV2
+	} else if major == 0 && this.endpoint.token != "" {
+		return apiV2
+	} else {
+		return apiV1
+	}
+}
+
+// authorize sets a token or user credentials on a request
+func (e endpoint) authorize(req *http.Request) {
+	if e.token != "" {
+		req.Header.Set("Authorization", "Token "+e.token)
+	} else if e.user != "" {
+		req.SetBasicAuth(e.user, e.password)
+	}
+}
+
+// params returns the query parameters for writing with an API version
+func (e endpoint) params(api uint) url.Values {
+	params := url.Values{}
+	precision := ""
+	for _, p := range precisions {
+		if p.d == e.precision {
+			precision = p.v1
+			if api == apiV2 {
+				precision = p.v2
+			}
+		}
+	}
+	if api == apiV2 {
+		if e.org != "" {
+			params.Set("org", e.org)
+		}
+		if e.db != "" {
+			params.Set("bucket", e.db)
+		}
+	} else if e.db != "" {
+		params.Set("db", e.db)
+	}
+	if precision != "" {
+		params.Set("precision", precision)
+	}
+	return params
+}
+
+// retryable returns true if the request can be retried with the same
+// measurements, which is when the endpoint could not be reached or
+// is temporarily unavailable
+func retryable(status int) bool {
+	switch {
+	case status == 0, status == http.StatusTooManyRequests, status == http.StatusRequestTimeout:
+		return true
+	case status >= http.StatusInternalServerError:
+		return true
+	default:
+		return false
+	}
+}
+
+func (this *Writer) queue(delta int) {
+	this.stats.Lock()
+	defer this.stats.Unlock()
+	this.stats.queued = uint(int(this.stats.queued) + delta)
+}
+
+func (this *Writer) queued() uint {
+	this.stats.Lock()
+	defer this.stats.Unlock()
+	return this.stats.queued
+}
+
+func (this *Writer) drop(count uint) {
+	this.stats.Lock()
+	defer this.stats.Unlock()
+	this.stats.dropped += uint64(count)
+}
+
 // Parse -influxdb.url parameter to extract host,port,username,password,database
 func parseUrl(value string) (endpoint, error) {
 	// Check various styles
@@ -300,6 +704,8 @@ func parseUrl(value string) (endpoint, error) {
 		value = DefaultEndpoint
 	} else if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
 		// Skip
+	} else if strings.HasPrefix(value, schemeV2+"://") || strings.HasPrefix(value, schemeV2TLS+"://") {
+		// Skip
 	} else if host, port, err := net.SplitHostPort(value); err == nil {
 		value = DefaultScheme + "://" + host
 		if port != "" {
@@ -322,9 +728,15 @@ func parseUrl(value string) (endpoint, error) {
 			u.Path = "/"
 		}
 	}
-	// Check scheme
-	if u.Scheme == "" {
+	// Check scheme, and select API version 2 by scheme
+	api := uint(0)
+	switch u.Scheme {
+	case "":
 		u.Scheme = DefaultScheme
+	case schemeV2:
+		u.Scheme, api = "http", apiV2
+	case schemeV2TLS:
+		u.Scheme, api = "https", apiV2
 	}
 	// Check port
 	if u.Port() == "" {
@@ -344,10 +756,21 @@ func parseUrl(value string) (endpoint, error) {
 		}
 		u.Path = "/"
 		u.User = nil
-		return endpoint{*u, db, user, password}, nil
+		return endpoint{*u, db, user, password, api, "", "", time.Nanosecond}, nil
 	}
 }
 
 func parseDatabase(value *url.URL) (string, error) {
 	return strings.Trim(value.Path, "/"), nil
 }
+
+// parseVersion returns the major version from a version string such
+// as v2.7.1 or 1.8.10, or zero if it cannot be parsed
+func parseVersion(value string) uint {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	if major, err := strconv.ParseUint(strings.SplitN(value, ".", 2)[0], 10, 32); err != nil {
+		return 0
+	} else {
+		return uint(major)
+	}
+}
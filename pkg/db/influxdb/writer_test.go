@@ -143,7 +143,7 @@ func Test_Writer_008(t *testing.T) {
 		// Emit metrics and have the mockwriter write to the database
 		for i := 0; i < 10; i++ {
 			l1, l5, l15 := app.Platform.LoadAverages()
-			if err := app.Metrics.Emit("loadavg", l1, l5, l15); err != nil {
+			if err := app.Metrics.Emit("loadavg", nil, l1, l5, l15); err != nil {
 				t.Error(err)
 			}
 			time.Sleep(100 * time.Millisecond)
@@ -152,7 +152,7 @@ func Test_Writer_008(t *testing.T) {
 		// Emit metrics and have the mockwriter write to the database
 		for i := 0; i < 10; i++ {
 			l1, l5, l15 := app.Platform.LoadAverages()
-			if err := app.Metrics.EmitTS("loadavg", time.Now(), l1, l5, l15); err != nil {
+			if err := app.Metrics.EmitTS("loadavg", time.Now(), nil, l1, l5, l15); err != nil {
 				t.Error(err)
 			}
 			time.Sleep(100 * time.Millisecond)
@@ -0,0 +1,169 @@
+package tsdb
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	metrics "github.com/djthorpe/gopi/v3/pkg/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// bucket collects the values of metrics for measurements with the same
+// tag values within an interval, which are combined into one measurement
+type bucket struct {
+	ts     time.Time
+	tags   []gopi.Field
+	names  []string // Names of metrics in the order they were added
+	values map[string][]interface{}
+}
+
+// combineFunc returns a value from the values of a metric in time
+// order, or nil if the values cannot be combined
+type combineFunc func([]interface{}) interface{}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func newBucket(ts time.Time, tags []gopi.Field) *bucket {
+	return &bucket{ts: ts, tags: tags, values: make(map[string][]interface{})}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// METHODS
+
+// Add the values of metrics in a measurement, or the named metrics
+// when names is not empty
+func (this *bucket) Add(m gopi.Measurement, names map[string]bool) {
+	for _, field := range m.Metrics() {
+		name := field.Name()
+		if field.IsNil() || len(names) > 0 && names[name] == false {
+			continue
+		}
+		if _, exists := this.values[name]; exists == false {
+			this.names = append(this.names, name)
+		}
+		this.values[name] = append(this.values[name], field.Value())
+	}
+}
+
+// Measurement returns a measurement with the combined value of each
+// metric. Metrics which cannot be combined are omitted
+func (this *bucket) Measurement(name string, fn combineFunc) gopi.Measurement {
+	fields := []gopi.Field{}
+	for _, name := range this.names {
+		if value := fn(this.values[name]); value == nil {
+			continue
+		} else if field := metrics.NewField(name, value); field != nil {
+			fields = append(fields, field)
+		}
+	}
+	return metrics.NewMeasurementWithFields(name, this.ts, this.tags, fields)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// aggregateFunc returns a function which aggregates values. Numeric values
+// are returned as float64, and other values are only returned for the
+// first and last values
+func aggregateFunc(fn gopi.MetricAggregate) combineFunc {
+	return func(values []interface{}) interface{} {
+		if len(values) == 0 {
+			return nil
+		}
+		switch fn {
+		case gopi.METRIC_AGGREGATE_FIRST:
+			return values[0]
+		case gopi.METRIC_AGGREGATE_LAST:
+			return values[len(values)-1]
+		case gopi.METRIC_AGGREGATE_COUNT:
+			return float64(len(values))
+		}
+
+		// Numeric functions
+		floats := make([]float64, 0, len(values))
+		for _, value := range values {
+			if f, ok := metrics.ToFloat(value); ok {
+				floats = append(floats, f)
+			}
+		}
+		if len(floats) == 0 {
+			return nil
+		}
+		switch fn {
+		case gopi.METRIC_AGGREGATE_MEAN:
+			return sum(floats) / float64(len(floats))
+		case gopi.METRIC_AGGREGATE_SUM:
+			return sum(floats)
+		case gopi.METRIC_AGGREGATE_MIN:
+			sort.Float64s(floats)
+			return floats[0]
+		case gopi.METRIC_AGGREGATE_MAX:
+			sort.Float64s(floats)
+			return floats[len(floats)-1]
+		case gopi.METRIC_AGGREGATE_MEDIAN:
+			sort.Float64s(floats)
+			if n := len(floats); n%2 == 1 {
+				return floats[n/2]
+			} else {
+				return (floats[n/2-1] + floats[n/2]) / 2
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// downsample returns the mean of numeric values as float64, or
+// the last value for other values
+func downsample(values []interface{}) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	total := 0.0
+	for _, value := range values {
+		if f, ok := metrics.ToFloat(value); ok == false {
+			return values[len(values)-1]
+		} else {
+			total += f
+		}
+	}
+	return total / float64(len(values))
+}
+
+// groupKey returns the values of tags as a string. When names is not
+// empty, returns the key and the tags which are named, in the order of
+// the names
+func groupKey(tags []gopi.Field, names []string) (string, []gopi.Field) {
+	if len(names) == 0 {
+		key := ""
+		for _, tag := range tags {
+			key += tag.Name() + "=" + fmt.Sprint(tag.Value()) + "\x00"
+		}
+		return key, tags
+	}
+	key, result := "", make([]gopi.Field, 0, len(names))
+	for _, name := range names {
+		for _, tag := range tags {
+			if tag.Name() == name {
+				result = append(result, tag)
+				key += name + "=" + fmt.Sprint(tag.Value())
+				break
+			}
+		}
+		key += "\x00"
+	}
+	return key, result
+}
+
+func sum(values []float64) float64 {
+	result := 0.0
+	for _, value := range values {
+		result += value
+	}
+	return result
+}
@@ -0,0 +1,16 @@
+package tsdb
+
+import (
+	"reflect"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	graph "github.com/djthorpe/gopi/v3/pkg/graph"
+)
+
+func init() {
+	// *tsdb.Store -> gopi.MetricWriter
+	graph.RegisterUnit(reflect.TypeOf(&Store{}), reflect.TypeOf((*gopi.MetricWriter)(nil)))
+
+	// *tsdb.Store -> gopi.MetricReader
+	graph.RegisterUnit(reflect.TypeOf(&Store{}), reflect.TypeOf((*gopi.MetricReader)(nil)))
+}
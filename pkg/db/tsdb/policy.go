@@ -0,0 +1,125 @@
+package tsdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// level is a resolution at which measurements are stored, and the duration
+// they are retained. Measurements which have expired are downsampled into the
+// next level, or removed when there is no next level. The first level has
+// a zero interval and stores measurements as written. A zero retention
+// keeps measurements forever
+type level struct {
+	interval time.Duration
+	retain   time.Duration
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	rawLevel = "raw"
+	day      = 24 * time.Hour
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Name returns the name of the directory for the level
+func (this level) Name() string {
+	if this.interval == 0 {
+		return rawLevel
+	} else {
+		return formatDuration(this.interval)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// parsePolicy returns levels from the retention of measurements as written,
+// and comma-separated downsample intervals with an optional retention, for
+// example "1m:90d,1h". Each interval must divide the segment duration
+func parsePolicy(retain, downsample string, segment time.Duration) ([]level, error) {
+	if segment <= 0 || day%segment != 0 && segment%day != 0 {
+		return nil, gopi.ErrBadParameter.WithPrefix("segment: ", segment)
+	}
+	raw, err := parseDuration(retain)
+	if err != nil {
+		return nil, gopi.ErrBadParameter.WithPrefix("retain: ", strconv.Quote(retain))
+	}
+	levels := []level{{0, raw}}
+	for _, value := range strings.Split(downsample, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		var l level
+		parts := strings.SplitN(value, ":", 2)
+		if l.interval, err = parseDuration(parts[0]); err != nil || l.interval <= 0 {
+			return nil, gopi.ErrBadParameter.WithPrefix("downsample: ", strconv.Quote(value))
+		} else if segment%l.interval != 0 || l.interval <= levels[len(levels)-1].interval {
+			return nil, gopi.ErrBadParameter.WithPrefix("downsample: ", strconv.Quote(value))
+		}
+		if len(parts) == 2 {
+			if l.retain, err = parseDuration(parts[1]); err != nil {
+				return nil, gopi.ErrBadParameter.WithPrefix("downsample: ", strconv.Quote(value))
+			}
+		}
+
+		// The previous level needs to expire for measurements to be downsampled
+		if levels[len(levels)-1].retain == 0 {
+			return nil, gopi.ErrBadParameter.WithPrefix("downsample: ", strconv.Quote(value))
+		}
+		levels = append(levels, l)
+	}
+
+	// Return success
+	return levels, nil
+}
+
+// parseDuration returns a duration which can include a number of days
+// with a "d" suffix, for example "7d"
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.ParseUint(strings.TrimSuffix(value, "d"), 10, 32); err != nil {
+			return 0, err
+		} else {
+			return time.Duration(days) * day, nil
+		}
+	} else if d, err := time.ParseDuration(value); err != nil {
+		return 0, err
+	} else if d < 0 {
+		return 0, gopi.ErrBadParameter.WithPrefix(value)
+	} else {
+		return d, nil
+	}
+}
+
+// formatDuration returns a duration in the largest whole unit
+func formatDuration(d time.Duration) string {
+	for _, unit := range []struct {
+		d      time.Duration
+		suffix string
+	}{
+		{day, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+		{time.Millisecond, "ms"},
+	} {
+		if d%unit.d == 0 {
+			return fmt.Sprint(int64(d/unit.d), unit.suffix)
+		}
+	}
+	return fmt.Sprint(int64(d), "ns")
+}
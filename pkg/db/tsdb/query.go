@@ -0,0 +1,189 @@
+package tsdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	metrics "github.com/djthorpe/gopi/v3/pkg/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// query selects measurements with a name. Invalid parameters are
+// returned as an error when the query is executed
+type query struct {
+	metrics.Query
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// newQuery returns a query for a measurement, grouped by tags
+func newQuery(name string, tags ...string) *query {
+	this := &query{metrics.NewQuery(name, tags...)}
+	if isValidName(name) == false {
+		this.Err = gopi.ErrBadParameter.WithPrefix("NewQuery: ", strconv.Quote(name))
+	}
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *query) Select(fields ...string) gopi.MetricQuery {
+	this.SetFields(fields...)
+	return this
+}
+
+func (this *query) Where(tag string, value interface{}) gopi.MetricQuery {
+	this.SetTag(tag, value)
+	return this
+}
+
+func (this *query) Between(from, to time.Time) gopi.MetricQuery {
+	this.SetRange(from, to)
+	return this
+}
+
+func (this *query) Since(d time.Duration) gopi.MetricQuery {
+	this.SetPeriod(d)
+	return this
+}
+
+func (this *query) Aggregate(fn gopi.MetricAggregate, interval time.Duration) gopi.MetricQuery {
+	this.SetAggregate(fn, interval)
+	return this
+}
+
+func (this *query) Limit(limit uint) gopi.MetricQuery {
+	this.Max = limit
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *query) String() string {
+	str := "<query.tsdb"
+	str += " name=" + strconv.Quote(this.Name)
+	if len(this.Fields) > 0 {
+		str += " select=" + strconv.Quote(strings.Join(this.Fields, ","))
+	}
+	for _, tag := range this.Tags {
+		str += " where=" + strconv.Quote(tag.Name+"="+tag.Value)
+	}
+	if this.Period != 0 {
+		str += fmt.Sprint(" since=", this.Period)
+	}
+	if this.From.IsZero() == false {
+		str += " from=" + this.From.Format(time.RFC3339)
+	}
+	if this.To.IsZero() == false {
+		str += " to=" + this.To.Format(time.RFC3339)
+	}
+	if this.Fn != gopi.METRIC_AGGREGATE_NONE {
+		str += fmt.Sprint(" aggregate=", this.Fn)
+	}
+	if this.Interval != 0 {
+		str += fmt.Sprint(" interval=", this.Interval)
+	}
+	if len(this.Group) > 0 {
+		str += " group=" + strconv.Quote(strings.Join(this.Group, ","))
+	}
+	if this.Max > 0 {
+		str += fmt.Sprint(" limit=", this.Max)
+	}
+	if this.Err != nil {
+		str += " err=" + strconv.Quote(this.Err.Error())
+	}
+	return str + ">"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// execute returns measurements for each group in the query, from
+// measurements in time order
+func (this *query) execute(measurements []gopi.Measurement, from time.Time) []gopi.Measurement {
+	names := make(map[string]bool, len(this.Fields))
+	for _, field := range this.Fields {
+		names[field] = true
+	}
+
+	// Collect measurements into groups, then into buckets for each group
+	// when aggregating
+	keys := []string{}
+	groups := make(map[string][]gopi.Measurement)
+	buckets := make(map[string][]*bucket)
+	for _, m := range measurements {
+		key, tags := "", []gopi.Field(nil)
+		if len(this.Group) > 0 {
+			key, tags = groupKey(m.Tags(), this.Group)
+		}
+		if _, exists := groups[key]; exists == false {
+			keys = append(keys, key)
+			groups[key] = nil
+		}
+		if this.Fn == gopi.METRIC_AGGREGATE_NONE {
+			groups[key] = append(groups[key], selectMetrics(m, names))
+			continue
+		}
+
+		// Without an interval, there is one bucket for each group with
+		// the start time of the query, or the time of the first measurement
+		n := len(buckets[key])
+		if this.Interval == 0 && n == 0 {
+			ts := from
+			if ts.IsZero() {
+				ts = m.Time()
+			}
+			buckets[key] = append(buckets[key], newBucket(ts, tags))
+		} else if ts := m.Time().Truncate(this.Interval); this.Interval != 0 && (n == 0 || buckets[key][n-1].ts.Equal(ts) == false) {
+			buckets[key] = append(buckets[key], newBucket(ts, tags))
+		}
+		buckets[key][len(buckets[key])-1].Add(m, names)
+	}
+
+	// Aggregate buckets, omitting buckets without any metrics
+	if this.Fn != gopi.METRIC_AGGREGATE_NONE {
+		fn := aggregateFunc(this.Fn)
+		for _, key := range keys {
+			for _, b := range buckets[key] {
+				if m := b.Measurement(this.Name, fn); len(m.Metrics()) > 0 {
+					groups[key] = append(groups[key], m)
+				}
+			}
+		}
+	}
+
+	// Return groups in the order they were first seen, limiting
+	// the number of measurements in each group
+	result := []gopi.Measurement{}
+	for _, key := range keys {
+		group := groups[key]
+		if this.Max > 0 && uint(len(group)) > this.Max {
+			group = group[:this.Max]
+		}
+		result = append(result, group...)
+	}
+	return result
+}
+
+// selectMetrics returns a measurement with the named metrics, or
+// the measurement when names is empty
+func selectMetrics(m gopi.Measurement, names map[string]bool) gopi.Measurement {
+	if len(names) == 0 {
+		return m
+	}
+	fields := []gopi.Field{}
+	for _, field := range m.Metrics() {
+		if names[field.Name()] {
+			fields = append(fields, field)
+		}
+	}
+	return metrics.NewMeasurementWithFields(m.Name(), m.Time(), m.Tags(), fields)
+}
@@ -0,0 +1,198 @@
+package tsdb
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// segment is an append-only file of measurements within a time window.
+// Each record is the length and checksum of the data, followed by the
+// data. A record which is incomplete or has an invalid checksum, for example
+// after a power failure, ends the segment and is removed when the segment
+// is opened for appending
+type segment struct {
+	path       string
+	start, end time.Time
+	size       int64
+	fh         *os.File
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	segmentExt     = ".seg"
+	segmentTime    = "20060102T150405Z"
+	headerSize     = 8
+	maxRecordSize  = 1 << 24
+	segmentDirMode = 0755
+	segmentMode    = 0644
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// openSegment opens a segment for appending, creating it when it does not
+// exist. Returns true if an incomplete record was removed
+func openSegment(path string) (*segment, bool, error) {
+	this := new(segment)
+	if start, end, ok := parseSegmentName(filepath.Base(path)); ok == false {
+		return nil, false, gopi.ErrBadParameter.WithPrefix(path)
+	} else {
+		this.path, this.start, this.end = path, start, end
+	}
+	if err := os.MkdirAll(filepath.Dir(path), segmentDirMode); err != nil {
+		return nil, false, err
+	}
+	fh, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, segmentMode)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Remove any incomplete record at the end of the file
+	stat, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+		return nil, false, err
+	}
+	size, err := readRecords(fh, func([]byte) error { return nil })
+	if err != nil {
+		fh.Close()
+		return nil, false, err
+	}
+	if size != stat.Size() {
+		if err := fh.Truncate(size); err != nil {
+			fh.Close()
+			return nil, false, err
+		}
+	}
+	if _, err := fh.Seek(size, io.SeekStart); err != nil {
+		fh.Close()
+		return nil, false, err
+	}
+	this.fh, this.size = fh, size
+
+	// Return success
+	return this, size != stat.Size(), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Append writes a record. When the write fails, the segment is truncated
+// so the incomplete record is removed
+func (this *segment) Append(data []byte) error {
+	if len(data) > maxRecordSize {
+		return gopi.ErrBadParameter.WithPrefix("Append")
+	}
+	buf := make([]byte, headerSize+len(data))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(data)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(data))
+	copy(buf[headerSize:], data)
+	if n, err := this.fh.Write(buf); err != nil {
+		this.fh.Truncate(this.size)
+		this.fh.Seek(this.size, io.SeekStart)
+		return err
+	} else {
+		this.size += int64(n)
+	}
+
+	// Return success
+	return nil
+}
+
+// Sync commits the segment to disk
+func (this *segment) Sync() error {
+	return this.fh.Sync()
+}
+
+func (this *segment) Close() error {
+	return this.fh.Close()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// segmentName returns the file name for a time window
+func segmentName(start, end time.Time) string {
+	return start.UTC().Format(segmentTime) + "-" + end.UTC().Format(segmentTime) + segmentExt
+}
+
+// parseSegmentName returns the time window for a file name, or false
+// if the name is not a segment
+func parseSegmentName(name string) (time.Time, time.Time, bool) {
+	if filepath.Ext(name) != segmentExt {
+		return time.Time{}, time.Time{}, false
+	} else if parts := strings.Split(strings.TrimSuffix(name, segmentExt), "-"); len(parts) != 2 {
+		return time.Time{}, time.Time{}, false
+	} else if start, err := time.Parse(segmentTime, parts[0]); err != nil {
+		return time.Time{}, time.Time{}, false
+	} else if end, err := time.Parse(segmentTime, parts[1]); err != nil || end.After(start) == false {
+		return time.Time{}, time.Time{}, false
+	} else {
+		return start, end, true
+	}
+}
+
+// syncDir commits a directory to storage, so that files which have been
+// renamed into it are not lost after a power failure
+func syncDir(path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	return fh.Sync()
+}
+
+// readSegment calls a function with the data of each complete record
+// in a segment file
+func readSegment(path string, fn func([]byte) error) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	_, err = readRecords(fh, fn)
+	return err
+}
+
+// readRecords calls a function with the data of each record until the
+// end of the file or an incomplete record, and returns the size of the
+// complete records
+func readRecords(r io.Reader, fn func([]byte) error) (int64, error) {
+	var size int64
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, nil
+		} else if err != nil {
+			return size, err
+		}
+		length := binary.BigEndian.Uint32(header[0:])
+		if length > maxRecordSize {
+			return size, nil
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, nil
+		} else if err != nil {
+			return size, err
+		} else if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:]) {
+			return size, nil
+		} else if err := fn(data); err != nil {
+			return size, err
+		}
+		size += int64(headerSize + len(data))
+	}
+}
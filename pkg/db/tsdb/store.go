@@ -0,0 +1,545 @@
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	codec "github.com/djthorpe/gopi/v3/pkg/event/codec"
+	metrics "github.com/djthorpe/gopi/v3/pkg/metrics"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Store writes measurements to append-only segment files in a directory,
+// and queries them. Measurements are downsampled and removed as they expire
+type Store struct {
+	sync.Mutex
+	gopi.Unit
+	gopi.Logger
+	gopi.Publisher
+
+	// Flags & Parameters
+	path       *string
+	retain     *string
+	downsample *string
+	segment    *time.Duration
+	fsync      *bool
+
+	// Member variables
+	levels   []level
+	segments map[string]*segment // Segments open for appending, by path
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	DefaultRetain     = "7d"
+	DefaultDownsample = "1m:90d,1h"
+	DefaultSegment    = 24 * time.Hour
+
+	// Interval for downsampling and removing expired segments
+	compactInterval = 10 * time.Minute
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// INIT
+
+func (this *Store) Define(cfg gopi.Config) error {
+	this.path = cfg.FlagString("tsdb.path", "", "Path to directory for measurements")
+	this.retain = cfg.FlagString("tsdb.retain", DefaultRetain, "Retention of measurements before downsampling, or zero to keep forever")
+	this.downsample = cfg.FlagString("tsdb.downsample", DefaultDownsample, "Comma-separated downsample intervals, each with an optional retention")
+	this.segment = cfg.FlagDuration("tsdb.segment", DefaultSegment, "Duration of measurements in each segment file")
+	this.fsync = cfg.FlagBool("tsdb.sync", false, "Commit measurements to disk after each write")
+	return nil
+}
+
+func (this *Store) New(cfg gopi.Config) error {
+	// Check path is a folder
+	if *this.path == "" {
+		return gopi.ErrBadParameter.WithPrefix("-tsdb.path")
+	} else if stat, err := os.Stat(*this.path); os.IsNotExist(err) {
+		return gopi.ErrBadParameter.WithPrefix("-tsdb.path")
+	} else if err != nil {
+		return err
+	} else if stat.IsDir() == false {
+		return gopi.ErrBadParameter.WithPrefix("-tsdb.path")
+	}
+
+	// Set retention and downsampling
+	if levels, err := parsePolicy(*this.retain, *this.downsample, *this.segment); err != nil {
+		return gopi.ErrBadParameter.WithPrefix("-tsdb: ", err)
+	} else {
+		this.levels = levels
+	}
+
+	// Create segment mapping
+	this.segments = make(map[string]*segment)
+
+	// Return success
+	return nil
+}
+
+func (this *Store) Dispose() error {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
+	// Close all opened segments
+	var result error
+	for _, segment := range this.segments {
+		if err := segment.Close(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Release resources
+	this.segments = nil
+
+	// Return any errors
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// RUN
+
+func (this *Store) Run(ctx context.Context) error {
+	ch := this.Publisher.SubscribeType((*gopi.Measurement)(nil))
+	defer this.Publisher.Unsubscribe(ch)
+
+	timer := time.NewTimer(100 * time.Millisecond)
+	defer timer.Stop()
+
+	for {
+		select {
+		case evt := <-ch:
+			if m, ok := evt.(gopi.Measurement); ok {
+				if err := this.Write(m); err != nil {
+					this.Print(err)
+				}
+			}
+		case <-timer.C:
+			if err := this.Compact(); err != nil {
+				this.Print(err)
+			}
+			timer.Reset(compactInterval)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Ping returns the time taken to read the directory
+func (this *Store) Ping() (time.Duration, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
+	now := time.Now()
+	if _, err := os.Stat(*this.path); err != nil {
+		return 0, err
+	}
+	return time.Since(now), nil
+}
+
+// Write measurements to segments. Measurements without a timestamp are
+// written with the current time, and measurements which would have already
+// expired return ErrOutOfOrder
+func (this *Store) Write(metrics ...gopi.Measurement) error {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
+	// Return bad parameter if no metrics
+	if len(metrics) == 0 {
+		return gopi.ErrBadParameter.WithPrefix("Write")
+	}
+
+	var result error
+	now := time.Now()
+	for _, m := range metrics {
+		if err := this.write(m, now); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%v: %w", m.Name(), err))
+		}
+	}
+
+	// Return any errors
+	return result
+}
+
+// NewQuery returns a query for a measurement, grouped by tags
+func (this *Store) NewQuery(name string, tags ...string) gopi.MetricQuery {
+	return newQuery(name, tags...)
+}
+
+// Query returns measurements in time order for each group, and the groups
+// in the order of their first measurement. Aggregated values are returned
+// as float64, except for the first and last values
+func (this *Store) Query(q gopi.MetricQuery) ([]gopi.Measurement, error) {
+	query, ok := q.(*query)
+	if ok == false || query == nil {
+		return nil, gopi.ErrBadParameter.WithPrefix("Query")
+	} else if query.Err != nil {
+		return nil, query.Err
+	}
+
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
+	from, to := query.Bounds(time.Now())
+	measurements, err := this.read(query.Name, from, to, query.Match)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return query.execute(measurements, from), nil
+}
+
+// Compact downsamples and removes segments which have expired, and closes
+// segments which are no longer written. It is called periodically, and can
+// be called to apply a new retention policy
+func (this *Store) Compact() error {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
+	// Close segments for the past
+	now := time.Now()
+	for path, segment := range this.segments {
+		if segment.end.After(now) == false {
+			segment.Close()
+			delete(this.segments, path)
+		}
+	}
+
+	// Downsample segments for each measurement and level, removing
+	// segments which have expired
+	names, err := ioutil.ReadDir(*this.path)
+	if err != nil {
+		return err
+	}
+	var result error
+	for _, name := range names {
+		if name.IsDir() == false || isValidName(name.Name()) == false {
+			continue
+		}
+		for i, l := range this.levels {
+			if l.retain == 0 {
+				continue
+			}
+			var next *level
+			if i+1 < len(this.levels) {
+				next = &this.levels[i+1]
+			}
+			if err := this.compact(name.Name(), l, next, now.Add(-l.retain)); err != nil {
+				result = multierror.Append(result, err)
+			}
+		}
+	}
+
+	// Return any errors
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Store) String() string {
+	str := "<tsdb"
+	if *this.path != "" {
+		str += " path=" + strconv.Quote(*this.path)
+	}
+	for _, level := range this.levels {
+		str += " " + level.Name() + "="
+		if level.retain == 0 {
+			str += "forever"
+		} else {
+			str += formatDuration(level.retain)
+		}
+	}
+	return str + ">"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// write appends a measurement to the segment for the time window
+func (this *Store) write(m gopi.Measurement, now time.Time) error {
+	name := m.Name()
+	if isValidName(name) == false {
+		return gopi.ErrBadParameter.WithPrefix("Write")
+	}
+
+	// Set the timestamp when not set
+	ts := m.Time()
+	if ts.IsZero() {
+		ts = now
+		m = metrics.NewMeasurementWithFields(name, ts, m.Tags(), m.Metrics())
+	}
+
+	// Measurements which have expired are not written, as the segment
+	// may already be downsampled
+	start := ts.Truncate(*this.segment)
+	end := start.Add(*this.segment)
+	if retain := this.levels[0].retain; retain != 0 && end.After(now.Add(-retain)) == false {
+		return gopi.ErrOutOfOrder.WithPrefix(ts.Format(time.RFC3339))
+	}
+
+	// Encode measurement
+	_, data, err := codec.Encode(m)
+	if err != nil {
+		return err
+	}
+
+	// Open segment
+	path := filepath.Join(*this.path, name, rawLevel, segmentName(start, end))
+	s, exists := this.segments[path]
+	if exists == false {
+		var recovered bool
+		if s, recovered, err = openSegment(path); err != nil {
+			return err
+		} else if recovered {
+			this.Print("Removed incomplete measurement from ", strconv.Quote(path))
+		}
+		this.segments[path] = s
+	}
+
+	// Append measurement
+	if err := s.Append(data); err != nil {
+		return err
+	} else if *this.fsync {
+		return s.Sync()
+	}
+
+	// Return success
+	return nil
+}
+
+// read returns measurements from all levels between two times, which
+// match a filter function, in time order. Segments which have already been
+// downsampled into the next level are skipped, which is when compaction was
+// interrupted before they were removed
+func (this *Store) read(name string, from, to time.Time, fn func(gopi.Measurement) bool) ([]gopi.Measurement, error) {
+	result := []gopi.Measurement{}
+	for i, level := range this.levels {
+		paths, err := segments(filepath.Join(*this.path, name, level.Name()), from, to)
+		if err != nil {
+			return nil, err
+		}
+		downsampled := map[string]bool{}
+		if i+1 < len(this.levels) {
+			next, err := segments(filepath.Join(*this.path, name, this.levels[i+1].Name()), from, to)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range next {
+				downsampled[filepath.Base(path)] = true
+			}
+		}
+		for _, path := range paths {
+			if downsampled[filepath.Base(path)] {
+				continue
+			}
+			if err := readSegment(path, func(data []byte) error {
+				m, err := decode(data)
+				if err != nil {
+					return fmt.Errorf("%v: %w", path, err)
+				}
+				if ts := m.Time(); from.IsZero() == false && ts.Before(from) {
+					return nil
+				} else if to.IsZero() == false && ts.Before(to) == false {
+					return nil
+				} else if fn(m) {
+					result = append(result, m)
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Order by time
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time().Before(result[j].Time())
+	})
+
+	// Return success
+	return result, nil
+}
+
+// compact downsamples segments for a measurement and level which end before
+// a time into the next level, and removes them
+func (this *Store) compact(name string, l level, next *level, before time.Time) error {
+	dir := filepath.Join(*this.path, name, l.Name())
+	paths, err := segments(dir, time.Time{}, before)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, end, _ := parseSegmentName(filepath.Base(path)); end.After(before) {
+			continue
+		}
+		if next != nil {
+			if err := this.downsampleSegment(name, path, *next); err != nil {
+				return err
+			}
+		}
+		if segment, exists := this.segments[path]; exists {
+			segment.Close()
+			delete(this.segments, path)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		this.Debug("Compact: ", strconv.Quote(path))
+	}
+
+	// Return success
+	return nil
+}
+
+// downsampleSegment writes the measurements in a segment to a segment with the
+// same time window in the next level. Numeric values are combined with
+// the mean for each interval, and for other values the last value is used.
+// The segment is written atomically and the directory is synced before
+// the source segment is removed, so downsampling can be repeated if it
+// is interrupted
+func (this *Store) downsampleSegment(name, path string, next level) error {
+	keys := []string{}
+	buckets := make(map[string][]*bucket)
+	if err := readSegment(path, func(data []byte) error {
+		m, err := decode(data)
+		if err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		key, tags := groupKey(m.Tags(), nil)
+		ts := m.Time().Truncate(next.interval)
+		if _, exists := buckets[key]; exists == false {
+			keys = append(keys, key)
+		}
+		var b *bucket
+		for _, other := range buckets[key] {
+			if other.ts.Equal(ts) {
+				b = other
+				break
+			}
+		}
+		if b == nil {
+			b = newBucket(ts, tags)
+			buckets[key] = append(buckets[key], b)
+		}
+		b.Add(m, nil)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// Order measurements by time
+	measurements := []gopi.Measurement{}
+	for _, key := range keys {
+		for _, b := range buckets[key] {
+			measurements = append(measurements, b.Measurement(name, downsample))
+		}
+	}
+	sort.SliceStable(measurements, func(i, j int) bool {
+		return measurements[i].Time().Before(measurements[j].Time())
+	})
+
+	// Write to a temporary file, then replace the segment. Temporary files
+	// which remain from an interrupted downsample are removed
+	dir := filepath.Join(*this.path, name, next.Name())
+	if err := os.MkdirAll(dir, segmentDirMode); err != nil {
+		return err
+	} else if files, err := filepath.Glob(filepath.Join(dir, "tmp.*")); err == nil {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}
+	tmp, err := ioutil.TempFile(dir, "tmp.*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	s := &segment{path: tmp.Name(), fh: tmp}
+	for _, m := range measurements {
+		if _, data, err := codec.Encode(m); err != nil {
+			tmp.Close()
+			return err
+		} else if err := s.Append(data); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	} else if err := tmp.Close(); err != nil {
+		return err
+	} else if err := os.Rename(tmp.Name(), filepath.Join(dir, filepath.Base(path))); err != nil {
+		return err
+	} else if err := syncDir(dir); err != nil {
+		return err
+	}
+
+	// Return success
+	return nil
+}
+
+// segments returns the paths of segments in a directory which overlap
+// two times, in time order. A zero time is unbounded
+func segments(dir string, from, to time.Time) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, file := range files {
+		if file.Mode().IsRegular() == false {
+			continue
+		} else if start, end, ok := parseSegmentName(file.Name()); ok == false {
+			continue
+		} else if from.IsZero() == false && end.After(from) == false {
+			continue
+		} else if to.IsZero() == false && start.Before(to) == false {
+			continue
+		}
+		result = append(result, filepath.Join(dir, file.Name()))
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// decode returns a measurement from a record
+func decode(data []byte) (gopi.Measurement, error) {
+	if evt, err := codec.Decode(codec.Measurement, data); err != nil {
+		return nil, err
+	} else if m, ok := evt.(gopi.Measurement); ok == false {
+		return nil, gopi.ErrUnexpectedResponse.WithPrefix("decode")
+	} else {
+		return m, nil
+	}
+}
+
+// isValidName returns true if a measurement name can be used
+// as a directory name
+func isValidName(name string) bool {
+	if name == "" || strings.HasPrefix(name, ".") {
+		return false
+	} else {
+		return strings.ContainsAny(name, `/\`) == false
+	}
+}
@@ -0,0 +1,383 @@
+package tsdb_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	tsdb "github.com/djthorpe/gopi/v3/pkg/db/tsdb"
+	metrics "github.com/djthorpe/gopi/v3/pkg/metrics"
+	tool "github.com/djthorpe/gopi/v3/pkg/tool"
+)
+
+type StoreApp struct {
+	gopi.Unit
+	gopi.Metrics
+	*tsdb.Store
+}
+
+func (this *StoreApp) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func Test_Store_001(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	tool.Test(t, []string{"-tsdb.path", tempdir}, new(StoreApp), func(app *StoreApp) {
+		if app.Store == nil {
+			t.Error("nil Store unit")
+		} else if _, err := app.Store.Ping(); err != nil {
+			t.Error(err)
+		} else {
+			t.Log(app.Store)
+		}
+	})
+}
+
+func Test_Store_002(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	tool.Test(t, []string{"-tsdb.path", tempdir}, new(StoreApp), func(app *StoreApp) {
+		ts := time.Now().Add(-time.Hour).Truncate(time.Minute)
+		m := measurements(t, ts, "temp", "value float32, requests counter, label string", [][]interface{}{
+			{"kitchen", float32(20), 1, "a"},
+			{"hall", float32(18), 2, "b"},
+			{"kitchen", float32(22), 3, "c"},
+		})
+		if err := app.Store.Write(m...); err != nil {
+			t.Fatal(err)
+		}
+
+		// Types are retained
+		if result, err := app.Store.Query(app.Store.NewQuery("temp")); err != nil {
+			t.Error(err)
+		} else if len(result) != 3 {
+			t.Error("Unexpected result", result)
+		} else if result[0].Get("value") != float32(20) || result[0].Get("room") != "kitchen" || result[0].Time().Equal(ts) == false {
+			t.Error("Unexpected measurement", result[0])
+		} else if v, ok := result[2].Get("requests").(gopi.MetricCounter); !ok || v.Total != 4 {
+			t.Error("Unexpected counter", result[2])
+		}
+
+		// Select and where
+		if result, err := app.Store.Query(app.Store.NewQuery("temp").Select("value").Where("room", "kitchen")); err != nil {
+			t.Error(err)
+		} else if len(result) != 2 || len(result[1].Metrics()) != 1 || result[1].Get("value") != float32(22) {
+			t.Error("Unexpected result", result)
+		}
+
+		// Between, which excludes the end time
+		if result, err := app.Store.Query(app.Store.NewQuery("temp").Between(ts.Add(time.Second), ts.Add(2*time.Second))); err != nil {
+			t.Error(err)
+		} else if len(result) != 1 || result[0].Get("room") != "hall" {
+			t.Error("Unexpected result", result)
+		}
+
+		// Unknown measurement
+		if result, err := app.Store.Query(app.Store.NewQuery("other").Since(time.Hour)); err != nil {
+			t.Error(err)
+		} else if len(result) != 0 {
+			t.Error("Unexpected result", result)
+		}
+
+		// Invalid queries
+		if _, err := app.Store.Query(app.Store.NewQuery("../temp")); err == nil {
+			t.Error("Expected error")
+		}
+		if _, err := app.Store.Query(app.Store.NewQuery("temp").Since(-time.Hour)); err == nil {
+			t.Error("Expected error")
+		}
+	})
+}
+
+func Test_Store_003(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	tool.Test(t, []string{"-tsdb.path", tempdir}, new(StoreApp), func(app *StoreApp) {
+		ts := time.Now().Add(-time.Hour).Truncate(time.Hour)
+		m := measurements(t, ts, "temp", "value float64", [][]interface{}{
+			{"kitchen", 20.0},
+			{"hall", 18.0},
+			{"kitchen", 22.0},
+			{"kitchen", 30.0},
+		})
+		if err := app.Store.Write(m...); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			q      gopi.MetricQuery
+			values []float64
+		}{
+			{app.Store.NewQuery("temp").Aggregate(gopi.METRIC_AGGREGATE_MEAN, 0), []float64{22.5}},
+			{app.Store.NewQuery("temp", "room").Aggregate(gopi.METRIC_AGGREGATE_MEAN, 0), []float64{24, 18}},
+			{app.Store.NewQuery("temp", "room").Aggregate(gopi.METRIC_AGGREGATE_MAX, time.Hour), []float64{30, 18}},
+			{app.Store.NewQuery("temp", "room").Aggregate(gopi.METRIC_AGGREGATE_MEDIAN, time.Hour), []float64{22, 18}},
+			{app.Store.NewQuery("temp").Aggregate(gopi.METRIC_AGGREGATE_COUNT, 2*time.Second), []float64{2, 2}},
+			{app.Store.NewQuery("temp").Aggregate(gopi.METRIC_AGGREGATE_SUM, 2*time.Second).Limit(1), []float64{38}},
+		}
+		for i, test := range tests {
+			result, err := app.Store.Query(test.q)
+			if err != nil {
+				t.Errorf("Test %v: %v", i, err)
+				continue
+			}
+			values := []float64{}
+			for _, m := range result {
+				values = append(values, m.Get("value").(float64))
+			}
+			if len(values) != len(test.values) {
+				t.Errorf("Test %v: Unexpected result %v", i, result)
+				continue
+			}
+			for j := range values {
+				if values[j] != test.values[j] {
+					t.Errorf("Test %v: Unexpected values %v, expected %v", i, values, test.values)
+					break
+				}
+			}
+		}
+
+		// Grouped measurements only include the group tags
+		if result, err := app.Store.Query(app.Store.NewQuery("temp", "room").Aggregate(gopi.METRIC_AGGREGATE_LAST, 0)); err != nil {
+			t.Error(err)
+		} else if len(result) != 2 || len(result[0].Tags()) != 1 || result[0].Get("room") != "kitchen" || result[0].Get("value") != 30.0 {
+			t.Error("Unexpected result", result)
+		}
+	})
+}
+
+func Test_Store_004(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	ts := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	tool.Test(t, []string{"-tsdb.path", tempdir}, new(StoreApp), func(app *StoreApp) {
+		if err := app.Store.Write(measurements(t, ts, "temp", "value float64", [][]interface{}{
+			{"kitchen", 20.0},
+			{"hall", 18.0},
+		})...); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Append an incomplete record, as if the power failed during a write
+	files, err := filepath.Glob(filepath.Join(tempdir, "temp", "raw", "*.seg"))
+	if err != nil || len(files) != 1 {
+		t.Fatal("Unexpected segments", files, err)
+	}
+	fh, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := fh.Write([]byte{0, 0, 0, 100, 1, 2, 3, 4, '{'}); err != nil {
+		t.Fatal(err)
+	}
+	fh.Close()
+
+	// The incomplete record is ignored, and removed when writing
+	tool.Test(t, []string{"-tsdb.path", tempdir}, new(StoreApp), func(app *StoreApp) {
+		if result, err := app.Store.Query(app.Store.NewQuery("temp")); err != nil {
+			t.Error(err)
+		} else if len(result) != 2 {
+			t.Error("Unexpected result", result)
+		}
+		if err := app.Store.Write(measurements(t, ts.Add(time.Minute), "temp", "value float64", [][]interface{}{
+			{"kitchen", 21.0},
+		})...); err != nil {
+			t.Fatal(err)
+		}
+		if result, err := app.Store.Query(app.Store.NewQuery("temp")); err != nil {
+			t.Error(err)
+		} else if len(result) != 3 || result[2].Get("value") != 21.0 {
+			t.Error("Unexpected result", result)
+		}
+	})
+}
+
+func Test_Store_005(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	// Write measurements three and six days ago
+	now := time.Now()
+	ts3 := now.Add(-3 * 24 * time.Hour).Truncate(time.Hour)
+	ts6 := now.Add(-6 * 24 * time.Hour).Truncate(time.Hour)
+	tool.Test(t, []string{"-tsdb.path", tempdir, "-tsdb.retain", "30d"}, new(StoreApp), func(app *StoreApp) {
+		for _, ts := range []time.Time{ts3, ts6} {
+			if err := app.Store.Write(measurements(t, ts, "temp", "value uint32, label string", [][]interface{}{
+				{"kitchen", uint32(10), "a"},
+				{"kitchen", uint32(20), "b"},
+				{"hall", uint32(5), "c"},
+			})...); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	// Downsample to hourly means after two days, and remove after five days
+	args := []string{"-tsdb.path", tempdir, "-tsdb.retain", "2d", "-tsdb.downsample", "1h:5d"}
+	tool.Test(t, args, new(StoreApp), func(app *StoreApp) {
+		if err := app.Store.Compact(); err != nil {
+			t.Fatal(err)
+		}
+		result, err := app.Store.Query(app.Store.NewQuery("temp"))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(result) != 2 {
+			t.Fatal("Unexpected result", result)
+		}
+		for _, m := range result {
+			if m.Time().Equal(ts3) == false {
+				t.Error("Unexpected time", m)
+			}
+			switch m.Get("room") {
+			case "kitchen":
+				if m.Get("value") != 15.0 || m.Get("label") != "b" {
+					t.Error("Unexpected measurement", m)
+				}
+			case "hall":
+				if m.Get("value") != 5.0 || m.Get("label") != "c" {
+					t.Error("Unexpected measurement", m)
+				}
+			default:
+				t.Error("Unexpected measurement", m)
+			}
+		}
+		if files, _ := filepath.Glob(filepath.Join(tempdir, "temp", "raw", "*")); len(files) != 0 {
+			t.Error("Unexpected raw segments", files)
+		}
+
+		// Measurements which have expired are not written
+		if err := app.Store.Write(measurements(t, ts3, "temp", "value uint32, label string", [][]interface{}{
+			{"kitchen", uint32(10), "a"},
+		})...); err == nil {
+			t.Error("Expected error")
+		}
+	})
+}
+
+func Test_Store_006(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	// Measurements emitted are written
+	tool.Test(t, []string{"-tsdb.path", tempdir}, new(StoreApp), func(app *StoreApp) {
+		if _, err := app.Metrics.NewMeasurement("temp", "value float64", metrics.NewField("room", "kitchen")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if err := app.Metrics.Emit("temp", nil, 21.5); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if result, err := app.Store.Query(app.Store.NewQuery("temp").Since(time.Minute)); err != nil {
+			t.Error(err)
+		} else if len(result) != 1 || result[0].Get("value") != 21.5 || result[0].Time().IsZero() {
+			t.Error("Unexpected result", result)
+		}
+	})
+}
+
+func Test_Store_007(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	// Write measurements three days ago
+	ts := time.Now().Add(-3 * 24 * time.Hour).Truncate(time.Hour)
+	tool.Test(t, []string{"-tsdb.path", tempdir, "-tsdb.retain", "30d"}, new(StoreApp), func(app *StoreApp) {
+		if err := app.Store.Write(measurements(t, ts, "temp", "value float64", [][]interface{}{
+			{"kitchen", 10.0},
+			{"kitchen", 20.0},
+			{"hall", 5.0},
+		})...); err != nil {
+			t.Fatal(err)
+		}
+	})
+	files, err := filepath.Glob(filepath.Join(tempdir, "temp", "raw", "*.seg"))
+	if err != nil || len(files) != 1 {
+		t.Fatal("Unexpected segments", files, err)
+	}
+	raw, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compact, then restore the raw segment as if compaction was
+	// interrupted between writing the downsampled segment and removing
+	// the raw segment. The raw segment is not returned by queries, and
+	// is removed when compacted again
+	args := []string{"-tsdb.path", tempdir, "-tsdb.retain", "2d", "-tsdb.downsample", "1h:5d"}
+	tool.Test(t, args, new(StoreApp), func(app *StoreApp) {
+		if err := app.Store.Compact(); err != nil {
+			t.Fatal(err)
+		} else if err := ioutil.WriteFile(files[0], raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if result, err := app.Store.Query(app.Store.NewQuery("temp")); err != nil {
+			t.Error(err)
+		} else if len(result) != 2 {
+			t.Error("Unexpected result", result)
+		}
+		if err := app.Store.Compact(); err != nil {
+			t.Fatal(err)
+		} else if files, _ := filepath.Glob(filepath.Join(tempdir, "temp", "raw", "*")); len(files) != 0 {
+			t.Error("Unexpected raw segments", files)
+		}
+		if result, err := app.Store.Query(app.Store.NewQuery("temp")); err != nil {
+			t.Error(err)
+		} else if len(result) != 2 {
+			t.Error("Unexpected result", result)
+		}
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// measurements returns measurements one second apart, where the
+// first value of each row is the room tag
+func measurements(t *testing.T, ts time.Time, name, def string, rows [][]interface{}) []gopi.Measurement {
+	t.Helper()
+	m, err := metrics.NewMeasurement(name, def, metrics.NewField("room", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make([]gopi.Measurement, 0, len(rows))
+	for i, row := range rows {
+		if that, err := m.Clone(ts.Add(time.Duration(i)*time.Second), []gopi.Field{metrics.NewField("room", row[0])}, row[1:]...); err != nil {
+			t.Fatal(err)
+		} else {
+			result = append(result, that)
+		}
+	}
+	return result
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	// Frameworks
@@ -24,6 +25,7 @@ import (
 
 type argonone struct {
 	gopi.Unit
+	sync.Mutex
 	gopi.I2C
 	gopi.Platform
 	gopi.Logger
@@ -36,6 +38,7 @@ type argonone struct {
 	tempzone    string
 	measurement string
 	fan         *Value
+	fanconfig   fanConfigArr
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -67,6 +70,7 @@ func (this *argonone) Define(cfg gopi.Config) error {
 	cfg.FlagUint("i2c.slave", 0x1A, "I2C Slave")
 	cfg.FlagString("argonone.zone", "", "Temperature zone")
 	cfg.FlagString("argonone.measurement", "cpufan", "Measurement name")
+	cfg.FlagString("argonone.fan", fanConfig.String(), "Fan duty cycle for temperature (celcius:fan,...)")
 	return nil
 }
 
@@ -93,6 +97,13 @@ func (this *argonone) New(cfg gopi.Config) error {
 	// Set Hysteresis to prevent flapping
 	this.fan = NewValueWithDelta(fanDelay)
 
+	// Set fan configuration
+	if fanconfig, err := parseFanConfig(cfg.GetString("argonone.fan")); err != nil {
+		return err
+	} else {
+		this.fanconfig = fanconfig
+	}
+
 	// Check platform
 	if this.Platform == nil {
 		return fmt.Errorf("Missing Platform interface")
@@ -121,6 +132,22 @@ func (this *argonone) New(cfg gopi.Config) error {
 	return nil
 }
 
+// Reload sets the fan configuration from the -argonone.fan flag
+func (this *argonone) Reload(cfg gopi.Config) error {
+	fanconfig, err := parseFanConfig(cfg.GetString("argonone.fan"))
+	if err != nil {
+		return err
+	}
+
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	this.fanconfig = fanconfig
+	this.Debug("Fan configuration: ", fanconfig)
+
+	// Return success
+	return nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // RUN
 
@@ -232,7 +259,9 @@ func (this *argonone) getTemperature() float32 {
 
 func (this *argonone) setFanForTemperature(celcius float32) error {
 	// Obtain fan value for temperature
-	fan, changed := this.fan.Set(fanConfig.fanForTemperature(celcius))
+	this.Mutex.Lock()
+	fan, changed := this.fan.Set(this.fanconfig.fanForTemperature(celcius))
+	this.Mutex.Unlock()
 
 	// Report measurement
 	if this.measurement != "" {
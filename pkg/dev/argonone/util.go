@@ -1,7 +1,12 @@
 package argonone
 
 import (
+	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+
+	gopi "github.com/djthorpe/gopi/v3"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -40,3 +45,45 @@ func (arr fanConfigArr) fanForTemperature(celcius float32) uint8 {
 	}
 	return fan
 }
+
+// String returns the fan configuration in the same format as the
+// -argonone.fan flag, for example 55:10,60:50,65:100
+func (arr fanConfigArr) String() string {
+	str := make([]string, 0, len(arr))
+	for _, v := range arr {
+		str = append(str, fmt.Sprintf("%v:%v", v.celcius, v.fan))
+	}
+	return strings.Join(str, ",")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// parseFanConfig parses comma-separated celcius:fan values, where fan
+// is the duty cycle between zero and one hundred
+func parseFanConfig(value string) (fanConfigArr, error) {
+	arr := fanConfigArr{}
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 {
+			return nil, gopi.ErrBadParameter.WithPrefix("-argonone.fan: ", strconv.Quote(pair))
+		}
+		celcius, err := strconv.ParseFloat(strings.TrimSpace(kv[0]), 32)
+		if err != nil {
+			return nil, gopi.ErrBadParameter.WithPrefix("-argonone.fan: ", strconv.Quote(pair))
+		}
+		fan, err := strconv.ParseUint(strings.TrimSpace(kv[1]), 10, 8)
+		if err != nil || fan > fanMax {
+			return nil, gopi.ErrBadParameter.WithPrefix("-argonone.fan: ", strconv.Quote(pair))
+		}
+		arr = append(arr, struct {
+			celcius float32
+			fan     uint8
+		}{float32(celcius), uint8(fan)})
+	}
+	sort.Sort(arr)
+	return arr, nil
+}
@@ -152,6 +152,18 @@ func (this *connection) String() string {
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// health returns an error if the connection is closed or the device
+// has not sent a heartbeat recently
+func (this *connection) health() error {
+	if this.isConnected() == false {
+		return gopi.ErrOutOfOrder.WithPrefix("Not connected")
+	} else if this.channel.ping.IsZero() == false && time.Since(this.channel.ping) > pingTimeout {
+		return gopi.ErrUnexpectedResponse.WithPrefix("Stale ping")
+	} else {
+		return nil
+	}
+}
+
 func (this *connection) send(data []byte) error {
 	if len(data) == 0 {
 		return nil
@@ -102,6 +102,24 @@ func (this *Manager) Run(ctx context.Context) error {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// HEALTH
+
+// Health returns an error for any connected device which has been
+// disconnected or has not sent a heartbeat recently
+func (this *Manager) Health(context.Context) error {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	var result error
+	for _, cast := range this.dev {
+		if err := cast.health(); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%v: %w", cast.Name(), err))
+		}
+	}
+	return result
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // IMPLEMENTATION
 
@@ -21,6 +21,19 @@ type App struct {
 	*rfm69.RFM69
 }
 
+// fakeSPI returns register values without any hardware
+type fakeSPI struct {
+	gopi.SPI
+	regs map[byte]byte
+}
+
+func (this *fakeSPI) SetMode(gopi.SPIBus, gopi.SPIMode) error { return nil }
+func (this *fakeSPI) SetMaxSpeedHz(gopi.SPIBus, uint32) error { return nil }
+
+func (this *fakeSPI) Transfer(_ gopi.SPIBus, data []byte) ([]byte, error) {
+	return []byte{0, this.regs[data[0]]}, nil
+}
+
 func (app *App) Run(ctx context.Context) error {
 	for {
 		select {
@@ -42,3 +55,14 @@ func Test_RFM69_001(t *testing.T) {
 		}
 	})
 }
+
+func Test_RFM69_002(t *testing.T) {
+	spi := &fakeSPI{regs: map[byte]byte{0x10: rfm69.RFM_VERSION_VALUE}}
+	tool.Test(t, nil, new(App), func(app *App) {
+		if version, err := app.RFM69.GetVersion(); err != nil {
+			t.Error(err)
+		} else if version != rfm69.RFM_VERSION_VALUE {
+			t.Errorf("Unexpected version 0x%02X", version)
+		}
+	}, tool.WithUnit((*gopi.SPI)(nil), spi))
+}
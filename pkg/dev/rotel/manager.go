@@ -32,6 +32,7 @@ type Manager struct {
 
 	fd  *term.Term // TTY file handle
 	buf *strings.Builder
+	err error // Last error reading or writing the TTY
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -128,6 +129,19 @@ func (this *Manager) Run(ctx context.Context) error {
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
+// Health returns an error if the TTY is not open or the last
+// read or write on the TTY failed
+func (this *Manager) Health(context.Context) error {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	if this.fd == nil {
+		return gopi.ErrOutOfOrder.WithPrefix("Health")
+	} else {
+		return this.err
+	}
+}
+
 func (this *Manager) SetPower(state bool) error {
 	if state {
 		return this.writetty("power_on!")
@@ -332,7 +346,11 @@ func (this *Manager) readtty() error {
 
 	// Append data to the buffer and parse any parameters
 	buf := make([]byte, 1024)
-	if n, err := this.fd.Read(buf); err == io.EOF {
+	n, err := this.fd.Read(buf)
+	if err != io.EOF {
+		this.setErr(err)
+	}
+	if err == io.EOF {
 		return nil
 	} else if err != nil {
 		return err
@@ -367,5 +385,12 @@ func (this *Manager) readtty() error {
 func (this *Manager) writetty(cmd string) error {
 	this.Debugf("writetty: %q", cmd)
 	_, err := this.fd.Write([]byte(cmd))
+	this.setErr(err)
 	return err
 }
+
+func (this *Manager) setErr(err error) {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+	this.err = err
+}
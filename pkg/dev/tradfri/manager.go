@@ -137,6 +137,22 @@ func (this *Manager) Version() string {
 	}
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// HEALTH
+
+// Health returns an error if the gateway is not connected or does
+// not respond to a ping
+func (this *Manager) Health(ctx context.Context) error {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	if this.ClientConn == nil {
+		return gopi.ErrOutOfOrder.WithPrefix("Health")
+	} else {
+		return this.ClientConn.PingWithContext(ctx)
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // CONNECT AND DISCONNECT
 
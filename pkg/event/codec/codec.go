@@ -0,0 +1,133 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// EncodeFunc returns the serialised form of an event
+type EncodeFunc func(gopi.Event) (interface{}, error)
+
+// DecodeFunc returns an event from serialised data
+type DecodeFunc func(json.RawMessage) (gopi.Event, error)
+
+type codec struct {
+	name   string
+	t      reflect.Type
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	lock   sync.RWMutex
+	codecs []*codec
+)
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Register adds a codec for events with the type of a value. A nil
+// pointer to an interface, for example (*gopi.LIRCEvent)(nil), matches
+// events which implement it. Codecs are matched in the order they are
+// registered, and a codec with an existing name replaces it
+func Register(name string, value interface{}, encode EncodeFunc, decode DecodeFunc) {
+	t := reflect.TypeOf(value)
+	if name == "" || t == nil || encode == nil || decode == nil {
+		panic("Register: Invalid parameters")
+	} else if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+		t = t.Elem()
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	c := &codec{name, t, encode, decode}
+	for i, other := range codecs {
+		if other.name == name {
+			codecs[i] = c
+			return
+		}
+	}
+	codecs = append(codecs, c)
+}
+
+// Names returns the names of registered codecs in order
+func Names() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	result := make([]string, len(codecs))
+	for i, c := range codecs {
+		result[i] = c.name
+	}
+	return result
+}
+
+// Encode returns the name of the codec for an event and the event
+// serialised as JSON, or ErrNotImplemented if no codec matches
+func Encode(evt gopi.Event) (string, []byte, error) {
+	if evt == nil {
+		return "", nil, gopi.ErrBadParameter.WithPrefix("Encode")
+	}
+	c := match(reflect.TypeOf(evt))
+	if c == nil {
+		return "", nil, gopi.ErrNotImplemented.WithPrefix("Encode: ", reflect.TypeOf(evt))
+	}
+	if v, err := c.encode(evt); err != nil {
+		return "", nil, fmt.Errorf("%v: %w", c.name, err)
+	} else if data, err := json.Marshal(v); err != nil {
+		return "", nil, fmt.Errorf("%v: %w", c.name, err)
+	} else {
+		return c.name, data, nil
+	}
+}
+
+// Decode returns an event from the name of a codec and serialised data
+func Decode(name string, data []byte) (gopi.Event, error) {
+	c := lookup(name)
+	if c == nil {
+		return nil, gopi.ErrNotFound.WithPrefix("Decode: ", name)
+	}
+	if evt, err := c.decode(json.RawMessage(data)); err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	} else {
+		return evt, nil
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func match(t reflect.Type) *codec {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, c := range codecs {
+		if t == c.t || (c.t.Kind() == reflect.Interface && t.Implements(c.t)) {
+			return c
+		}
+	}
+	return nil
+}
+
+func lookup(name string) *codec {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, c := range codecs {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package codec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/dev/rotel"
+	"github.com/djthorpe/gopi/v3/pkg/event/codec"
+	"github.com/djthorpe/gopi/v3/pkg/hw/gpio"
+	"github.com/djthorpe/gopi/v3/pkg/hw/lirc"
+	lirccodec "github.com/djthorpe/gopi/v3/pkg/hw/lirc/codec"
+	"github.com/djthorpe/gopi/v3/pkg/hw/lirc/keycode"
+	"github.com/djthorpe/gopi/v3/pkg/metrics"
+)
+
+func Test_Codec_001(t *testing.T) {
+	names := codec.Names()
+	for _, name := range []string{codec.LIRC, codec.GPIO, codec.Input, codec.Cast, codec.Rotel, codec.Measurement} {
+		found := false
+		for _, other := range names {
+			if name == other {
+				found = true
+			}
+		}
+		if found == false {
+			t.Error("Missing codec", name)
+		}
+	}
+	if _, _, err := codec.Encode(nil); err == nil {
+		t.Error("Expected error encoding nil")
+	}
+	if _, err := codec.Decode("unknown", []byte("{}")); err == nil {
+		t.Error("Expected error decoding unknown codec")
+	}
+}
+
+func Test_Codec_002(t *testing.T) {
+	// LIRC event
+	evt := roundtrip(t, codec.LIRC, lirc.NewEvent("lirc0", gopi.LIRC_MODE_MODE2, uint32(gopi.LIRC_TYPE_PULSE)|9000))
+	if e, ok := evt.(gopi.LIRCEvent); !ok {
+		t.Error("Unexpected event", evt)
+	} else if e.Name() != "lirc0" || e.Mode() != gopi.LIRC_MODE_MODE2 || e.Type() != gopi.LIRC_TYPE_PULSE || e.Value() != uint32(9000) {
+		t.Error("Unexpected event", e)
+	}
+
+	// GPIO event
+	evt = roundtrip(t, codec.GPIO, gpio.NewEvent("gpio", gopi.GPIOPin(17), gopi.GPIO_EDGE_RISING))
+	if e, ok := evt.(gopi.GPIOEvent); !ok {
+		t.Error("Unexpected event", evt)
+	} else if e.Name() != "gpio" || e.Pin() != 17 || e.Edge() != gopi.GPIO_EDGE_RISING {
+		t.Error("Unexpected event", e)
+	}
+
+	// Input event
+	evt = roundtrip(t, codec.Input, keycode.NewInputEvent("remote", gopi.KEYCODE_VOLUMEUP, &lirccodec.CodecEvent{Type: gopi.INPUT_EVENT_KEYPRESS, Device: gopi.INPUT_DEVICE_NEC_32, Code: 0xFFEF}))
+	if e, ok := evt.(gopi.InputEvent); !ok {
+		t.Error("Unexpected event", evt)
+	} else if device, code := e.Device(); e.Key() != gopi.KEYCODE_VOLUMEUP || e.Type() != gopi.INPUT_EVENT_KEYPRESS || device != gopi.INPUT_DEVICE_NEC_32|gopi.INPUT_DEVICE_REMOTE || code != 0xFFEF {
+		t.Error("Unexpected event", e)
+	}
+}
+
+func Test_Codec_003(t *testing.T) {
+	// Rotel event includes the state of the amplifier
+	state := new(rotel.State)
+	for _, param := range []string{"model=rsp1570", "power=on", "volume=42", "source=cd", "mute=on", "bass=-2"} {
+		if _, err := state.Set(param); err != nil {
+			t.Fatal(err)
+		}
+	}
+	evt := roundtrip(t, codec.Rotel, rotel.NewEvent(gopi.ROTEL_FLAG_VOLUME, state))
+	if e, ok := evt.(gopi.RotelEvent); !ok {
+		t.Error("Unexpected event", evt)
+	} else if e.Name() != "rsp1570" || e.Flags() != gopi.ROTEL_FLAG_VOLUME {
+		t.Error("Unexpected event", e)
+	} else if s, ok := evt.(interface {
+		Power() bool
+		Volume() uint
+		Source() string
+		Muted() bool
+		Bass() int
+	}); !ok {
+		t.Error("Missing state", e)
+	} else if s.Power() != true || s.Volume() != 42 || s.Source() != "cd" || s.Muted() != true || s.Bass() != -2 {
+		t.Error("Unexpected state", e)
+	}
+
+	// Encoding the decoded event again retains the state
+	if _, data, err := codec.Encode(evt); err != nil {
+		t.Error(err)
+	} else if _, data2, err := codec.Encode(rotel.NewEvent(gopi.ROTEL_FLAG_VOLUME, state)); err != nil {
+		t.Error(err)
+	} else if string(data) != string(data2) {
+		t.Errorf("Unexpected data %s, expected %s", data, data2)
+	}
+}
+
+func Test_Codec_004(t *testing.T) {
+	ts := time.Now().Truncate(time.Millisecond)
+	m, err := metrics.NewMeasurement("test", "a uint32, b float64, c bool, d string", metrics.NewField("host", "rpi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = m.Clone(ts, nil, uint32(42), float64(3.5), true, "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	evt := roundtrip(t, codec.Measurement, m)
+	if e, ok := evt.(gopi.Measurement); !ok {
+		t.Error("Unexpected event", evt)
+	} else if e.Name() != "test" || e.Time().Equal(ts) == false {
+		t.Error("Unexpected measurement", e)
+	} else if len(e.Tags()) != 1 || e.Get("host") != "rpi" {
+		t.Error("Unexpected tags", e)
+	} else if e.Get("a") != uint32(42) || e.Get("b") != float64(3.5) || e.Get("c") != true || e.Get("d") != "value" {
+		t.Error("Unexpected metrics", e)
+	}
+}
+
+func Test_Codec_005(t *testing.T) {
+	// Events without a codec are not encoded
+	if _, _, err := codec.Encode(&lirccodec.CodecEvent{}); err == nil {
+		t.Error("Expected error for event without codec")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func roundtrip(t *testing.T, name string, evt gopi.Event) gopi.Event {
+	t.Helper()
+	if name_, data, err := codec.Encode(evt); err != nil {
+		t.Fatal(err)
+	} else if name_ != name {
+		t.Fatalf("Unexpected codec %q for %v", name_, evt)
+	} else if evt, err := codec.Decode(name, data); err != nil {
+		t.Fatal(err)
+	} else {
+		t.Logf("%s => %v", data, evt)
+		return evt
+	}
+	return nil
+}
+
+func Test_Codec_006(t *testing.T) {
+	evt := roundtrip(t, codec.Cast, &castEvent{"Living Room", gopi.CAST_FLAG_VOLUME})
+	if e, ok := evt.(gopi.CastEvent); !ok {
+		t.Error("Unexpected event", evt)
+	} else if e.Name() != "Living Room" || e.Flags() != gopi.CAST_FLAG_VOLUME {
+		t.Error("Unexpected event", e)
+	} else if c := e.Cast(); c == nil {
+		t.Error("Missing cast", e)
+	} else if volume, muted := c.Volume(); c.Id() != "cast1" || c.Name() != "Living Room" || volume != 0.5 || muted != true {
+		t.Error("Unexpected cast", c)
+	}
+}
+
+func Test_Codec_007(t *testing.T) {
+	m, err := metrics.NewMeasurement("test", "a counter, b histogram(0.5,1), c summary(0.5), d gauge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = m.Clone(time.Now(), nil, 2, 0.75, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evt := roundtrip(t, codec.Measurement, m)
+	e, ok := evt.(gopi.Measurement)
+	if !ok {
+		t.Fatal("Unexpected event", evt)
+	}
+	if v, ok := e.Get("a").(gopi.MetricCounter); !ok || v.Total != 2 {
+		t.Error("Unexpected counter", e.Get("a"))
+	}
+	if v, ok := e.Get("b").(gopi.MetricHistogram); !ok || v.Count != 1 || len(v.Buckets) != 2 || v.Buckets[0].Count != 0 || v.Buckets[1] != (gopi.MetricBucket{UpperBound: 1, Count: 1}) {
+		t.Error("Unexpected histogram", e.Get("b"))
+	}
+	if v, ok := e.Get("c").(gopi.MetricSummary); !ok || v.Count != 1 || len(v.Quantiles) != 1 || v.Quantiles[0] != (gopi.MetricQuantile{Quantile: 0.5, Value: 3}) {
+		t.Error("Unexpected summary", e.Get("c"))
+	}
+	if e.Get("d") != float64(4) {
+		t.Error("Unexpected gauge", e.Get("d"))
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CAST EVENT
+
+type castEvent struct {
+	name  string
+	flags gopi.CastFlag
+}
+
+func (this *castEvent) Name() string            { return this.name }
+func (this *castEvent) Flags() gopi.CastFlag    { return this.flags }
+func (this *castEvent) Cast() gopi.Cast         { return this }
+func (this *castEvent) Id() string              { return "cast1" }
+func (this *castEvent) Model() string           { return "Chromecast" }
+func (this *castEvent) Service() string         { return "" }
+func (this *castEvent) State() uint             { return 0 }
+func (this *castEvent) Volume() (float32, bool) { return 0.5, true }
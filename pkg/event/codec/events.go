@@ -0,0 +1,401 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+type lircEvent struct {
+	Name_  string        `json:"name,omitempty"`
+	Mode_  gopi.LIRCMode `json:"mode"`
+	Type_  gopi.LIRCType `json:"type"`
+	Value_ uint32        `json:"value"`
+}
+
+type gpioEvent struct {
+	Name_ string        `json:"name,omitempty"`
+	Pin_  gopi.GPIOPin  `json:"pin"`
+	Edge_ gopi.GPIOEdge `json:"edge"`
+}
+
+type inputEvent struct {
+	Name_   string               `json:"name,omitempty"`
+	Key_    gopi.KeyCode         `json:"key"`
+	Type_   gopi.InputType       `json:"type"`
+	Device_ gopi.InputDeviceType `json:"device"`
+	Code_   uint32               `json:"code"`
+}
+
+type castEvent struct {
+	Name_  string        `json:"name,omitempty"`
+	Flags_ gopi.CastFlag `json:"flags"`
+	Cast_  *cast         `json:"cast,omitempty"`
+}
+
+type cast struct {
+	Id_      string  `json:"id"`
+	Name_    string  `json:"name,omitempty"`
+	Model_   string  `json:"model,omitempty"`
+	Service_ string  `json:"service,omitempty"`
+	State_   uint    `json:"state"`
+	Volume_  float32 `json:"volume"`
+	Muted_   bool    `json:"muted"`
+}
+
+// rotelEvent includes a snapshot of amplifier state when the
+// event provides it
+type rotelEvent struct {
+	Name_     string         `json:"name,omitempty"`
+	Flags_    gopi.RotelFlag `json:"flags"`
+	Power_    bool           `json:"power"`
+	Source_   string         `json:"source,omitempty"`
+	Freq_     string         `json:"freq,omitempty"`
+	Volume_   uint           `json:"volume"`
+	Muted_    bool           `json:"muted"`
+	Bypass_   bool           `json:"bypass"`
+	Bass_     int            `json:"bass"`
+	Treble_   int            `json:"treble"`
+	Balance_  string         `json:"balance,omitempty"`
+	Scalar_   uint           `json:"scalar"`
+	Speakers_ []string       `json:"speakers,omitempty"`
+	Dimmer_   uint           `json:"dimmer"`
+}
+
+// rotelStateGetter is implemented by events which embed amplifier state
+type rotelStateGetter interface {
+	Power() bool
+	Source() string
+	Freq() string
+	Volume() uint
+	Muted() bool
+	Bypass() bool
+	Bass() int
+	Treble() int
+	Balance() (string, uint)
+	Speakers() []string
+	Dimmer() uint
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	LIRC        = "lirc"
+	GPIO        = "gpio"
+	Input       = "input"
+	Cast        = "cast"
+	Rotel       = "rotel"
+	Measurement = "measurement"
+)
+
+/////////////////////////////////////////////////////////////////////
+// INIT
+
+func init() {
+	Register(LIRC, (*gopi.LIRCEvent)(nil), encodeLIRC, decode(func() gopi.Event { return new(lircEvent) }))
+	Register(GPIO, (*gopi.GPIOEvent)(nil), encodeGPIO, decode(func() gopi.Event { return new(gpioEvent) }))
+	Register(Input, (*gopi.InputEvent)(nil), encodeInput, decode(func() gopi.Event { return new(inputEvent) }))
+	Register(Cast, (*gopi.CastEvent)(nil), encodeCast, decode(func() gopi.Event { return new(castEvent) }))
+	Register(Rotel, (*gopi.RotelEvent)(nil), encodeRotel, decode(func() gopi.Event { return new(rotelEvent) }))
+	Register(Measurement, (*gopi.Measurement)(nil), encodeMeasurement, decodeMeasurement)
+}
+
+/////////////////////////////////////////////////////////////////////
+// ENCODE
+
+func encodeLIRC(evt gopi.Event) (interface{}, error) {
+	e := evt.(gopi.LIRCEvent)
+	value, ok := e.Value().(uint32)
+	if !ok {
+		return nil, gopi.ErrBadParameter.WithPrefix("Value: ", e.Value())
+	}
+	return &lircEvent{e.Name(), e.Mode(), e.Type(), value}, nil
+}
+
+func encodeGPIO(evt gopi.Event) (interface{}, error) {
+	e := evt.(gopi.GPIOEvent)
+	return &gpioEvent{e.Name(), e.Pin(), e.Edge()}, nil
+}
+
+func encodeInput(evt gopi.Event) (interface{}, error) {
+	e := evt.(gopi.InputEvent)
+	device, code := e.Device()
+	return &inputEvent{e.Name(), e.Key(), e.Type(), device, code}, nil
+}
+
+func encodeCast(evt gopi.Event) (interface{}, error) {
+	e := evt.(gopi.CastEvent)
+	result := &castEvent{Name_: e.Name(), Flags_: e.Flags()}
+	if c := e.Cast(); c != nil {
+		volume, muted := c.Volume()
+		result.Cast_ = &cast{c.Id(), c.Name(), c.Model(), c.Service(), c.State(), volume, muted}
+	}
+	return result, nil
+}
+
+func encodeRotel(evt gopi.Event) (interface{}, error) {
+	e := evt.(gopi.RotelEvent)
+	if s, ok := evt.(rotelStateGetter); ok {
+		balance, scalar := s.Balance()
+		return &rotelEvent{
+			e.Name(), e.Flags(),
+			s.Power(), s.Source(), s.Freq(), s.Volume(), s.Muted(), s.Bypass(),
+			s.Bass(), s.Treble(), balance, scalar, s.Speakers(), s.Dimmer(),
+		}, nil
+	} else {
+		return &rotelEvent{Name_: e.Name(), Flags_: e.Flags()}, nil
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// DECODE
+
+// decode returns a function which unmarshals data into a new event
+func decode(fn func() gopi.Event) DecodeFunc {
+	return func(data json.RawMessage) (gopi.Event, error) {
+		evt := fn()
+		if err := json.Unmarshal(data, evt); err != nil {
+			return nil, err
+		}
+		return evt, nil
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// LIRC EVENT
+
+func (this *lircEvent) Name() string {
+	return this.Name_
+}
+
+func (this *lircEvent) Type() gopi.LIRCType {
+	return this.Type_
+}
+
+func (this *lircEvent) Mode() gopi.LIRCMode {
+	return this.Mode_
+}
+
+func (this *lircEvent) Value() interface{} {
+	return this.Value_
+}
+
+func (this *lircEvent) String() string {
+	str := "<lircevent"
+	if this.Name_ != "" {
+		str += " name=" + strconv.Quote(this.Name_)
+	}
+	str += " mode=" + fmt.Sprint(this.Mode_)
+	str += " type=" + fmt.Sprint(this.Type_)
+	str += " value=" + fmt.Sprint(this.Value_)
+	return str + ">"
+}
+
+/////////////////////////////////////////////////////////////////////
+// GPIO EVENT
+
+func (this *gpioEvent) Name() string {
+	return this.Name_
+}
+
+func (this *gpioEvent) Pin() gopi.GPIOPin {
+	return this.Pin_
+}
+
+func (this *gpioEvent) Edge() gopi.GPIOEdge {
+	return this.Edge_
+}
+
+func (this *gpioEvent) String() string {
+	str := "<gpio.event"
+	if this.Name_ != "" {
+		str += " name=" + strconv.Quote(this.Name_)
+	}
+	str += " pin=" + fmt.Sprint(this.Pin_)
+	str += " edge=" + fmt.Sprint(this.Edge_)
+	return str + ">"
+}
+
+/////////////////////////////////////////////////////////////////////
+// INPUT EVENT
+
+func (this *inputEvent) Name() string {
+	return this.Name_
+}
+
+func (this *inputEvent) Key() gopi.KeyCode {
+	return this.Key_
+}
+
+func (this *inputEvent) Type() gopi.InputType {
+	return this.Type_
+}
+
+func (this *inputEvent) Device() (gopi.InputDeviceType, uint32) {
+	return this.Device_, this.Code_
+}
+
+func (this *inputEvent) String() string {
+	str := "<event.input"
+	if this.Name_ != "" {
+		str += " name=" + strconv.Quote(this.Name_)
+	}
+	str += " key=" + fmt.Sprint(this.Key_)
+	str += " type=" + fmt.Sprint(this.Type_)
+	str += " device=" + fmt.Sprint(this.Device_)
+	str += fmt.Sprintf(" code=0x%08X", this.Code_)
+	return str + ">"
+}
+
+/////////////////////////////////////////////////////////////////////
+// CAST EVENT
+
+func (this *castEvent) Name() string {
+	return this.Name_
+}
+
+func (this *castEvent) Flags() gopi.CastFlag {
+	return this.Flags_
+}
+
+func (this *castEvent) Cast() gopi.Cast {
+	if this.Cast_ == nil {
+		return nil
+	}
+	return this.Cast_
+}
+
+func (this *cast) Id() string {
+	return this.Id_
+}
+
+func (this *cast) Name() string {
+	return this.Name_
+}
+
+func (this *cast) Model() string {
+	return this.Model_
+}
+
+func (this *cast) Service() string {
+	return this.Service_
+}
+
+func (this *cast) State() uint {
+	return this.State_
+}
+
+func (this *cast) Volume() (float32, bool) {
+	return this.Volume_, this.Muted_
+}
+
+func (this *castEvent) String() string {
+	str := "<cast.event"
+	if this.Name_ != "" {
+		str += " name=" + strconv.Quote(this.Name_)
+	}
+	str += " flags=" + fmt.Sprint(this.Flags_)
+	if this.Cast_ != nil {
+		str += " " + fmt.Sprint(this.Cast_)
+	}
+	return str + ">"
+}
+
+func (this *cast) String() string {
+	str := "<cast"
+	str += " id=" + strconv.Quote(this.Id_)
+	if this.Name_ != "" {
+		str += " name=" + strconv.Quote(this.Name_)
+	}
+	if this.Service_ != "" {
+		str += " service=" + strconv.Quote(this.Service_)
+	}
+	str += fmt.Sprint(" volume=", this.Volume_, " muted=", this.Muted_)
+	return str + ">"
+}
+
+/////////////////////////////////////////////////////////////////////
+// ROTEL EVENT
+
+func (this *rotelEvent) Name() string {
+	return this.Name_
+}
+
+func (this *rotelEvent) Flags() gopi.RotelFlag {
+	return this.Flags_
+}
+
+func (this *rotelEvent) Power() bool {
+	return this.Power_
+}
+
+func (this *rotelEvent) Source() string {
+	return this.Source_
+}
+
+func (this *rotelEvent) Freq() string {
+	return this.Freq_
+}
+
+func (this *rotelEvent) Volume() uint {
+	return this.Volume_
+}
+
+func (this *rotelEvent) Muted() bool {
+	return this.Muted_
+}
+
+func (this *rotelEvent) Bypass() bool {
+	return this.Bypass_
+}
+
+func (this *rotelEvent) Bass() int {
+	return this.Bass_
+}
+
+func (this *rotelEvent) Treble() int {
+	return this.Treble_
+}
+
+func (this *rotelEvent) Balance() (string, uint) {
+	return this.Balance_, this.Scalar_
+}
+
+func (this *rotelEvent) Speakers() []string {
+	return this.Speakers_
+}
+
+func (this *rotelEvent) Dimmer() uint {
+	return this.Dimmer_
+}
+
+func (this *rotelEvent) String() string {
+	str := "<rotel.event"
+	str += fmt.Sprintf(" name=%q", this.Name_)
+	if this.Flags_ != gopi.ROTEL_FLAG_NONE {
+		str += fmt.Sprint(" flags=", this.Flags_)
+	}
+	str += fmt.Sprint(" power=", this.Power_)
+	if this.Source_ != "" {
+		str += fmt.Sprintf(" source=%q", this.Source_)
+	}
+	if this.Freq_ != "" {
+		str += fmt.Sprintf(" freq=%q", this.Freq_)
+	}
+	if this.Volume_ != 0 {
+		str += fmt.Sprint(" vol=", this.Volume_)
+	}
+	if this.Muted_ {
+		str += fmt.Sprint(" mute=", this.Muted_)
+	}
+	if this.Bypass_ {
+		str += fmt.Sprint(" bypass=", this.Bypass_)
+	}
+	return str + ">"
+}
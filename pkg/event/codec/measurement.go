@@ -0,0 +1,170 @@
+package codec
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/metrics"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+type measurementData struct {
+	Name    string      `json:"name"`
+	Time    time.Time   `json:"ts,omitempty"`
+	Tags    []fieldData `json:"tags,omitempty"`
+	Metrics []fieldData `json:"metrics,omitempty"`
+}
+
+// fieldData includes the kind of a field, so that the value is
+// decoded with the same type
+type fieldData struct {
+	Name  string      `json:"name"`
+	Kind  string      `json:"kind"`
+	Value interface{} `json:"value"`
+}
+
+/////////////////////////////////////////////////////////////////////
+// ENCODE AND DECODE
+
+func encodeMeasurement(evt gopi.Event) (interface{}, error) {
+	m := evt.(gopi.Measurement)
+	return &measurementData{m.Name(), m.Time(), encodeFields(m.Tags()), encodeFields(m.Metrics())}, nil
+}
+
+func decodeMeasurement(data json.RawMessage) (gopi.Event, error) {
+	var v struct {
+		Name    string            `json:"name"`
+		Time    time.Time         `json:"ts,omitempty"`
+		Tags    []json.RawMessage `json:"tags,omitempty"`
+		Metrics []json.RawMessage `json:"metrics,omitempty"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	tags, err := decodeFields(v.Tags)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := decodeFields(v.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	return metrics.NewMeasurementWithFields(v.Name, v.Time, tags, fields), nil
+}
+
+func encodeFields(fields []gopi.Field) []fieldData {
+	result := make([]fieldData, 0, len(fields))
+	for _, f := range fields {
+		result = append(result, fieldData{f.Name(), f.Kind(), f.Value()})
+	}
+	return result
+}
+
+func decodeFields(data []json.RawMessage) ([]gopi.Field, error) {
+	result := make([]gopi.Field, 0, len(data))
+	for _, data := range data {
+		var v struct {
+			Name  string          `json:"name"`
+			Kind  string          `json:"kind"`
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		value, err := decodeValue(v.Kind, v.Value)
+		if err != nil {
+			return nil, gopi.ErrBadParameter.WithPrefix(v.Name, ": ", err)
+		}
+		var f gopi.Field
+		if value == nil {
+			f = metrics.NewField(v.Name)
+		} else {
+			f = metrics.NewField(v.Name, value)
+		}
+		if f == nil {
+			return nil, gopi.ErrBadParameter.WithPrefix("Field: ", strconv.Quote(v.Name))
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// decodeValue returns a value with the type of a field kind
+func decodeValue(kind string, data json.RawMessage) (interface{}, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var err error
+	switch kind {
+	case "string":
+		var v string
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "bool":
+		var v bool
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "uint8":
+		var v uint8
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "uint16":
+		var v uint16
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "uint32":
+		var v uint32
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "uint64":
+		var v uint64
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "int8":
+		var v int8
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "int16":
+		var v int16
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "int32":
+		var v int32
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "int64":
+		var v int64
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "float32":
+		var v float32
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "float64", "gauge":
+		var v float64
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "time.Time":
+		var v time.Time
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "counter":
+		var v gopi.MetricCounter
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "histogram":
+		var v gopi.MetricHistogram
+		err = json.Unmarshal(data, &v)
+		return v, err
+	case "summary":
+		var v gopi.MetricSummary
+		err = json.Unmarshal(data, &v)
+		return v, err
+	default:
+		return nil, gopi.ErrBadParameter.WithPrefix("Kind: ", kind)
+	}
+}
@@ -3,8 +3,10 @@ package event
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/djthorpe/gopi/v3"
+	multierror "github.com/hashicorp/go-multierror"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -16,10 +18,27 @@ type Promises struct {
 }
 
 type promise struct {
-	parent  context.Context
-	chain   []PromiseFunc
-	value   interface{}
-	finally PromiseFinally
+	sync.Mutex
+	parent    context.Context
+	chain     []*step
+	value     interface{}
+	cancel    context.CancelFunc
+	cancelled bool
+	started   bool
+}
+
+// step is a function in the chain, with an optional timeout for each
+// call and policy for retrying when it fails
+type step struct {
+	fn      PromiseFunc
+	timeout time.Duration
+	retry   *gopi.PromiseRetry
+}
+
+type result struct {
+	index int
+	value interface{}
+	err   error
 }
 
 // A promise function executes functions sequentially and calls error
@@ -29,6 +48,15 @@ type PromiseFunc func(context.Context, interface{}) (interface{}, error)
 // A Promise error is the last in the chain and acts on any error
 type PromiseFinally func(interface{}, error) error
 
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// minRetryDelay is the delay between attempts when there is no limit
+	// on the number of attempts and no delay is set
+	minRetryDelay = 10 * time.Millisecond
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
@@ -50,38 +78,113 @@ func (this *Promises) Do(ctx context.Context, fn func(context.Context, interface
 
 	// Return a promise context
 	return &promise{
-		parent:  ctx,
-		chain:   []PromiseFunc{fn},
-		value:   v,
-		finally: nil,
+		parent: ctx,
+		chain:  []*step{{fn: fn}},
+		value:  v,
 	}
 }
 
+func (this *Promises) All(ctx context.Context, promises ...gopi.Promise) gopi.Promise {
+	return this.Do(ctx, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// Cancel the other promises on the first error
+		values := make([]interface{}, len(promises))
+		for r := range runAll(ctx, promises) {
+			if r.err != nil {
+				return nil, r.err
+			}
+			values[r.index] = r.value
+		}
+		return values, nil
+	}, nil)
+}
+
+func (this *Promises) Any(ctx context.Context, promises ...gopi.Promise) gopi.Promise {
+	return this.Do(ctx, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if len(promises) == 0 {
+			return nil, gopi.ErrBadParameter.WithPrefix("Any")
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// Return the first success, or all errors
+		var errs error
+		for r := range runAll(ctx, promises) {
+			if r.err == nil {
+				return r.value, nil
+			}
+			errs = multierror.Append(errs, r.err)
+		}
+		return nil, errs
+	}, nil)
+}
+
+func (this *Promises) Race(ctx context.Context, promises ...gopi.Promise) gopi.Promise {
+	return this.Do(ctx, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if len(promises) == 0 {
+			return nil, gopi.ErrBadParameter.WithPrefix("Race")
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// Return the first to complete
+		r := <-runAll(ctx, promises)
+		return r.value, r.err
+	}, nil)
+}
+
 func (this *promise) Then(fn func(context.Context, interface{}) (interface{}, error)) gopi.Promise {
 	if fn == nil {
 		return nil
 	} else {
-		this.chain = append(this.chain, fn)
+		this.chain = append(this.chain, &step{fn: fn})
+	}
+	return this
+}
+
+func (this *promise) Timeout(timeout time.Duration) gopi.Promise {
+	if timeout < 0 {
+		return nil
+	} else {
+		this.chain[len(this.chain)-1].timeout = timeout
 	}
 	return this
 }
 
+func (this *promise) Retry(policy gopi.PromiseRetry) gopi.Promise {
+	this.chain[len(this.chain)-1].retry = &policy
+	return this
+}
+
 func (this *promise) Finally(fn func(interface{}, error) error, wait bool) error {
 	var wg sync.WaitGroup
 	var err error
-	if fn != nil {
-		this.finally = fn
+
+	// A promise can only be run once
+	if err := this.start(); err != nil {
+		return err
 	}
+
 	wg.Add(1)
 	go func() {
 		// Run the chain of promises
-		err = this.run()
+		value, result := this.run(nil)
+		if fn != nil {
+			err = fn(value, result)
+		} else {
+			err = result
+		}
 
 		// Release resources
+		this.Lock()
 		this.parent = nil
 		this.chain = nil
 		this.value = nil
-		this.finally = nil
+		this.Unlock()
 
 		// Indicate done
 		wg.Done()
@@ -96,24 +199,167 @@ func (this *promise) Finally(fn func(interface{}, error) error, wait bool) error
 	}
 }
 
+func (this *promise) Cancel() {
+	this.Lock()
+	defer this.Unlock()
+	this.cancelled = true
+	if this.cancel != nil {
+		this.cancel()
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
-// Run the promise in foreground and call finally when completed
-func (this *promise) run() error {
-	for {
+// start marks the promise as started, or returns an error if it has
+// already been started
+func (this *promise) start() error {
+	this.Lock()
+	defer this.Unlock()
+	if this.started {
+		return gopi.ErrOutOfOrder.WithPrefix("Promise has already been started")
+	}
+	this.started = true
+	return nil
+}
+
+// Run the chain of functions and return the last value, or the value and
+// error from the function which failed. The chain is cancelled with either
+// the context or the parent context of the promise, and the parent context
+// is used when the context is nil
+func (this *promise) run(parent context.Context) (interface{}, error) {
+	this.Lock()
+	if parent == nil {
+		parent = this.parent
+	}
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	this.cancel = cancel
+	if this.cancelled {
+		cancel()
+	}
+	value, chain := this.value, this.chain
+	if parent != this.parent {
+		// Cancel when the parent context of the promise is done
+		go func(parent context.Context) {
+			select {
+			case <-parent.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}(this.parent)
+	}
+	this.Unlock()
+
+	for _, step := range chain {
 		select {
-		case <-this.parent.Done():
-			return this.finally(this.value, this.parent.Err())
+		case <-ctx.Done():
+			return value, ctx.Err()
 		default:
-			if len(this.chain) == 0 {
-				return this.finally(this.value, nil)
-			} else if out, err := this.chain[0](this.parent, this.value); err != nil {
-				return this.finally(out, err)
+			if out, err := step.run(ctx, value); err != nil {
+				return out, err
 			} else {
-				this.chain = this.chain[1:]
-				this.value = out
+				value = out
 			}
 		}
 	}
+	return value, nil
+}
+
+// run calls the function and retries according to the policy
+func (this *step) run(ctx context.Context, value interface{}) (interface{}, error) {
+	var delay time.Duration
+	if this.retry != nil {
+		delay = this.retry.Delay
+		if delay <= 0 && this.retry.Attempts == 0 {
+			delay = minRetryDelay
+		}
+	}
+	for attempt := uint(1); ; attempt++ {
+		out, err := this.call(ctx, value)
+		if err == nil || this.retry == nil {
+			return out, err
+		} else if ctx.Err() != nil {
+			return out, ctx.Err()
+		} else if this.retry.Attempts > 0 && attempt >= this.retry.Attempts {
+			return out, err
+		} else if this.retry.Retry != nil && this.retry.Retry(err) == false {
+			return out, err
+		}
+
+		// Wait before the next attempt
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return out, ctx.Err()
+		case <-timer.C:
+		}
+		if delay *= 2; this.retry.MaxDelay > 0 && delay > this.retry.MaxDelay {
+			delay = this.retry.MaxDelay
+		}
+	}
+}
+
+// call the function, and return when the timeout expires even if the
+// function has not returned
+func (this *step) call(ctx context.Context, value interface{}) (interface{}, error) {
+	if this.timeout == 0 {
+		return this.fn(ctx, value)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, this.timeout)
+	defer cancel()
+
+	ch := make(chan result, 1)
+	go func() {
+		out, err := this.fn(ctx, value)
+		ch <- result{0, out, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runAll runs promises in parallel and returns a channel of results,
+// which is closed when all have completed
+func runAll(ctx context.Context, promises []gopi.Promise) <-chan result {
+	var wg sync.WaitGroup
+	ch := make(chan result, len(promises))
+	for i, p := range promises {
+		wg.Add(1)
+		go func(i int, p gopi.Promise) {
+			defer wg.Done()
+			value, err := runPromise(ctx, p)
+			ch <- result{i, value, err}
+		}(i, p)
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+	return ch
+}
+
+// runPromise runs a promise with a context, or waits for a promise
+// with another implementation. Returns an error if the promise has
+// already been started with Finally
+func runPromise(ctx context.Context, p gopi.Promise) (interface{}, error) {
+	if p == nil {
+		return nil, gopi.ErrBadParameter.WithPrefix("Promise")
+	} else if p, ok := p.(*promise); ok {
+		if err := p.start(); err != nil {
+			return nil, err
+		}
+		return p.run(ctx)
+	}
+	var value interface{}
+	err := p.Finally(func(v interface{}, err error) error {
+		value = v
+		return err
+	}, true)
+	return value, err
 }
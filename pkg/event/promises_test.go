@@ -1,9 +1,11 @@
 package event_test
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -44,14 +46,42 @@ func (this *PromiseApp) Delay(ctx context.Context, v interface{}) (interface{},
 	return v, nil
 }
 
-func (this *PromiseApp) Finally(v interface{}, err error) {
+func (this *PromiseApp) Finally(v interface{}, err error) error {
 	if err != nil {
 		fmt.Println("ERROR", err)
 	} else {
 		fmt.Println("SUCCESS", string(v.([]byte)))
 	}
+	return err
 }
 
+// Value returns a function which returns a value after a delay
+func (this *PromiseApp) Value(value interface{}, delay time.Duration) PromiseFunc {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+			return value, nil
+		}
+	}
+}
+
+// Fail returns a function which returns an error after a delay
+func (this *PromiseApp) Fail(err error, delay time.Duration) PromiseFunc {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+			return nil, err
+		}
+	}
+}
+
+// PromiseFunc is a function in a promise chain
+type PromiseFunc func(context.Context, interface{}) (interface{}, error)
+
 func Test_Promise_000(t *testing.T) {
 	tool.Test(t, nil, new(PromiseApp), func(app *PromiseApp) {
 		if app.Promises == nil {
@@ -87,3 +117,208 @@ func Test_Promise_002(t *testing.T) {
 		app.Print("<= Done")
 	})
 }
+
+func Test_Promise_003(t *testing.T) {
+	tool.Test(t, nil, new(PromiseApp), func(app *PromiseApp) {
+		// All resolves with values in order
+		var values interface{}
+		if err := app.All(context.Background(),
+			app.Do(nil, app.Value(1, 50*time.Millisecond), nil),
+			app.Do(nil, app.Value(2, 10*time.Millisecond), nil),
+			app.Do(nil, app.Value(3, 0), nil),
+		).Finally(func(v interface{}, err error) error {
+			values = v
+			return err
+		}, true); err != nil {
+			t.Error(err)
+		} else if fmt.Sprint(values) != "[1 2 3]" {
+			t.Error("Unexpected values", values)
+		}
+
+		// All fails on the first error, and cancels the other promises
+		start := time.Now()
+		if err := app.All(context.Background(),
+			app.Do(nil, app.Value(1, time.Second), nil),
+			app.Do(nil, app.Fail(gopi.ErrNotFound, 10*time.Millisecond), nil),
+		).Finally(nil, true); errors.Is(err, gopi.ErrNotFound) == false {
+			t.Error("Expected ErrNotFound, got", err)
+		} else if since := time.Since(start); since > 500*time.Millisecond {
+			t.Error("Expected other promises to be cancelled", since)
+		}
+	})
+}
+
+func Test_Promise_004(t *testing.T) {
+	tool.Test(t, nil, new(PromiseApp), func(app *PromiseApp) {
+		// Any resolves with the first success
+		var value interface{}
+		if err := app.Any(context.Background(),
+			app.Do(nil, app.Fail(gopi.ErrNotFound, 0), nil),
+			app.Do(nil, app.Value(1, 100*time.Millisecond), nil),
+			app.Do(nil, app.Value(2, 10*time.Millisecond), nil),
+		).Finally(func(v interface{}, err error) error {
+			value = v
+			return err
+		}, true); err != nil {
+			t.Error(err)
+		} else if value != 2 {
+			t.Error("Unexpected value", value)
+		}
+
+		// Any fails when all promises fail
+		if err := app.Any(context.Background(),
+			app.Do(nil, app.Fail(gopi.ErrNotFound, 0), nil),
+			app.Do(nil, app.Fail(gopi.ErrBadParameter, 10*time.Millisecond), nil),
+		).Finally(nil, true); errors.Is(err, gopi.ErrNotFound) == false || errors.Is(err, gopi.ErrBadParameter) == false {
+			t.Error("Expected all errors, got", err)
+		}
+
+		// Race fails with the first to complete
+		if err := app.Race(context.Background(),
+			app.Do(nil, app.Value(1, 100*time.Millisecond), nil),
+			app.Do(nil, app.Fail(gopi.ErrNotFound, 10*time.Millisecond), nil),
+		).Finally(nil, true); errors.Is(err, gopi.ErrNotFound) == false {
+			t.Error("Expected ErrNotFound, got", err)
+		}
+	})
+}
+
+func Test_Promise_005(t *testing.T) {
+	tool.Test(t, nil, new(PromiseApp), func(app *PromiseApp) {
+		// A step which times out fails the chain
+		if err := app.Do(nil, app.Value(1, 0), nil).
+			Then(app.Value(2, time.Second)).Timeout(50*time.Millisecond).
+			Finally(nil, true); errors.Is(err, context.DeadlineExceeded) == false {
+			t.Error("Expected deadline exceeded, got", err)
+		}
+
+		// A step which ignores the context still times out
+		if err := app.Do(nil, func(context.Context, interface{}) (interface{}, error) {
+			time.Sleep(200 * time.Millisecond)
+			return nil, nil
+		}, nil).Timeout(50*time.Millisecond).Finally(nil, true); errors.Is(err, context.DeadlineExceeded) == false {
+			t.Error("Expected deadline exceeded, got", err)
+		}
+	})
+}
+
+func Test_Promise_006(t *testing.T) {
+	tool.Test(t, nil, new(PromiseApp), func(app *PromiseApp) {
+		// Retry until success on the third attempt
+		attempts := 0
+		fn := func(context.Context, interface{}) (interface{}, error) {
+			if attempts++; attempts < 3 {
+				return nil, gopi.ErrOutOfOrder
+			}
+			return attempts, nil
+		}
+		if err := app.Do(nil, fn, nil).Retry(gopi.PromiseRetry{Delay: 10 * time.Millisecond}).Finally(nil, true); err != nil {
+			t.Error(err)
+		} else if attempts != 3 {
+			t.Error("Unexpected attempts", attempts)
+		}
+
+		// Give up after the maximum attempts
+		attempts = 0
+		if err := app.Do(nil, fn, nil).Retry(gopi.PromiseRetry{Attempts: 2}).Finally(nil, true); errors.Is(err, gopi.ErrOutOfOrder) == false {
+			t.Error("Expected ErrOutOfOrder, got", err)
+		} else if attempts != 2 {
+			t.Error("Unexpected attempts", attempts)
+		}
+
+		// Errors which are not retried
+		attempts = 0
+		if err := app.Do(nil, fn, nil).Retry(gopi.PromiseRetry{Retry: func(err error) bool {
+			return errors.Is(err, gopi.ErrOutOfOrder) == false
+		}}).Finally(nil, true); errors.Is(err, gopi.ErrOutOfOrder) == false {
+			t.Error("Expected ErrOutOfOrder, got", err)
+		} else if attempts != 1 {
+			t.Error("Unexpected attempts", attempts)
+		}
+
+		// Each attempt has a timeout
+		var calls int32
+		if err := app.Do(nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) < 2 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return nil, nil
+		}, nil).Timeout(20*time.Millisecond).Retry(gopi.PromiseRetry{Attempts: 3}).Finally(nil, true); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_Promise_007(t *testing.T) {
+	tool.Test(t, nil, new(PromiseApp), func(app *PromiseApp) {
+		// Cancel a promise running in the background
+		done := make(chan error)
+		p := app.Do(nil, app.Value(1, time.Second), nil)
+		p.Finally(func(_ interface{}, err error) error {
+			done <- err
+			return err
+		}, false)
+		time.Sleep(10 * time.Millisecond)
+		p.Cancel()
+		select {
+		case err := <-done:
+			if errors.Is(err, context.Canceled) == false {
+				t.Error("Expected cancelled, got", err)
+			}
+		case <-time.After(500 * time.Millisecond):
+			t.Error("Timeout waiting for cancel")
+		}
+
+		// Cancelling the parent context cancels promises in All
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if err := app.All(ctx,
+			app.Do(nil, app.Value(1, time.Second), nil),
+			app.Do(nil, app.Value(2, time.Second), nil),
+		).Finally(nil, true); errors.Is(err, context.DeadlineExceeded) == false {
+			t.Error("Expected deadline exceeded, got", err)
+		} else if since := time.Since(start); since > 500*time.Millisecond {
+			t.Error("Expected promises to be cancelled", since)
+		}
+
+		// Cancelling the context of a promise fails All
+		child, cancelChild := context.WithCancel(context.Background())
+		cancelChild()
+		if err := app.All(context.Background(),
+			app.Do(child, app.Value(1, time.Second), nil),
+		).Finally(nil, true); errors.Is(err, context.Canceled) == false {
+			t.Error("Expected cancelled, got", err)
+		}
+	})
+}
+
+func Test_Promise_008(t *testing.T) {
+	tool.Test(t, nil, new(PromiseApp), func(app *PromiseApp) {
+		// Unlimited attempts with no delay do not retry without pause
+		var attempts int32
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := app.Do(ctx, func(context.Context, interface{}) (interface{}, error) {
+			atomic.AddInt32(&attempts, 1)
+			return nil, gopi.ErrUnexpectedResponse
+		}, nil).Retry(gopi.PromiseRetry{}).Finally(nil, true); errors.Is(err, context.DeadlineExceeded) == false {
+			t.Error("Expected deadline exceeded, got", err)
+		} else if n := atomic.LoadInt32(&attempts); n > 20 {
+			t.Error("Unexpected attempts", n)
+		}
+
+		// A promise which has been started cannot be run again
+		p := app.Do(nil, app.Value(1, 50*time.Millisecond), nil)
+		if err := p.Finally(nil, false); err != nil {
+			t.Error(err)
+		}
+		if err := p.Finally(nil, true); errors.Is(err, gopi.ErrOutOfOrder) == false {
+			t.Error("Expected out of order, got", err)
+		}
+		if err := app.All(nil, p).Finally(nil, true); errors.Is(err, gopi.ErrOutOfOrder) == false {
+			t.Error("Expected out of order, got", err)
+		}
+	})
+}
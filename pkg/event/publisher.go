@@ -3,7 +3,11 @@ package event
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/djthorpe/gopi/v3"
 )
@@ -12,17 +16,71 @@ type publisher struct {
 	gopi.Unit
 	sync.RWMutex
 
-	q  chan gopi.Event
-	ch []chan gopi.Event
+	// Flags
+	size     *uint
+	overflow *string
+	interval *time.Duration
+
+	q      chan gopi.Event
+	ch     []*subscriber
+	id     uint
+	policy overflow
+}
+
+// subscriber has a queue of events, and an optional filter on
+// the events which are queued. The mutex guards sending on and
+// closing the queue, and done is closed to release a blocked send
+// when the subscriber is removed
+type subscriber struct {
+	sync.Mutex
+	id     uint
+	ch     chan gopi.Event
+	done   chan struct{}
+	closed bool
+	filter func(gopi.Event) bool
+	desc   string
+	drops  uint64
+	sent   uint64
 }
 
+// overflow determines what happens when a subscriber queue is full
+type overflow uint
+
 const (
 	// queuesize defines the buffer of events, in case the receiver is not
 	// quick at picking up events compared to sender
 	queuesize = 100
 )
 
+const (
+	overflowBlock      overflow = iota // Wait for the subscriber
+	overflowDropOldest                 // Remove the oldest event from the queue
+	overflowDropNewest                 // Discard the event being sent
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func (this *publisher) Define(cfg gopi.Config) error {
+	this.size = cfg.FlagUint("publisher.queue", queuesize, "Size of event queue for each subscriber")
+	this.overflow = cfg.FlagString("publisher.overflow", fmt.Sprint(overflowBlock), "Action when a subscriber queue is full (block, drop-oldest, drop-newest)")
+	this.interval = cfg.FlagDuration("publisher.metrics", 0, "Interval for emitting subscriber queue measurements, or zero to disable")
+	return nil
+}
+
 func (this *publisher) New(gopi.Config) error {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+
+	// Set overflow policy
+	if this.overflow != nil {
+		if policy, err := parseOverflow(*this.overflow); err != nil {
+			return err
+		} else {
+			this.policy = policy
+		}
+	}
+
 	this.q = make(chan gopi.Event, queuesize)
 	return nil
 }
@@ -35,10 +93,8 @@ func (this *publisher) Dispose() error {
 	close(this.q)
 
 	// Unsubscribe channels
-	for _, ch := range this.ch {
-		if ch != nil {
-			close(ch)
-		}
+	for _, sub := range this.ch {
+		sub.close()
 	}
 
 	// Dispose
@@ -50,39 +106,87 @@ func (this *publisher) Dispose() error {
 }
 
 func (this *publisher) Run(ctx context.Context) error {
+	// Emit measurements on an interval if set
+	var tick <-chan time.Time
+	if this.interval != nil && *this.interval > 0 {
+		ticker := time.NewTicker(*this.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
 	for {
 		select {
 		case evt := <-this.q:
+			// Send without holding the lock, so a blocked send does
+			// not prevent subscribers being added or removed
 			this.RWMutex.RLock()
-			for _, ch := range this.ch {
-				if ch != nil {
-					ch <- evt
+			subs := append([]*subscriber(nil), this.ch...)
+			this.RWMutex.RUnlock()
+			for _, sub := range subs {
+				if sub.filter == nil || sub.filter(evt) {
+					sub.send(ctx, evt, this.policy)
 				}
 			}
-			this.RWMutex.RUnlock()
+		case <-tick:
+			for _, m := range this.Measurements() {
+				this.Emit(m, false)
+			}
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 	}
 }
 
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
 func (this *publisher) Subscribe() <-chan gopi.Event {
-	this.RWMutex.Lock()
-	defer this.RWMutex.Unlock()
+	return this.subscribe(nil, "")
+}
+
+func (this *publisher) SubscribeName(names ...string) <-chan gopi.Event {
+	match := make(map[string]bool, len(names))
+	for _, name := range names {
+		match[name] = true
+	}
+	return this.subscribe(func(evt gopi.Event) bool {
+		return match[evt.Name()]
+	}, "name="+strings.Join(names, ","))
+}
 
-	ch := make(chan gopi.Event)
-	this.ch = append(this.ch, ch)
-	return ch
+func (this *publisher) SubscribeType(values ...interface{}) <-chan gopi.Event {
+	types := make([]reflect.Type, 0, len(values))
+	desc := make([]string, 0, len(values))
+	for _, v := range values {
+		if t := reflect.TypeOf(v); t == nil {
+			continue
+		} else if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+			types = append(types, t.Elem())
+		} else {
+			types = append(types, t)
+		}
+		desc = append(desc, fmt.Sprint(types[len(types)-1]))
+	}
+	return this.subscribe(func(evt gopi.Event) bool {
+		t := reflect.TypeOf(evt)
+		for _, other := range types {
+			if t == other || (other.Kind() == reflect.Interface && t.Implements(other)) {
+				return true
+			}
+		}
+		return false
+	}, "type="+strings.Join(desc, ","))
 }
 
 func (this *publisher) Unsubscribe(ch <-chan gopi.Event) {
 	this.RWMutex.Lock()
 	defer this.RWMutex.Unlock()
 
-	for i, other := range this.ch {
-		if other == ch {
-			close(other)
-			this.ch[i] = nil
+	for i, sub := range this.ch {
+		if sub.ch == ch {
+			sub.close()
+			this.ch = append(this.ch[:i], this.ch[i+1:]...)
+			break
 		}
 	}
 }
@@ -108,6 +212,117 @@ func (this *publisher) Emit(evt gopi.Event, block bool) error {
 	}
 }
 
+// Measurements returns the queue depth, queue size and number of events
+// sent and dropped for each subscriber
+func (this *publisher) Measurements() []gopi.Measurement {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	result := make([]gopi.Measurement, 0, len(this.ch))
+	ts := time.Now()
+	for _, sub := range this.ch {
+		result = append(result, sub.measurement(ts))
+	}
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *publisher) subscribe(filter func(gopi.Event) bool, desc string) <-chan gopi.Event {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+
+	size := uint(queuesize)
+	if this.size != nil {
+		size = *this.size
+	}
+	this.id++
+	sub := &subscriber{id: this.id, ch: make(chan gopi.Event, size), done: make(chan struct{}), filter: filter, desc: desc}
+	this.ch = append(this.ch, sub)
+	return sub.ch
+}
+
+// send queues an event for a subscriber. When the queue is full, the
+// oldest or newest event is dropped, or the send blocks until there is
+// space, the subscriber is closed or the context is cancelled
+func (this *subscriber) send(ctx context.Context, evt gopi.Event, policy overflow) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
+	// Ignore events once the subscriber is closed
+	if this.closed {
+		return
+	}
+
+	switch policy {
+	case overflowDropNewest:
+		select {
+		case this.ch <- evt:
+			atomic.AddUint64(&this.sent, 1)
+		default:
+			atomic.AddUint64(&this.drops, 1)
+		}
+	case overflowDropOldest:
+		for {
+			select {
+			case this.ch <- evt:
+				atomic.AddUint64(&this.sent, 1)
+				return
+			default:
+				// Remove oldest event and try again
+				select {
+				case <-this.ch:
+					atomic.AddUint64(&this.drops, 1)
+				default:
+				}
+			}
+		}
+	default:
+		select {
+		case this.ch <- evt:
+			atomic.AddUint64(&this.sent, 1)
+		case <-this.done:
+			atomic.AddUint64(&this.drops, 1)
+		case <-ctx.Done():
+			atomic.AddUint64(&this.drops, 1)
+		}
+	}
+}
+
+// close releases any blocked send and then closes the queue
+func (this *subscriber) close() {
+	close(this.done)
+
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	this.closed = true
+	close(this.ch)
+}
+
+// measurement returns the queue depth, queue size and counters
+func (this *subscriber) measurement(ts time.Time) gopi.Measurement {
+	return &stats{ts, []gopi.Field{
+		&stat{"subscriber", fmt.Sprint(this.id)},
+		&stat{"filter", this.desc},
+	}, []gopi.Field{
+		&stat{"depth", uint32(len(this.ch))},
+		&stat{"size", uint32(cap(this.ch))},
+		&stat{"sent", atomic.LoadUint64(&this.sent)},
+		&stat{"drops", atomic.LoadUint64(&this.drops)},
+	}}
+}
+
+func parseOverflow(value string) (overflow, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, policy := range []overflow{overflowBlock, overflowDropOldest, overflowDropNewest} {
+		if policy.String() == value {
+			return policy, nil
+		}
+	}
+	return overflowBlock, gopi.ErrBadParameter.WithPrefix("-publisher.overflow: ", value)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
@@ -116,7 +331,35 @@ func (this *publisher) String() string {
 	if this == nil {
 		str += " nil"
 	} else {
-		str += " ch=" + fmt.Sprint(this.ch)
+		str += " overflow=" + fmt.Sprint(this.policy)
+		for _, sub := range this.ch {
+			str += " " + fmt.Sprint(sub)
+		}
+	}
+	return str + ">"
+}
+
+func (this *subscriber) String() string {
+	str := "<subscriber"
+	str += " id=" + fmt.Sprint(this.id)
+	if this.desc != "" {
+		str += " filter=" + this.desc
 	}
+	str += fmt.Sprintf(" depth=%v/%v", len(this.ch), cap(this.ch))
+	str += " sent=" + fmt.Sprint(atomic.LoadUint64(&this.sent))
+	str += " drops=" + fmt.Sprint(atomic.LoadUint64(&this.drops))
 	return str + ">"
 }
+
+func (v overflow) String() string {
+	switch v {
+	case overflowDropOldest:
+		return "drop-oldest"
+	case overflowDropNewest:
+		return "drop-newest"
+	case overflowBlock:
+		return "block"
+	default:
+		return "[?? Invalid overflow value]"
+	}
+}
@@ -1,6 +1,8 @@
 package event
 
 import (
+	"context"
+	"errors"
 	"math/rand"
 	"sync"
 	"testing"
@@ -15,6 +17,11 @@ type App struct {
 	gopi.Publisher
 }
 
+func (this *App) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
 func Test_Event_000(t *testing.T) {
 	pub := &publisher{}
 	if ch := pub.Subscribe(); ch == nil {
@@ -40,9 +47,9 @@ func Test_Event_001(t *testing.T) {
 		defer app.Publisher.Unsubscribe(ch)
 
 		// Emit all null events
+		wg.Add(msgs)
 		wg2.Add(1)
 		go func(n int) {
-			wg.Add(n)
 			for i := 0; i < n; i++ {
 				t.Log("Emitting events", i+1, "of", n)
 				if err := app.Publisher.Emit(nil, true); err != nil {
@@ -55,10 +62,9 @@ func Test_Event_001(t *testing.T) {
 		// Receive null events
 		wg2.Add(1)
 		go func(n int) {
-			i := 1
-			for _ = range ch {
-				t.Log("Receiving event", i, "of", n)
-				i++
+			for i := 0; i < n; i++ {
+				<-ch
+				t.Log("Receiving event", i+1, "of", n)
 				wg.Done()
 			}
 			wg2.Done()
@@ -67,7 +73,7 @@ func Test_Event_001(t *testing.T) {
 		t.Log("Waiting for all messages received")
 		wg.Wait()
 		t.Log("Waiting for goroutines to end")
-		//wg2.Wait()
+		wg2.Wait()
 
 	})
 }
@@ -107,3 +113,133 @@ func Test_Event_002(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+type testEvent struct {
+	name string
+}
+
+func (this *testEvent) Name() string { return this.name }
+
+func Test_Event_003(t *testing.T) {
+	pub := &publisher{}
+	if err := pub.New(nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pub.Run(ctx)
+
+	all := pub.Subscribe()
+	named := pub.SubscribeName("a")
+	typed := pub.SubscribeType((*testEvent)(nil))
+	iface := pub.SubscribeType((*gopi.Measurement)(nil))
+	defer pub.Unsubscribe(all)
+	defer pub.Unsubscribe(named)
+	defer pub.Unsubscribe(typed)
+	defer pub.Unsubscribe(iface)
+
+	for _, evt := range []gopi.Event{&testEvent{"a"}, &testEvent{"b"}, nil} {
+		if err := pub.Emit(evt, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := map[<-chan gopi.Event][]string{
+		all:   {"a", "b", "null"},
+		named: {"a"},
+		typed: {"a", "b"},
+	}
+	for ch, names := range expect {
+		for _, name := range names {
+			select {
+			case evt := <-ch:
+				if evt.Name() != name {
+					t.Error("Unexpected event", evt, "expected", name)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for", name)
+			}
+		}
+	}
+
+	// The measurement subscriber receives the measurements
+	for _, m := range pub.Measurements() {
+		pub.Emit(m, true)
+	}
+	select {
+	case evt := <-iface:
+		if evt.Name() != "publisher" {
+			t.Error("Unexpected event", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for measurement")
+	}
+}
+
+func Test_Event_004(t *testing.T) {
+	for policy, sent := range map[overflow]uint64{overflowDropOldest: 4, overflowDropNewest: 2} {
+		sub := &subscriber{ch: make(chan gopi.Event, 2)}
+		for _, name := range []string{"a", "b", "c", "d"} {
+			sub.send(context.Background(), &testEvent{name}, policy)
+		}
+		m := sub.measurement(time.Now())
+		if m.Get("drops") != uint64(2) || m.Get("sent") != sent || m.Get("depth") != uint32(2) {
+			t.Error("Unexpected measurement", m)
+		}
+		first, second := <-sub.ch, <-sub.ch
+		if policy == overflowDropOldest && (first.Name() != "c" || second.Name() != "d") {
+			t.Error(policy, "Unexpected events", first, second)
+		} else if policy == overflowDropNewest && (first.Name() != "a" || second.Name() != "b") {
+			t.Error(policy, "Unexpected events", first, second)
+		}
+	}
+	if _, err := parseOverflow("drop"); errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	}
+}
+
+func Test_Event_005(t *testing.T) {
+	size := uint(1)
+	pub := &publisher{size: &size, policy: overflowBlock}
+	if err := pub.New(nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pub.Run(ctx)
+
+	// Fill the queue so that the publisher blocks sending to the subscriber
+	ch := pub.Subscribe()
+	for _, name := range []string{"a", "b", "c"} {
+		if err := pub.Emit(&testEvent{name}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Unsubscribe should not wait for the blocked send
+	done := make(chan struct{})
+	go func() {
+		pub.Unsubscribe(ch)
+		close(done)
+	}()
+	select {
+	case <-done:
+		break
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for unsubscribe")
+	}
+
+	// Other subscribers continue to receive events
+	other := pub.SubscribeName("d")
+	defer pub.Unsubscribe(other)
+	if err := pub.Emit(&testEvent{"d"}, true); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case evt := <-other:
+		if evt.Name() != "d" {
+			t.Error("Unexpected event", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for event")
+	}
+}
@@ -0,0 +1,195 @@
+package record
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/event/codec"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type recorder struct {
+	gopi.Unit
+	gopi.Publisher
+	gopi.Logger
+
+	// Flags
+	record *string
+	replay *string
+	speed  *float64
+}
+
+// line is a recorded event, with the time it was emitted and the
+// name of the codec used to serialise it
+type line struct {
+	Time  time.Time       `json:"ts"`
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Maximum size of a recorded line
+	maxLineSize = 1 << 20
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func (this *recorder) Define(cfg gopi.Config) error {
+	this.record = cfg.FlagString("event.record", "", "Path to file for recording events")
+	this.replay = cfg.FlagString("event.replay", "", "Path to file of recorded events to replay")
+	this.speed = cfg.FlagFloat("event.speed", 1.0, "Replay speed, or zero to replay without delay")
+	return nil
+}
+
+func (this *recorder) New(gopi.Config) error {
+	if *this.speed < 0 {
+		return gopi.ErrBadParameter.WithPrefix("-event.speed")
+	}
+	return nil
+}
+
+func (this *recorder) Run(ctx context.Context) error {
+	errs := make(chan error, 2)
+	n := 0
+
+	// Record events to a file
+	if *this.record != "" {
+		fh, err := os.Create(*this.record)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		n++
+		go func() {
+			errs <- this.Record(ctx, fh)
+		}()
+	}
+
+	// Replay events from a file
+	if *this.replay != "" {
+		fh, err := os.Open(*this.replay)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		n++
+		go func() {
+			errs <- this.Replay(ctx, fh, *this.speed)
+		}()
+	}
+
+	// Wait for recording and replay to end
+	var result error
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil && errors.Is(err, context.Canceled) == false {
+			result = err
+		}
+	}
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Record writes each event as a line of JSON until the context is
+// cancelled. Events which cannot be serialised are skipped
+func (this *recorder) Record(ctx context.Context, w io.Writer) error {
+	ch := this.Publisher.Subscribe()
+	defer this.Publisher.Unsubscribe(ch)
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case evt, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if name, data, err := codec.Encode(evt); errors.Is(err, gopi.ErrNotImplemented) {
+				this.Debug("Record: skipping ", evt)
+			} else if err != nil {
+				this.Print("Record: ", err)
+			} else if err := enc.Encode(&line{time.Now(), name, data}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Replay reads recorded events and emits them. The interval between
+// events is divided by the speed, so that a speed of 2 replays at twice
+// the recorded rate, and a speed of zero emits events without delay
+func (this *recorder) Replay(ctx context.Context, r io.Reader, speed float64) error {
+	if speed < 0 {
+		return gopi.ErrBadParameter.WithPrefix("Replay: ", speed)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	var prev time.Time
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		// Decode the line and event
+		var v line
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			return gopi.ErrUnexpectedResponse.WithPrefix("Replay: line ", n, ": ", err)
+		}
+		evt, err := codec.Decode(v.Type, v.Event)
+		if err != nil {
+			return gopi.ErrUnexpectedResponse.WithPrefix("Replay: line ", n, ": ", err)
+		}
+
+		// Wait for the interval since the previous event
+		if speed > 0 && prev.IsZero() == false && v.Time.After(prev) {
+			timer := time.NewTimer(time.Duration(float64(v.Time.Sub(prev)) / speed))
+			select {
+			case <-timer.C:
+				break
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		prev = v.Time
+
+		// Emit the event
+		if err := this.Publisher.Emit(evt, true); err != nil {
+			return err
+		}
+	}
+
+	// Return any read error
+	return scanner.Err()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *recorder) String() string {
+	str := "<recorder"
+	if this.record != nil && *this.record != "" {
+		str += fmt.Sprintf(" record=%q", *this.record)
+	}
+	if this.replay != nil && *this.replay != "" {
+		str += fmt.Sprintf(" replay=%q speed=%v", *this.replay, *this.speed)
+	}
+	return str + ">"
+}
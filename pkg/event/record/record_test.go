@@ -0,0 +1,120 @@
+package record_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/hw/gpio"
+	"github.com/djthorpe/gopi/v3/pkg/tool"
+
+	_ "github.com/djthorpe/gopi/v3/pkg/event/record"
+)
+
+type App struct {
+	gopi.Unit
+	gopi.Publisher
+	gopi.EventRecorder
+}
+
+func (app *App) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func Test_Record_001(t *testing.T) {
+	tool.Test(t, nil, new(App), func(app *App) {
+		if app.EventRecorder == nil {
+			t.Error("nil EventRecorder unit")
+		} else {
+			t.Log(app.EventRecorder)
+		}
+	})
+}
+
+func Test_Record_002(t *testing.T) {
+	tool.Test(t, nil, new(App), func(app *App) {
+		var buf bytes.Buffer
+		var wg sync.WaitGroup
+
+		// Record events
+		ctx, cancel := context.WithCancel(context.Background())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := app.EventRecorder.Record(ctx, &buf); err != nil {
+				t.Error(err)
+			}
+		}()
+		time.Sleep(100 * time.Millisecond)
+		for pin := gopi.GPIOPin(1); pin <= 3; pin++ {
+			app.Publisher.Emit(gpio.NewEvent("gpio", pin, gopi.GPIO_EDGE_RISING), true)
+		}
+		// Events without a codec are skipped
+		app.Publisher.Emit(nil, true)
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+		wg.Wait()
+
+		if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 {
+			t.Error("Unexpected number of lines", lines)
+		} else {
+			t.Log(lines)
+		}
+
+		// Replay events without delay
+		ch := app.Publisher.SubscribeType((*gopi.GPIOEvent)(nil))
+		defer app.Publisher.Unsubscribe(ch)
+		if err := app.EventRecorder.Replay(context.Background(), &buf, 0); err != nil {
+			t.Error(err)
+		}
+		for pin := gopi.GPIOPin(1); pin <= 3; pin++ {
+			select {
+			case evt := <-ch:
+				if evt.(gopi.GPIOEvent).Pin() != pin {
+					t.Error("Unexpected event", evt)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for pin", pin)
+			}
+		}
+	})
+}
+
+func Test_Record_003(t *testing.T) {
+	tool.Test(t, nil, new(App), func(app *App) {
+		// Events recorded 500ms apart
+		data := `{"ts":"2026-01-01T00:00:00Z","type":"gpio","event":{"name":"gpio","pin":1,"edge":1}}
+{"ts":"2026-01-01T00:00:00.5Z","type":"gpio","event":{"name":"gpio","pin":2,"edge":1}}
+{"ts":"2026-01-01T00:00:01Z","type":"gpio","event":{"name":"gpio","pin":3,"edge":1}}
+`
+		for _, speed := range []float64{1, 10} {
+			start := time.Now()
+			if err := app.EventRecorder.Replay(context.Background(), strings.NewReader(data), speed); err != nil {
+				t.Error(err)
+			}
+			expected := time.Duration(float64(time.Second) / speed)
+			if d := time.Since(start); d < expected || d > expected+250*time.Millisecond {
+				t.Error("Unexpected replay duration", d, "at speed", speed)
+			} else {
+				t.Log("Replayed in", d, "at speed", speed)
+			}
+		}
+
+		// Replay is cancelled with the context
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := app.EventRecorder.Replay(ctx, strings.NewReader(data), 1); err == nil {
+			t.Error("Expected error when cancelled")
+		}
+
+		// Bad data returns an error
+		if err := app.EventRecorder.Replay(context.Background(), strings.NewReader("{}\n"), 0); err == nil {
+			t.Error("Expected error for bad data")
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package scheduler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// cron is a parsed cron expression, with a bit set for each
+// value of each field
+type cron struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64
+}
+
+type field struct {
+	min, max uint
+	names    []string
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	fieldMinute = field{0, 59, nil}
+	fieldHour   = field{0, 23, nil}
+	fieldDom    = field{1, 31, nil}
+	fieldMonth  = field{1, 12, []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	fieldDow    = field{0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+const (
+	// Maximum number of years to search for the next time
+	maxYears = 5
+)
+
+/////////////////////////////////////////////////////////////////////
+// NEW
+
+// parseCron parses five fields separated by whitespace, where each field
+// is *, a value, a range a-b or a list, with an optional step /n
+func parseCron(spec string) (*cron, error) {
+	expr := strings.ToLower(strings.TrimSpace(spec))
+	if descriptor, exists := descriptors[expr]; exists {
+		expr = descriptor
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, gopi.ErrBadParameter.WithPrefix("Cron: ", strconv.Quote(spec))
+	}
+
+	this := &cron{spec: spec}
+	for i, dest := range []struct {
+		bits *uint64
+		field
+	}{
+		{&this.minute, fieldMinute},
+		{&this.hour, fieldHour},
+		{&this.dom, fieldDom},
+		{&this.month, fieldMonth},
+		{&this.dow, fieldDow},
+	} {
+		if bits, err := dest.parse(fields[i]); err != nil {
+			return nil, gopi.ErrBadParameter.WithPrefix("Cron: ", strconv.Quote(spec), ": ", err)
+		} else {
+			*dest.bits = bits
+		}
+	}
+
+	// Day of week 7 is Sunday
+	if this.dow&(1<<7) != 0 {
+		this.dow = this.dow&^(1<<7) | 1
+	}
+
+	// Return success
+	return this, nil
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Next returns the first time after t which matches the expression, or
+// zero time if there is no match within five years
+func (this *cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(maxYears, 0, 0)
+	for t.Before(end) {
+		if this.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		} else if this.matchDay(t) == false {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		} else if this.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		} else if this.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+		} else {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// matchDay returns true if the day matches. When both day of month and
+// day of week are restricted, either can match
+func (this *cron) matchDay(t time.Time) bool {
+	dom := this.dom&(1<<uint(t.Day())) != 0
+	dow := this.dow&(1<<uint(t.Weekday())) != 0
+	if this.dom == fieldDom.all() || this.dow == fieldDow.all()&^(1<<7) {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parse returns the bits set by a field
+func (f field) parse(value string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		step := uint64(1)
+		if i := strings.Index(part, "/"); i >= 0 {
+			if n, err := strconv.ParseUint(part[i+1:], 10, 8); err != nil || n == 0 {
+				return 0, gopi.ErrBadParameter.WithPrefix(part)
+			} else {
+				step, part = n, part[:i]
+			}
+		}
+		min, max := f.min, f.max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			if v, err := f.value(bounds[0]); err != nil {
+				return 0, err
+			} else {
+				min, max = v, v
+			}
+			if len(bounds) == 2 {
+				if v, err := f.value(bounds[1]); err != nil {
+					return 0, err
+				} else {
+					max = v
+				}
+			} else if step > 1 {
+				max = f.max
+			}
+			if min > max {
+				return 0, gopi.ErrBadParameter.WithPrefix(part)
+			}
+		}
+		for v := min; v <= max; v += uint(step) {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value returns a number or name in the range of the field
+func (f field) value(value string) (uint, error) {
+	for i, name := range f.names {
+		if name != "" && name == value {
+			return uint(i), nil
+		}
+	}
+	if v, err := strconv.ParseUint(value, 10, 8); err != nil || uint(v) < f.min || uint(v) > f.max {
+		return 0, gopi.ErrBadParameter.WithPrefix(value)
+	} else {
+		return uint(v), nil
+	}
+}
+
+// all returns the bits for every value of a field
+func (f field) all() uint64 {
+	var bits uint64
+	for v := f.min; v <= f.max; v++ {
+		bits |= 1 << v
+	}
+	return bits
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *cron) String() string {
+	return "<cron " + strconv.Quote(this.spec) + ">"
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Cron_001(t *testing.T) {
+	for _, spec := range []string{
+		"* * * * *", "0 0 * * *", "*/15 * * * *", "0 9-17 * * mon-fri",
+		"30 22 1,15 * *", "0 0 1 jan *", "@daily", "@hourly", "0 0 * * 7",
+	} {
+		if c, err := parseCron(spec); err != nil {
+			t.Error(spec, err)
+		} else {
+			t.Log(c)
+		}
+	}
+	for _, spec := range []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "@never",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Error("Expected error for", spec)
+		}
+	}
+}
+
+func Test_Cron_002(t *testing.T) {
+	// Friday 21 June 2024 at 23:00
+	now := time.Date(2024, 6, 21, 23, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 6, 21, 23, 1, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 6, 21, 23, 15, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 6, 22, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 6, 22, 0, 0, 0, 0, time.UTC)},
+		{"30 22 * * mon-fri", time.Date(2024, 6, 24, 22, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 6, 23, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 * *", time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Either day of month or day of week matches
+		{"0 6 1 * mon", time.Date(2024, 6, 24, 6, 0, 0, 0, time.UTC)},
+		// No match
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		if c, err := parseCron(test.spec); err != nil {
+			t.Error(test.spec, err)
+		} else if next := c.Next(now); next.Equal(test.next) == false {
+			t.Error(test.spec, "Unexpected next", next, "expected", test.next)
+		}
+	}
+}
+
+func Test_Cron_003(t *testing.T) {
+	// Daylight saving starts at 01:00 UTC on 31 March 2024 in London
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip(err)
+	}
+	c, _ := parseCron("30 2 * * *")
+	now := time.Date(2024, 3, 30, 12, 0, 0, 0, london)
+	next := c.Next(now)
+	if next.Before(now) || next.Sub(now) > 48*time.Hour {
+		t.Error("Unexpected next", next)
+	} else {
+		t.Log(next)
+	}
+}
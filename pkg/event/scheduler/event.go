@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+type event struct {
+	name   string
+	t      time.Time
+	missed bool
+}
+
+/////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewEvent returns an event for a job run at a scheduled time
+func NewEvent(name string, t time.Time, missed bool) gopi.SchedulerEvent {
+	return &event{name, t, missed}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PROPERTIES
+
+func (this *event) Name() string {
+	return this.name
+}
+
+func (this *event) Time() time.Time {
+	return this.t
+}
+
+func (this *event) Missed() bool {
+	return this.missed
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *event) String() string {
+	str := "<scheduler.event"
+	str += " name=" + strconv.Quote(this.name)
+	str += " ts=" + this.t.Format(time.RFC3339)
+	if this.missed {
+		str += fmt.Sprint(" missed=", this.missed)
+	}
+	return str + ">"
+}
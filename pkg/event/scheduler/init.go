@@ -0,0 +1,13 @@
+package scheduler
+
+import (
+	"reflect"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	_ "github.com/djthorpe/gopi/v3/pkg/event"
+	graph "github.com/djthorpe/gopi/v3/pkg/graph"
+)
+
+func init() {
+	graph.RegisterUnit(reflect.TypeOf(&scheduler{}), reflect.TypeOf((*gopi.Scheduler)(nil)))
+}
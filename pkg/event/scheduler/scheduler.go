@@ -0,0 +1,305 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+type scheduler struct {
+	gopi.Unit
+	gopi.Publisher
+	gopi.Logger
+	sync.Mutex
+
+	// Flags
+	lat, lng *float64
+	path     *string
+
+	jobs  map[string]*job
+	state *state
+	wake  chan struct{}
+}
+
+// schedule returns the first time after a time that a job runs
+type schedule interface {
+	Next(time.Time) time.Time
+}
+
+type job struct {
+	name     string
+	schedule schedule
+	fn       gopi.SchedulerFunc
+	next     time.Time // Time of the next run
+	missed   time.Time // Time of a missed run to catch up, or zero
+	running  bool
+}
+
+// interval schedules a job after a duration with a random jitter
+type interval struct {
+	interval, jitter time.Duration
+}
+
+/////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func (this *scheduler) Define(cfg gopi.Config) error {
+	this.lat = cfg.FlagFloat("scheduler.lat", 0, "Latitude for solar events, in degrees north")
+	this.lng = cfg.FlagFloat("scheduler.lng", 0, "Longitude for solar events, in degrees east")
+	this.path = cfg.FlagString("scheduler.state", "", "Path to file for the last run of jobs, to catch up missed jobs")
+	return nil
+}
+
+func (this *scheduler) New(gopi.Config) error {
+	this.Require(this.Publisher, this.Logger)
+
+	if *this.lat < -90 || *this.lat > 90 {
+		return gopi.ErrBadParameter.WithPrefix("-scheduler.lat")
+	}
+	if *this.lng < -180 || *this.lng > 180 {
+		return gopi.ErrBadParameter.WithPrefix("-scheduler.lng")
+	}
+
+	// Read the last run of jobs
+	if state, err := readState(*this.path); err != nil {
+		return err
+	} else {
+		this.state = state
+	}
+
+	this.jobs = make(map[string]*job)
+	this.wake = make(chan struct{}, 1)
+
+	// Return success
+	return nil
+}
+
+func (this *scheduler) Run(ctx context.Context) error {
+	timer := time.NewTimer(time.Hour)
+	defer func() {
+		timer.Stop()
+	}()
+	for {
+		// Run jobs which are due, and wait for the next job or until
+		// jobs are added
+		timer.Stop()
+		if next := this.runDue(ctx, time.Now()); next.IsZero() == false {
+			timer = time.NewTimer(time.Until(next))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		case <-this.wake:
+		}
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *scheduler) Cron(name, spec string, fn gopi.SchedulerFunc) error {
+	if cron, err := parseCron(spec); err != nil {
+		return err
+	} else {
+		return this.add(name, cron, fn)
+	}
+}
+
+func (this *scheduler) Interval(name string, d, jitter time.Duration, fn gopi.SchedulerFunc) error {
+	if d <= 0 || jitter < 0 {
+		return gopi.ErrBadParameter.WithPrefix("Interval: ", name)
+	}
+	return this.add(name, &interval{d, jitter}, fn)
+}
+
+func (this *scheduler) Solar(name string, event gopi.SolarEvent, offset time.Duration, fn gopi.SchedulerFunc) error {
+	if *this.lat == 0 && *this.lng == 0 {
+		return gopi.ErrBadParameter.WithPrefix("Solar: Set -scheduler.lat and -scheduler.lng")
+	} else if event > gopi.SOLAR_CIVIL_DUSK {
+		return gopi.ErrBadParameter.WithPrefix("Solar: ", event)
+	}
+	return this.add(name, &offsetSchedule{&solar{*this.lat, *this.lng, event}, offset}, fn)
+}
+
+func (this *scheduler) Remove(name string) error {
+	this.Lock()
+	defer this.Unlock()
+	if _, exists := this.jobs[name]; exists == false {
+		return gopi.ErrNotFound.WithPrefix("Remove: ", name)
+	}
+	delete(this.jobs, name)
+	return nil
+}
+
+func (this *scheduler) Next(name string) time.Time {
+	this.Lock()
+	defer this.Unlock()
+	if job, exists := this.jobs[name]; exists == false {
+		return time.Time{}
+	} else if job.missed.IsZero() == false {
+		return job.missed
+	} else {
+		return job.next
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// add a job, replacing any job with the same name. A job which should have
+// run since the last run was recorded is caught up
+func (this *scheduler) add(name string, s schedule, fn gopi.SchedulerFunc) error {
+	if name == "" {
+		return gopi.ErrBadParameter.WithPrefix("Missing job name")
+	}
+
+	now := time.Now()
+	job := &job{name: name, schedule: s, fn: fn, next: s.Next(now)}
+	if last, exists := this.state.Get(name); exists {
+		if missed := s.Next(last); missed.IsZero() == false && missed.Before(now) {
+			job.missed = missed
+		}
+	}
+
+	this.Lock()
+	this.jobs[name] = job
+	this.Unlock()
+
+	// Wake the run loop to reschedule
+	select {
+	case this.wake <- struct{}{}:
+	default:
+	}
+
+	// Return success
+	return nil
+}
+
+// runDue runs jobs which are due, and returns the time of the next job,
+// or zero time if no jobs are scheduled
+func (this *scheduler) runDue(ctx context.Context, now time.Time) time.Time {
+	this.Lock()
+	defer this.Unlock()
+
+	var next time.Time
+	modified := false
+	for _, job := range this.jobs {
+		if job.missed.IsZero() == false {
+			this.run(ctx, job, job.missed, true)
+			job.missed = time.Time{}
+			modified = true
+		}
+		if job.next.IsZero() == false && job.next.After(now) == false {
+			this.run(ctx, job, job.next, false)
+			job.next = job.schedule.Next(now)
+			modified = true
+		}
+		if job.next.IsZero() == false && (next.IsZero() || job.next.Before(next)) {
+			next = job.next
+		}
+	}
+
+	// Write the last run of jobs
+	if modified {
+		if err := this.state.Write(); err != nil {
+			this.Print("Scheduler: ", err)
+		}
+	}
+
+	// Return the time of the next job
+	return next
+}
+
+// run emits an event or calls the job function in the background. A job
+// is skipped if the previous run has not completed
+func (this *scheduler) run(ctx context.Context, job *job, t time.Time, missed bool) {
+	evt := NewEvent(job.name, t, missed)
+	this.state.Set(job.name, t)
+	if job.fn == nil {
+		if err := this.Publisher.Emit(evt, false); err != nil {
+			this.Print("Scheduler: ", job.name, ": ", err)
+		}
+		return
+	} else if job.running {
+		this.Print("Scheduler: ", job.name, ": Skipped, previous run has not completed")
+		return
+	}
+	job.running = true
+	go func() {
+		if err := job.fn(ctx, evt); err != nil {
+			this.Print("Scheduler: ", job.name, ": ", err)
+		}
+		this.Lock()
+		job.running = false
+		this.Unlock()
+	}()
+}
+
+/////////////////////////////////////////////////////////////////////
+// SCHEDULES
+
+func (this *interval) Next(t time.Time) time.Time {
+	next := t.Add(this.interval)
+	if this.jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(this.jitter))))
+	}
+	return next
+}
+
+// offsetSchedule adds an offset to the times of a schedule
+type offsetSchedule struct {
+	schedule
+	offset time.Duration
+}
+
+func (this *offsetSchedule) Next(t time.Time) time.Time {
+	if next := this.schedule.Next(t.Add(-this.offset)); next.IsZero() {
+		return next
+	} else {
+		return next.Add(this.offset)
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *scheduler) String() string {
+	str := "<scheduler"
+	if *this.lat != 0 || *this.lng != 0 {
+		str += fmt.Sprintf(" lat=%.4f lng=%.4f", *this.lat, *this.lng)
+	}
+	if *this.path != "" {
+		str += " state=" + strconv.Quote(*this.path)
+	}
+	this.Lock()
+	for name, job := range this.jobs {
+		str += fmt.Sprintf(" %v=%v", strconv.Quote(name), job.schedule)
+	}
+	this.Unlock()
+	return str + ">"
+}
+
+func (this *interval) String() string {
+	str := "<interval " + fmt.Sprint(this.interval)
+	if this.jitter > 0 {
+		str += " jitter=" + fmt.Sprint(this.jitter)
+	}
+	return str + ">"
+}
+
+func (this *offsetSchedule) String() string {
+	if this.offset == 0 {
+		return fmt.Sprint(this.schedule)
+	} else {
+		return fmt.Sprint(this.schedule, this.offset)
+	}
+}
@@ -0,0 +1,154 @@
+package scheduler_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/tool"
+
+	_ "github.com/djthorpe/gopi/v3/pkg/event/scheduler"
+)
+
+type App struct {
+	gopi.Unit
+	gopi.Publisher
+	gopi.Scheduler
+}
+
+func (app *App) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func Test_Scheduler_001(t *testing.T) {
+	tool.Test(t, nil, new(App), func(app *App) {
+		if app.Scheduler == nil {
+			t.Error("nil Scheduler unit")
+		} else {
+			t.Log(app.Scheduler)
+		}
+	})
+}
+
+func Test_Scheduler_002(t *testing.T) {
+	tool.Test(t, nil, new(App), func(app *App) {
+		// Call a function on an interval
+		var n int32
+		if err := app.Scheduler.Interval("test", 50*time.Millisecond, 10*time.Millisecond, func(_ context.Context, evt gopi.SchedulerEvent) error {
+			if evt.Name() != "test" || evt.Missed() {
+				t.Error("Unexpected event", evt)
+			}
+			atomic.AddInt32(&n, 1)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if next := app.Scheduler.Next("test"); next.IsZero() {
+			t.Error("Expected next time")
+		}
+		time.Sleep(280 * time.Millisecond)
+		if err := app.Scheduler.Remove("test"); err != nil {
+			t.Error(err)
+		}
+		if count := atomic.LoadInt32(&n); count < 3 || count > 5 {
+			t.Error("Unexpected count", count)
+		}
+		if err := app.Scheduler.Remove("test"); err == nil {
+			t.Error("Expected error removing job")
+		}
+
+		// Bad parameters
+		if err := app.Scheduler.Interval("", time.Second, 0, nil); err == nil {
+			t.Error("Expected error for empty name")
+		}
+		if err := app.Scheduler.Cron("test", "* * *", nil); err == nil {
+			t.Error("Expected error for bad expression")
+		}
+		if err := app.Scheduler.Solar("test", gopi.SOLAR_SUNSET, 0, nil); err == nil {
+			t.Error("Expected error without location")
+		}
+	})
+}
+
+func Test_Scheduler_003(t *testing.T) {
+	tool.Test(t, nil, new(App), func(app *App) {
+		// Emit an event when there is no function
+		ch := app.Publisher.SubscribeType((*gopi.SchedulerEvent)(nil))
+		defer app.Publisher.Unsubscribe(ch)
+		if err := app.Scheduler.Interval("tick", 50*time.Millisecond, 0, nil); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case evt := <-ch:
+			if evt.Name() != "tick" {
+				t.Error("Unexpected event", evt)
+			}
+		case <-time.After(time.Second):
+			t.Error("Timeout waiting for event")
+		}
+	})
+}
+
+func Test_Scheduler_004(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Last run of an hourly job was two hours ago
+	path := filepath.Join(dir, "state.json")
+	last := time.Now().Add(-2 * time.Hour).Truncate(time.Hour)
+	if data, err := json.Marshal(map[string]time.Time{"hourly": last}); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"-scheduler.state", path, "-scheduler.lat", "51.5", "-scheduler.lng", "-0.1"}
+	tool.Test(t, args, new(App), func(app *App) {
+		// The missed job is caught up once
+		missed := make(chan gopi.SchedulerEvent, 2)
+		if err := app.Scheduler.Cron("hourly", "@hourly", func(_ context.Context, evt gopi.SchedulerEvent) error {
+			missed <- evt
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case evt := <-missed:
+			if evt.Missed() == false || evt.Time().Equal(last.Add(time.Hour)) == false {
+				t.Error("Unexpected event", evt)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for missed job")
+		}
+
+		// A job at sunset
+		if err := app.Scheduler.Solar("sunset", gopi.SOLAR_SUNSET, -30*time.Minute, nil); err != nil {
+			t.Error(err)
+		} else if next := app.Scheduler.Next("sunset"); next.IsZero() || next.Sub(time.Now()) > 25*time.Hour {
+			t.Error("Unexpected next sunset", next)
+		} else {
+			t.Log("Next sunset", next)
+		}
+		t.Log(app.Scheduler)
+	})
+
+	// The last run is written to the state file
+	var state map[string]time.Time
+	if data, err := ioutil.ReadFile(path); err != nil {
+		t.Error(err)
+	} else if err := json.Unmarshal(data, &state); err != nil {
+		t.Error(err)
+	} else if state["hourly"].After(last) == false {
+		t.Error("Unexpected state", state)
+	}
+}
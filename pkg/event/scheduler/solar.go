@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"math"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// solar calculates the time of a solar event each day for a location,
+// using the sunrise equation from the Almanac for Computers, which is
+// accurate to within a few minutes
+type solar struct {
+	lat, lng float64
+	event    gopi.SolarEvent
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Zenith angle of the sun in degrees, allowing for refraction
+	// and the radius of the sun at sunrise and sunset
+	zenithOfficial = 90.833
+	zenithCivil    = 96.0
+
+	// Number of days to search for the next event, for locations where
+	// the sun does not rise or set for part of the year
+	maxDays = 366
+)
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Next returns the first time after t that the event occurs, or
+// zero time if the event does not occur in the next year
+func (this *solar) Next(t time.Time) time.Time {
+	for day := 0; day <= maxDays; day++ {
+		date := time.Date(t.Year(), t.Month(), t.Day()+day, 12, 0, 0, 0, t.Location())
+		if next, ok := this.On(date); ok && next.After(t) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// On returns the time of the event on the date in the location of the
+// date, and false if the event does not occur on that date
+func (this *solar) On(date time.Time) (time.Time, bool) {
+	var zenith float64
+	var rising bool
+	switch this.event {
+	case gopi.SOLAR_SUNRISE:
+		zenith, rising = zenithOfficial, true
+	case gopi.SOLAR_SUNSET:
+		zenith, rising = zenithOfficial, false
+	case gopi.SOLAR_CIVIL_DAWN:
+		zenith, rising = zenithCivil, true
+	case gopi.SOLAR_CIVIL_DUSK:
+		zenith, rising = zenithCivil, false
+	default:
+		return time.Time{}, false
+	}
+
+	// Approximate time of the event in days
+	lngHour := this.lng / 15
+	t := float64(date.YearDay())
+	if rising {
+		t += (6 - lngHour) / 24
+	} else {
+		t += (18 - lngHour) / 24
+	}
+
+	// Mean anomaly and true longitude of the sun
+	m := 0.9856*t - 3.289
+	l := normalize(m+1.916*sin(m)+0.020*sin(2*m)+282.634, 360)
+
+	// Right ascension in hours, in the same quadrant as the longitude
+	ra := normalize(deg(math.Atan(0.91764*tan(l))), 360)
+	ra += math.Floor(l/90)*90 - math.Floor(ra/90)*90
+	ra /= 15
+
+	// Declination and local hour angle
+	sinDec := 0.39782 * sin(l)
+	cosDec := math.Cos(math.Asin(sinDec))
+	cosH := (cos(zenith) - sinDec*sin(this.lat)) / (cosDec * cos(this.lat))
+	if cosH > 1 || cosH < -1 {
+		return time.Time{}, false
+	}
+	h := deg(math.Acos(cosH))
+	if rising {
+		h = 360 - h
+	}
+	h /= 15
+
+	// Local mean time and universal time in hours
+	ut := normalize(h+ra-0.06571*t-6.622-lngHour, 24)
+	utc := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	result := utc.Add(time.Duration(ut * float64(time.Hour))).In(date.Location())
+
+	// Correct the day when the location is far from its timezone
+	if y, m, d := result.Date(); d != date.Day() {
+		if time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Before(utc) {
+			result = result.Add(24 * time.Hour)
+		} else {
+			result = result.Add(-24 * time.Hour)
+		}
+	}
+	return result.Truncate(time.Second), true
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func sin(d float64) float64 {
+	return math.Sin(d * math.Pi / 180)
+}
+
+func cos(d float64) float64 {
+	return math.Cos(d * math.Pi / 180)
+}
+
+func tan(d float64) float64 {
+	return math.Tan(d * math.Pi / 180)
+}
+
+func deg(r float64) float64 {
+	return r * 180 / math.Pi
+}
+
+func normalize(v, max float64) float64 {
+	v = math.Mod(v, max)
+	if v < 0 {
+		v += max
+	}
+	return v
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *solar) String() string {
+	return "<solar " + this.event.String() + ">"
+}
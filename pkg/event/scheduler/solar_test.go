@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+func Test_Solar_001(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip(err)
+	}
+	newyork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		lat, lng float64
+		event    gopi.SolarEvent
+		expected time.Time
+	}{
+		// London on the summer solstice
+		{51.5074, -0.1278, gopi.SOLAR_SUNRISE, time.Date(2024, 6, 21, 4, 43, 0, 0, london)},
+		{51.5074, -0.1278, gopi.SOLAR_SUNSET, time.Date(2024, 6, 21, 21, 21, 0, 0, london)},
+		{51.5074, -0.1278, gopi.SOLAR_CIVIL_DAWN, time.Date(2024, 6, 21, 3, 56, 0, 0, london)},
+		{51.5074, -0.1278, gopi.SOLAR_CIVIL_DUSK, time.Date(2024, 6, 21, 22, 5, 0, 0, london)},
+		// New York on the winter solstice
+		{40.7128, -74.0060, gopi.SOLAR_SUNRISE, time.Date(2024, 12, 21, 7, 16, 0, 0, newyork)},
+		{40.7128, -74.0060, gopi.SOLAR_SUNSET, time.Date(2024, 12, 21, 16, 32, 0, 0, newyork)},
+	}
+	for _, test := range tests {
+		s := &solar{test.lat, test.lng, test.event}
+		date := time.Date(test.expected.Year(), test.expected.Month(), test.expected.Day(), 12, 0, 0, 0, test.expected.Location())
+		if when, ok := s.On(date); ok == false {
+			t.Error(test.event, "Expected event")
+		} else if d := when.Sub(test.expected); d < -5*time.Minute || d > 5*time.Minute {
+			t.Error(test.event, "Unexpected time", when, "expected", test.expected)
+		}
+	}
+}
+
+func Test_Solar_002(t *testing.T) {
+	// The sun does not set in Tromsø in June
+	s := &solar{69.6492, 18.9553, gopi.SOLAR_SUNSET}
+	if _, ok := s.On(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)); ok {
+		t.Error("Expected no sunset")
+	}
+	if next := s.Next(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)); next.IsZero() {
+		t.Error("Expected sunset later in the year")
+	} else if next.Month() != time.July {
+		t.Error("Unexpected next sunset", next)
+	}
+
+	// Next sunset is tomorrow when today's has passed
+	s = &solar{51.5074, -0.1278, gopi.SOLAR_SUNSET}
+	if next := s.Next(time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC)); next.Day() != 22 {
+		t.Error("Unexpected next sunset", next)
+	}
+}
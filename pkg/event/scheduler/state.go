@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// state is the time of the last run of each job, which is
+// read from and written to a file when the path is set
+type state struct {
+	sync.Mutex
+	path string
+	last map[string]time.Time
+}
+
+/////////////////////////////////////////////////////////////////////
+// NEW
+
+func readState(path string) (*state, error) {
+	this := &state{path: path, last: make(map[string]time.Time)}
+	if path == "" {
+		return this, nil
+	}
+	if data, err := ioutil.ReadFile(path); os.IsNotExist(err) {
+		return this, nil
+	} else if err != nil {
+		return nil, err
+	} else if err := json.Unmarshal(data, &this.last); err != nil {
+		return nil, err
+	}
+	return this, nil
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Get returns the last run of a job
+func (this *state) Get(name string) (time.Time, bool) {
+	this.Lock()
+	defer this.Unlock()
+	t, exists := this.last[name]
+	return t, exists
+}
+
+// Set the last run of a job
+func (this *state) Set(name string, t time.Time) {
+	this.Lock()
+	defer this.Unlock()
+	this.last[name] = t
+}
+
+// Write the last run of jobs to the file, replacing it atomically
+func (this *state) Write() error {
+	if this.path == "" {
+		return nil
+	}
+
+	this.Lock()
+	data, err := json.MarshalIndent(this.last, "", "  ")
+	this.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(this.path), filepath.Base(this.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	} else if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), this.path)
+}
@@ -0,0 +1,133 @@
+package event
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// stats is a measurement of a subscriber queue. It is defined here
+// rather than using the metrics package, which depends on this one
+type stats struct {
+	ts      time.Time
+	tags    []gopi.Field
+	metrics []gopi.Field
+}
+
+// stat is a read-only field of a stats measurement
+type stat struct {
+	name  string
+	value interface{}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Name of the measurement for subscriber queues
+	measurementName = "publisher"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *stats) Name() string {
+	return measurementName
+}
+
+func (this *stats) Time() time.Time {
+	return this.ts
+}
+
+func (this *stats) Tags() []gopi.Field {
+	return this.tags
+}
+
+func (this *stats) Metrics() []gopi.Field {
+	return this.metrics
+}
+
+func (this *stats) Get(name string) interface{} {
+	if f := this.field(name); f == nil {
+		return nil
+	} else {
+		return f.Value()
+	}
+}
+
+func (this *stats) Set(name string, value interface{}) error {
+	if f := this.field(name); f == nil {
+		return nil
+	} else {
+		return f.SetValue(value)
+	}
+}
+
+func (this *stat) Name() string {
+	return this.name
+}
+
+func (this *stat) Kind() string {
+	return reflect.TypeOf(this.value).String()
+}
+
+func (this *stat) IsNil() bool {
+	return this.value == nil
+}
+
+func (this *stat) Value() interface{} {
+	return this.value
+}
+
+func (this *stat) SetValue(interface{}) error {
+	return gopi.ErrNotImplemented.WithPrefix("SetValue: ", this.name)
+}
+
+func (this *stat) Copy() gopi.Field {
+	return &stat{this.name, this.value}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *stats) field(name string) gopi.Field {
+	for _, f := range this.tags {
+		if f.Name() == name {
+			return f
+		}
+	}
+	for _, f := range this.metrics {
+		if f.Name() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *stats) String() string {
+	str := "<measurement"
+	str += " name=" + strconv.Quote(measurementName)
+	if this.ts.IsZero() == false {
+		str += " ts=" + this.ts.Format(time.RFC3339)
+	}
+	for _, f := range this.tags {
+		str += " " + fmt.Sprint(f)
+	}
+	for _, f := range this.metrics {
+		str += " " + fmt.Sprint(f)
+	}
+	return str + ">"
+}
+
+func (this *stat) String() string {
+	return fmt.Sprintf("%v=%q", this.name, fmt.Sprint(this.value))
+}
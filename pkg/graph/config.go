@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// prefixConfig prefixes flag names with the instance name of a unit, so that
+// each named instance has its own set of flags
+type prefixConfig struct {
+	gopi.Config
+	prefix string
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	configType = reflect.TypeOf((*gopi.Config)(nil)).Elem()
+)
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// withPrefix replaces a configuration argument with one which prefixes
+// flag names with the instance name, if the name is not empty
+func withPrefix(args []reflect.Value, name string) []reflect.Value {
+	if name == "" {
+		return args
+	}
+	result := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		if arg.IsValid() && arg.Type().Implements(configType) {
+			result[i] = reflect.ValueOf(gopi.Config(&prefixConfig{arg.Interface().(gopi.Config), name + "."}))
+		} else {
+			result[i] = arg
+		}
+	}
+	return result
+}
+
+/////////////////////////////////////////////////////////////////////
+// DEFINE FLAGS
+
+func (this *prefixConfig) FlagString(name, value, usage string, cmds ...string) *string {
+	return this.Config.FlagString(this.prefix+name, value, usage, cmds...)
+}
+
+func (this *prefixConfig) FlagBool(name string, value bool, usage string, cmds ...string) *bool {
+	return this.Config.FlagBool(this.prefix+name, value, usage, cmds...)
+}
+
+func (this *prefixConfig) FlagUint(name string, value uint, usage string, cmds ...string) *uint {
+	return this.Config.FlagUint(this.prefix+name, value, usage, cmds...)
+}
+
+func (this *prefixConfig) FlagInt(name string, value int, usage string, cmds ...string) *int {
+	return this.Config.FlagInt(this.prefix+name, value, usage, cmds...)
+}
+
+func (this *prefixConfig) FlagDuration(name string, value time.Duration, usage string, cmds ...string) *time.Duration {
+	return this.Config.FlagDuration(this.prefix+name, value, usage, cmds...)
+}
+
+func (this *prefixConfig) FlagFloat(name string, value float64, usage string, cmds ...string) *float64 {
+	return this.Config.FlagFloat(this.prefix+name, value, usage, cmds...)
+}
+
+/////////////////////////////////////////////////////////////////////
+// COMMANDS
+
+// Command returns an error, since commands are shared by all instances
+// and cannot be defined by a named unit
+func (this *prefixConfig) Command(name, usage string, fn gopi.CommandFunc) error {
+	return gopi.ErrNotImplemented.WithPrefix("Command: ", strings.TrimSuffix(this.prefix, "."), ": ", name)
+}
+
+// Complete defines shell completion for the value of a flag, which is
+// prefixed with the instance name. Completion for command arguments returns
+// an error, since a named unit cannot define commands
+func (this *prefixConfig) Complete(name string, fn gopi.CompleteFunc) error {
+	if strings.HasPrefix(name, "-") == false {
+		return gopi.ErrNotImplemented.WithPrefix("Complete: ", strings.TrimSuffix(this.prefix, "."), ": ", name)
+	}
+	return this.Config.Complete("-"+this.prefix+strings.TrimPrefix(name, "-"), fn)
+}
+
+/////////////////////////////////////////////////////////////////////
+// GET PROPERTIES
+
+func (this *prefixConfig) GetString(name string) string {
+	return this.Config.GetString(this.prefix + name)
+}
+
+func (this *prefixConfig) GetBool(name string) bool {
+	return this.Config.GetBool(this.prefix + name)
+}
+
+func (this *prefixConfig) GetUint(name string) uint {
+	return this.Config.GetUint(this.prefix + name)
+}
+
+func (this *prefixConfig) GetInt(name string) int {
+	return this.Config.GetInt(this.prefix + name)
+}
+
+func (this *prefixConfig) GetDuration(name string) time.Duration {
+	return this.Config.GetDuration(this.prefix + name)
+}
+
+func (this *prefixConfig) GetFloat(name string) float64 {
+	return this.Config.GetFloat(this.prefix + name)
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *prefixConfig) String() string {
+	return "<config prefix=" + this.prefix + ">"
+}
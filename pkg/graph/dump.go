@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// dump is the resolved graph, with units in lifecycle order
+type dump struct {
+	Objects []string     `json:"objects"`
+	Units   []*dumpUnit  `json:"units"`
+	Fields  []*dumpField `json:"fields"`
+}
+
+type dumpUnit struct {
+	Order      int      `json:"order"`
+	Key        string   `json:"key"`
+	Type       string   `json:"type"`
+	Instance   string   `json:"instance,omitempty"`
+	Object     bool     `json:"object,omitempty"`
+	Interfaces []string `json:"interfaces,omitempty"`
+}
+
+// dumpField is a field of a unit which references another unit
+type dumpField struct {
+	Unit  string `json:"unit"`
+	Field string `json:"field"`
+	Type  string `json:"type"`
+	Ref   string `json:"ref"`
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	DumpDot  = "dot"
+	DumpJSON = "json"
+)
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Dump writes the global graph in dot or json format
+func Dump(w io.Writer, format string) error {
+	return Global.Dump(w, format)
+}
+
+// Dump writes the resolved graph in dot or json format, including the
+// application objects, the units and the interfaces they satisfy, and
+// the fields which reference each unit. Units are in lifecycle order,
+// so that every unit appears after the units it references
+func (this *graph) Dump(w io.Writer, format string) error {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case DumpDot:
+		return this.dump().writeDot(w)
+	case DumpJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(this.dump())
+	default:
+		return gopi.ErrBadParameter.WithPrefix("Dump: ", strconv.Quote(format))
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *graph) dump() *dump {
+	result := &dump{[]string{}, []*dumpUnit{}, []*dumpField{}}
+	units := make(map[unitKey]*dumpUnit, len(this.order))
+	for i, unit := range this.order {
+		key := this.keyForUnit(unit)
+		u := &dumpUnit{i, key.String(), fmt.Sprint(key.t), key.name, this.isAppObject(unit), nil}
+		if u.Object {
+			result.Objects = append(result.Objects, u.Key)
+		}
+		result.Units = append(result.Units, u)
+		units[key] = u
+	}
+
+	// Add fields which reference units, and the interfaces each unit satisfies
+	for _, unit := range this.order {
+		from := this.keyForUnit(unit)
+		forEachField(unit, false, func(f reflect.StructField, i int) error {
+			if key, exists := this.unitKeyForField(f); exists == false {
+				return nil
+			} else if u, exists := units[key]; exists == false {
+				return nil
+			} else {
+				result.Fields = append(result.Fields, &dumpField{from.String(), f.Name, fmt.Sprint(f.Type), u.Key})
+				if f.Type.Kind() == reflect.Interface {
+					u.addInterface(fmt.Sprint(f.Type))
+				}
+			}
+			return nil
+		})
+	}
+
+	return result
+}
+
+func (this *dumpUnit) addInterface(name string) {
+	for _, other := range this.Interfaces {
+		if other == name {
+			return
+		}
+	}
+	this.Interfaces = append(this.Interfaces, name)
+	sort.Strings(this.Interfaces)
+}
+
+func (this *dump) writeDot(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph gopi {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, u := range this.Units {
+		label := fmt.Sprintf("%d: %v", u.Order, u.Key)
+		for _, i := range u.Interfaces {
+			label += "\n" + i
+		}
+		attrs := "label=" + strconv.Quote(label)
+		if u.Object {
+			attrs += ", style=bold"
+		}
+		fmt.Fprintf(&b, "  %v [%v];\n", strconv.Quote(u.Key), attrs)
+	}
+	for _, f := range this.Fields {
+		fmt.Fprintf(&b, "  %v -> %v [label=%v];\n", strconv.Quote(f.Unit), strconv.Quote(f.Ref), strconv.Quote(f.Field))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+type restartEvent struct {
+	name    string
+	err     error
+	attempt uint
+	delay   time.Duration
+	stopped bool
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *restartEvent) Name() string {
+	return this.name
+}
+
+func (this *restartEvent) Err() error {
+	return this.err
+}
+
+func (this *restartEvent) Attempt() uint {
+	return this.attempt
+}
+
+func (this *restartEvent) Delay() time.Duration {
+	return this.delay
+}
+
+func (this *restartEvent) Stopped() bool {
+	return this.stopped
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *restartEvent) String() string {
+	str := "<restart"
+	str += " name=" + strconv.Quote(this.name)
+	if this.err != nil {
+		str += " err=" + strconv.Quote(this.err.Error())
+	}
+	str += " attempt=" + fmt.Sprint(this.attempt)
+	if this.stopped {
+		str += " stopped=true"
+	} else {
+		str += " delay=" + fmt.Sprint(this.delay)
+	}
+	return str + ">"
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	gopi "github.com/djthorpe/gopi/v3"
 	multierror "github.com/hashicorp/go-multierror"
@@ -17,22 +19,55 @@ import (
 
 type graph struct {
 	sync.RWMutex
-	sync.WaitGroup
 
-	units   map[reflect.Type]reflect.Value
-	objs    []reflect.Value
-	Logfn   func(...interface{})
-	errs    chan error
-	cancels []context.CancelFunc
+	units     map[unitKey]reflect.Value
+	objs      []reflect.Value
+	order     []reflect.Value
+	Logfn     func(...interface{})
+	timeout   *time.Duration
+	cancel    context.CancelFunc
+	selected  map[reflect.Type]string
+	policies  map[unitKey]policy
+	overrides map[reflect.Type]reflect.Value
+	health    health
+}
+
+// unitKey identifies a unit instance by type and optional name
+type unitKey struct {
+	t    reflect.Type
+	name string
+}
+
+type run struct {
+	unit   reflect.Value
+	key    unitKey
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
 }
 
+/////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	// DefaultTimeout is the time each unit is given to return from Run
+	// when the graph is being shut down
+	DefaultTimeout = 5 * time.Second
+)
+
 /////////////////////////////////////////////////////////////////////
 // GLOBALS
 
 var (
-	Global = NewGraph(nil)
-	iface  = make(map[reflect.Type]reflect.Type)
-	stubs  = make(map[string]reflect.Type)
+	// Instance names are used as flag prefixes
+	reInstanceName = regexp.MustCompile("^[A-Za-z][A-Za-z0-9_\\-]*$")
+)
+
+var (
+	Global   = NewGraph(nil)
+	iface    = make(map[reflect.Type][]impl)
+	defaults = make(map[reflect.Type]string)
+	stubs    = make(map[string]reflect.Type)
 )
 
 /////////////////////////////////////////////////////////////////////
@@ -41,7 +76,10 @@ var (
 // Construct empty graph
 func NewGraph(fn func(...interface{})) *graph {
 	this := new(graph)
-	this.units = make(map[reflect.Type]reflect.Value)
+	this.units = make(map[unitKey]reflect.Value)
+	this.selected = make(map[reflect.Type]string)
+	this.policies = make(map[unitKey]policy)
+	this.overrides = make(map[reflect.Type]reflect.Value)
 	this.Logfn = fn
 	return this
 }
@@ -49,8 +87,28 @@ func NewGraph(fn func(...interface{})) *graph {
 /////////////////////////////////////////////////////////////////////
 // REGISTRATION FUNCTIONS
 
+// RegisterUnit registers a unit type which implements an interface.
+// The unit is named after the package in which it is defined
 func RegisterUnit(t, i reflect.Type) {
-	if err := registerUnit(t, i); err != nil {
+	if err := registerUnit(unitName(t), t, i); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterNamedUnit registers a unit type which implements an interface
+// with a name, which is used to select amongst several units registered
+// for the same interface
+func RegisterNamedUnit(name string, t, i reflect.Type) {
+	if err := registerUnit(name, t, i); err != nil {
+		panic(err)
+	}
+}
+
+// SetDefaultUnit sets the name of the unit which is used when several
+// are registered for the same interface and none has been selected.
+// Otherwise the first unit registered is used
+func SetDefaultUnit(i reflect.Type, name string) {
+	if err := setDefaultUnit(i, name); err != nil {
 		panic(err)
 	}
 }
@@ -61,8 +119,8 @@ func RegisterServiceStub(s string, t reflect.Type) {
 	}
 }
 
-func registerUnit(t, i reflect.Type) error {
-	if t == nil || i == nil {
+func registerUnit(name string, t, i reflect.Type) error {
+	if t == nil || i == nil || name == "" {
 		return gopi.ErrBadParameter.WithPrefix("RegisterUnit")
 	}
 	for i.Kind() == reflect.Ptr {
@@ -77,10 +135,30 @@ func registerUnit(t, i reflect.Type) error {
 	if t.Implements(i) == false {
 		return fmt.Errorf("%v does not implement interface %v", t, i)
 	}
-	if _, exists := iface[i]; exists {
+	for _, other := range iface[i] {
+		if other.name == name || other.t == t {
+			return gopi.ErrDuplicateEntry.WithPrefix(i, " ", name)
+		}
+	}
+
+	// Append the unit
+	iface[i] = append(iface[i], impl{name, t})
+
+	// Return success
+	return nil
+}
+
+func setDefaultUnit(i reflect.Type, name string) error {
+	if i == nil {
+		return gopi.ErrBadParameter.WithPrefix("SetDefaultUnit")
+	}
+	for i.Kind() == reflect.Ptr {
+		i = i.Elem()
+	}
+	if _, exists := defaults[i]; exists {
 		return gopi.ErrDuplicateEntry.WithPrefix(i)
 	} else {
-		iface[i] = t
+		defaults[i] = name
 	}
 
 	// Return success
@@ -132,18 +210,25 @@ func (this *graph) Create(objs ...interface{}) error {
 			this.objs = append(this.objs, obj_)
 		}
 	}
+
+	// Determine the lifecycle order of the units
+	this.order = this.sort()
+
 	return unwrap(result)
 }
 
 // Call Define for each unit object
 func (this *graph) Define(cfg gopi.Config) error {
+	// Define flags for the graph itself
+	this.define(cfg)
+
 	this.RWMutex.RLock()
 	defer this.RWMutex.RUnlock()
 
 	var result error
-	seen := make(map[reflect.Type]bool, len(this.units))
+	seen := make(map[unitKey]bool, len(this.units))
 	for _, obj := range this.objs {
-		if err := this.do("Define", obj, []reflect.Value{reflect.ValueOf(cfg)}, seen, 0); err != nil {
+		if err := this.do("Define", obj, "", []reflect.Value{reflect.ValueOf(cfg)}, seen, 0); err != nil {
 			result = multierror.Append(result, err)
 		}
 	}
@@ -155,9 +240,14 @@ func (this *graph) New(cfg gopi.Config) error {
 	this.RWMutex.RLock()
 	defer this.RWMutex.RUnlock()
 
-	seen := make(map[reflect.Type]bool, len(this.units))
+	// Check units selected are consistent with flags
+	if err := this.checkUnitFlags(cfg); err != nil {
+		return err
+	}
+
+	seen := make(map[unitKey]bool, len(this.units))
 	for _, obj := range this.objs {
-		if err := this.do("New", obj, []reflect.Value{reflect.ValueOf(cfg)}, seen, 0); err != nil {
+		if err := this.do("New", obj, "", []reflect.Value{reflect.ValueOf(cfg)}, seen, 0); err != nil {
 			return err
 		}
 	}
@@ -166,62 +256,84 @@ func (this *graph) New(cfg gopi.Config) error {
 	return nil
 }
 
-// Call Dispose for each unit object. At the moment, the order of
-// the Dispose is not considered.
+// Call Reload for each unit object in dependency order, after the
+// configuration has been reloaded. Units which do not implement
+// Reload are not affected
+func (this *graph) Reload(cfg gopi.Config) error {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	var result error
+	for _, unit := range this.order {
+		key := this.keyForUnit(unit)
+		if this.Logfn != nil {
+			this.Logfn("Reload", "=>", key)
+		}
+		if err := callFn("Reload", unit, withPrefix([]reflect.Value{reflect.ValueOf(cfg)}, key.name)); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%w (in %v)", err, key))
+		}
+	}
+	return unwrap(result)
+}
+
+// Call Dispose for each unit object in reverse dependency order, so
+// that a unit is disposed only after every unit which references it
 func (this *graph) Dispose() error {
 	this.RWMutex.RLock()
 	defer this.RWMutex.RUnlock()
 
 	var result error
-	seen := make(map[reflect.Type]bool, len(this.units))
-	for _, obj := range this.objs {
-		if err := this.do("Dispose", obj, []reflect.Value{}, seen, 0); err != nil {
-			result = multierror.Append(result, err)
+	for i := len(this.order) - 1; i >= 0; i-- {
+		unit := this.order[i]
+		if this.Logfn != nil {
+			this.Logfn("Dispose", "=>", this.keyForUnit(unit))
+		}
+		if err := callFn("Dispose", unit, []reflect.Value{}); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%w (in %v)", err, this.keyForUnit(unit)))
 		}
 	}
 	return unwrap(result)
 }
 
-// Call Run for each unit object and wait for all to complete
+// Call Run for each unit object and wait for all to complete. Run
+// ends when any application Run function ends or any unit returns an
+// error. The units are then cancelled in reverse dependency order,
+// each waiting up to the shutdown timeout for the Run function to return
 func (this *graph) Run(ctx context.Context, done bool) error {
 	this.RWMutex.RLock()
 	defer this.RWMutex.RUnlock()
 
-	// Set up channel for receiving errors from Run invocations
-	this.errs = make(chan error)
+	// Make new context with cancel
+	ctx, this.cancel = context.WithCancel(ctx)
 
-	// Collect errors
-	var result error
-	go func() {
-		for err := range this.errs {
-			if err != nil && errors.Is(err, context.Canceled) == false {
-				result = multierror.Append(result, err)
-				this.cancelWithError(unwrap(result))
-			}
+	// Call Run functions, dependencies first
+	runs := make([]*run, 0, len(this.order))
+	for _, unit := range this.order {
+		if this.Logfn != nil {
+			this.Logfn("Run", "=>", this.keyForUnit(unit))
 		}
+		runs = append(runs, this.callRun(unit))
+	}
+
+	// Check unit health in the background
+	health := make(chan struct{})
+	go func() {
+		defer close(health)
+		this.runHealth(ctx)
 	}()
 
-	// Make new context with cancel
-	ctx2, cancel := context.WithCancel(ctx)
-	this.cancels = append(this.cancels, cancel)
+	// Wait for ctx.Done and for health checks to end
+	<-ctx.Done()
+	<-health
 
-	// Call Run functions
-	seen := make(map[reflect.Type]bool, len(this.units))
-	for _, obj := range this.objs {
-		if err := this.do("Run", obj, nil, seen, 0); err != nil {
+	// Cancel the Run functions in reverse order and collect errors
+	var result error
+	for i := len(runs) - 1; i >= 0; i-- {
+		if err := runs[i].stop(this.shutdownTimeout(runs[i].key)); err != nil {
 			result = multierror.Append(result, err)
 		}
 	}
 
-	// Wait for ctx.Done, then send cancels
-	<-ctx2.Done()
-	this.cancelWithError(nil)
-
-	// Wait for all Run functions to complete, then finish
-	// collecting errors
-	this.WaitGroup.Wait()
-	close(this.errs)
-
 	// Return the result
 	return unwrap(result)
 }
@@ -232,8 +344,8 @@ func (this *graph) cancelWithError(err error) {
 			logger.Debug("Cancelling with error: ", err)
 		}
 	}
-	for _, cancel := range this.cancels {
-		cancel()
+	if this.cancel != nil {
+		this.cancel()
 	}
 }
 
@@ -273,14 +385,42 @@ func (this *graph) NewServiceStub(s string) gopi.ServiceStub {
 	}
 }
 
+// Units returns the units in the global graph which implement an
+// interface, in dependency order
+func Units(i interface{}) []interface{} {
+	return Global.Units(i)
+}
+
+// Units returns the units which implement an interface, in dependency
+// order, for example Units((*gopi.Server)(nil))
+func (this *graph) Units(i interface{}) []interface{} {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	t := reflect.TypeOf(i)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Interface {
+		return nil
+	}
+	result := []interface{}{}
+	for _, unit := range this.order {
+		if unit.Type().Implements(t) {
+			result = append(result, unit.Interface())
+		}
+	}
+	return result
+}
+
 // GetLogger returns a logger object if used, or nil
 func (this *graph) GetLogger() gopi.Logger {
 	this.RWMutex.RLock()
 	defer this.RWMutex.RUnlock()
 
-	if t, exists := iface[loggerType]; exists == false {
+	if t := this.unitTypeForInterface(loggerType); t == nil {
 		return nil
-	} else if unit, exists := this.units[t]; exists == false {
+	} else if unit, exists := this.units[unitKey{t, ""}]; exists == false {
 		return nil
 	} else if isLoggerType(t) == false {
 		return nil
@@ -292,6 +432,70 @@ func (this *graph) GetLogger() gopi.Logger {
 /////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+func (this *graph) define(cfg gopi.Config) {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+
+	this.timeout = cfg.FlagDuration("graph.timeout", DefaultTimeout, "Default shutdown timeout for each unit")
+	cfg.FlagString("graph.dump", "", "Write the unit graph and exit (dot, json)")
+	this.defineUnitFlags(cfg)
+	this.health.define(cfg)
+}
+
+// shutdownTimeout returns the time to wait for a unit to stop, from
+// the policy for the unit or else from the -graph.timeout flag
+func (this *graph) shutdownTimeout(key unitKey) time.Duration {
+	timeout := DefaultTimeout
+	if this.timeout != nil {
+		timeout = *this.timeout
+	}
+	if p, exists := this.policies[key]; exists {
+		return p.shutdownTimeout(timeout)
+	} else {
+		return timeout
+	}
+}
+
+// sort returns the units and application objects in dependency
+// order, so that every unit appears after the units it references
+func (this *graph) sort() []reflect.Value {
+	order := make([]reflect.Value, 0, len(this.units)+len(this.objs))
+	visited := make(map[reflect.Value]bool, cap(order))
+	var visit func(reflect.Value)
+	visit = func(unit reflect.Value) {
+		// Units of zero size may share a pointer, so the type is
+		// also part of the key
+		key := unit
+		if _, exists := visited[key]; exists {
+			return
+		}
+		// Mark as visited before descending, which breaks any cycles
+		visited[key] = true
+		for _, dep := range this.dependencies(unit) {
+			visit(dep)
+		}
+		order = append(order, unit)
+	}
+	for _, obj := range this.objs {
+		visit(obj)
+	}
+	return order
+}
+
+// dependencies returns the units referenced by the fields of a unit
+func (this *graph) dependencies(unit reflect.Value) []reflect.Value {
+	deps := []reflect.Value{}
+	forEachField(unit, false, func(f reflect.StructField, i int) error {
+		if key, exists := this.unitKeyForField(f); exists {
+			if dep, exists := this.units[key]; exists && isUnitType(key.t) {
+				deps = append(deps, dep)
+			}
+		}
+		return nil
+	})
+	return deps
+}
+
 func (this *graph) graph(unit reflect.Value) error {
 	// Check incoming parameter
 	if isUnitType(unit.Type()) == false {
@@ -301,28 +505,78 @@ func (this *graph) graph(unit reflect.Value) error {
 	// For each field, initialise either by mapping an interface to
 	// a registered unit type or directly
 	return forEachField(unit, false, func(f reflect.StructField, i int) error {
-		t := this.unitTypeForField(f)
-		if t == nil {
+		key, exists := this.unitKeyForField(f)
+		if exists == false {
 			return nil
+		} else if key.name != "" && reInstanceName.MatchString(key.name) == false {
+			return gopi.ErrBadParameter.WithPrefix(f.Name, ": ", key.name)
 		}
 
-		// Create a unit
-		if _, exists := this.units[t]; exists == false {
-			this.units[t] = reflect.New(t.Elem())
-			if err := this.graph(this.units[t]); err != nil {
-				return err
+		// Set the supervision policy for the unit from the struct tag
+		p, exists := this.policies[key]
+		if exists == false {
+			p = defaultPolicy()
+		}
+		if p, err := parsePolicy(p, fieldTag(f)); err != nil {
+			return fmt.Errorf("%w (in %v)", err, f.Name)
+		} else {
+			this.policies[key] = p
+		}
+
+		// Create a unit, or use an override. Overrides which are not
+		// units are not resolved further
+		if _, exists := this.units[key]; exists == false {
+			if v, exists := this.overrideForType(key.t); exists {
+				this.units[key] = v
+			} else {
+				this.units[key] = reflect.New(key.t.Elem())
+			}
+			if isUnitType(key.t) {
+				if err := this.graph(this.units[key]); err != nil {
+					return err
+				}
 			}
 		}
 
 		// Set field to unit
 		field := unit.Elem().Field(i)
-		field.Set(this.units[t])
+		field.Set(this.fieldValue(unit, f, key))
 
 		// Return success
 		return nil
 	})
 }
 
+// fieldValue returns the value for a field of a unit. When the field
+// is a logger interface, units are given a logger named after the unit
+func (this *graph) fieldValue(unit reflect.Value, f reflect.StructField, key unitKey) reflect.Value {
+	v := this.units[key]
+	if f.Type.Kind() != reflect.Interface || v.Type().Implements(namedType) == false {
+		return v
+	} else if name := this.loggerName(unit); name == "" {
+		return v
+	} else if named := reflect.ValueOf(v.Interface().(namedLogger).Named(name)); named.Type().AssignableTo(f.Type) {
+		return named
+	} else {
+		return v
+	}
+}
+
+// loggerName returns the package name of a unit, followed by the instance
+// name if set, or an empty string for application objects
+func (this *graph) loggerName(unit reflect.Value) string {
+	for key, other := range this.units {
+		if other != unit {
+			continue
+		} else if key.name == "" {
+			return unitName(key.t)
+		} else {
+			return unitName(key.t) + "." + key.name
+		}
+	}
+	return ""
+}
+
 func (this *graph) isAppObject(unit reflect.Value) bool {
 	for _, obj := range this.objs {
 		if obj == unit {
@@ -334,9 +588,7 @@ func (this *graph) isAppObject(unit reflect.Value) bool {
 
 func (this *graph) unitTypeForField(f reflect.StructField) reflect.Type {
 	if f.Type.Kind() == reflect.Interface {
-		if _, exists := iface[f.Type]; exists {
-			return iface[f.Type]
-		}
+		return this.unitTypeForInterface(f.Type)
 	} else if isUnitType(f.Type) {
 		return f.Type
 	}
@@ -344,78 +596,169 @@ func (this *graph) unitTypeForField(f reflect.StructField) reflect.Type {
 	return nil
 }
 
-func (this *graph) do(fn string, unit reflect.Value, args []reflect.Value, seen map[reflect.Type]bool, indent int) error {
-	// Check incoming parameter
-	if isUnitType(unit.Type()) == false {
-		return gopi.ErrBadParameter.WithPrefix(unit.Type().String())
+// unitKeyForField returns the unit instance for a field, which is named
+// by a struct tag of the form `gopi:"name=<name>"`
+func (this *graph) unitKeyForField(f reflect.StructField) (unitKey, bool) {
+	if t := this.unitTypeForField(f); t == nil {
+		return unitKey{}, false
+	} else {
+		return unitKey{t, fieldTag(f)["name"]}, true
 	}
+}
 
-	var result error
-	if fn == "Dispose" {
-		if this.Logfn != nil {
-			this.Logfn(strings.Repeat(" ", indent*2), fn, "=>", unit.Type())
-		}
-		if err := callFn(fn, unit, args); err != nil {
-			result = multierror.Append(result, err)
+// keyForUnit returns the unit instance for a unit, or the type of
+// the unit if it is an application object
+func (this *graph) keyForUnit(unit reflect.Value) unitKey {
+	for key, other := range this.units {
+		if other == unit {
+			return key
 		}
 	}
+	return unitKey{unit.Type(), ""}
+}
+
+func (this *graph) do(fn string, unit reflect.Value, name string, args []reflect.Value, seen map[unitKey]bool, indent int) error {
+	// Check incoming parameter
+	if isUnitType(unit.Type()) == false {
+		return gopi.ErrBadParameter.WithPrefix(unit.Type().String())
+	}
 
 	// For each field, call function
+	var result error
 	if err := forEachField(unit, fn == "New", func(f reflect.StructField, i int) error {
-		if t := this.unitTypeForField(f); t == nil {
+		if key, exists := this.unitKeyForField(f); exists == false {
+			return nil
+		} else if isUnitType(key.t) == false {
 			return nil
-		} else if _, exists := seen[t]; exists {
+		} else if _, exists := seen[key]; exists {
 			return nil
-		} else if err := this.do(fn, this.units[t], args, seen, indent+1); err != nil {
-			seen[t] = true
-			return fmt.Errorf("%w (in %v)", err, t)
+		} else if err := this.do(fn, this.units[key], key.name, args, seen, indent+1); err != nil {
+			seen[key] = true
+			return fmt.Errorf("%w (in %v)", err, key)
 		} else {
-			seen[t] = true
+			seen[key] = true
 		}
 		return nil
 	}); err != nil {
 		result = multierror.Append(result, err)
 	}
 
-	if fn == "Run" {
-		if this.Logfn != nil {
-			this.Logfn(strings.Repeat(" ", indent*2), fn, "=>", unit.Type())
-		}
-		this.callRun(unit)
-	} else if fn != "Dispose" {
-		if this.Logfn != nil {
-			this.Logfn(strings.Repeat(" ", indent*2), fn, "=>", unit.Type())
-		}
-		if err := callFn(fn, unit, args); err != nil {
-			result = multierror.Append(result, err)
-		}
+	if this.Logfn != nil {
+		this.Logfn(strings.Repeat(" ", indent*2), fn, "=>", unitKey{unit.Type(), name})
+	}
+	if err := callFn(fn, unit, withPrefix(args, name)); err != nil {
+		result = multierror.Append(result, err)
 	}
 
 	return result
 }
 
-func (this *graph) callRun(unit reflect.Value) {
-	this.WaitGroup.Add(1)
+// callRun calls the Run function for a unit in the background and
+// returns a handle which can be used to stop it. The Run function is
+// restarted according to the policy for the unit
+func (this *graph) callRun(unit reflect.Value) *run {
 	ctx, cancel := context.WithCancel(context.Background())
-	this.cancels = append(this.cancels, cancel)
+	r := &run{unit, this.keyForUnit(unit), cancel, make(chan struct{}), nil}
+	p, exists := this.policies[r.key]
+	if exists == false {
+		p = defaultPolicy()
+	}
 	go func() {
-		err := callFn("Run", unit, []reflect.Value{reflect.ValueOf(ctx)})
-		if this.isAppObject(unit) {
-			// Run ends when any application Run function ends
-			this.cancelWithError(err)
+		defer close(r.done)
+		// The number of restarts is counted separately from the exponent
+		// of the delay, which is reset when the unit runs for long enough
+		for attempt, exponent := uint(0), uint(0); ; attempt, exponent = attempt+1, exponent+1 {
+			start := time.Now()
+			r.err = callFn("Run", unit, []reflect.Value{reflect.ValueOf(ctx)})
+			if ctx.Err() != nil || this.isAppObject(unit) {
+				break
+			} else if p.shouldRestart(r.err, attempt) == false {
+				if p.restart != restartNever {
+					this.emit(&restartEvent{r.key.String(), r.err, attempt, 0, true})
+				}
+				break
+			}
+
+			// Reset the delay if the unit ran for long enough
+			if time.Since(start) > backoffReset {
+				exponent = 0
+			}
+
+			// Wait before restarting
+			delay := p.delay(exponent)
+			if logger := this.GetLogger(); logger != nil {
+				logger.Debug("Restarting ", r.key, " in ", delay, ": ", r.err)
+			}
+			this.emit(&restartEvent{r.key.String(), r.err, attempt + 1, delay, false})
+			select {
+			case <-ctx.Done():
+				r.err = nil
+			case <-time.After(delay):
+				continue
+			}
+			break
 		}
 		if this.Logfn != nil {
-			this.Logfn("Run", "<=", unit.Type())
+			this.Logfn("Run", "<=", r.key)
+		}
+		if this.isAppObject(unit) {
+			// Run ends when any application Run function ends
+			this.cancelWithError(r.err)
+		} else if r.err != nil && errors.Is(r.err, context.Canceled) == false {
+			if p.critical {
+				// Run ends when any critical unit returns an error
+				this.cancelWithError(r.err)
+			} else if logger := this.GetLogger(); logger != nil {
+				logger.Print("Stopped ", r.key, ": ", r.err)
+			}
 		}
-		this.errs <- err
-		this.WaitGroup.Done()
-		<-ctx.Done()
 	}()
+	return r
+}
+
+// emit sends an event to the publisher, if there is one in the graph
+func (this *graph) emit(evt gopi.Event) {
+	if t := this.unitTypeForInterface(publisherType); t == nil {
+		return
+	} else if unit, exists := this.units[unitKey{t, ""}]; exists == false {
+		return
+	} else if publisher, ok := unit.Interface().(gopi.Publisher); ok {
+		publisher.Emit(evt, false)
+	}
+}
+
+// stop cancels a Run function and waits for it to return, or returns
+// an error if it does not return within the timeout. A zero timeout
+// waits indefinitely
+func (r *run) stop(timeout time.Duration) error {
+	r.cancel()
+	if timeout > 0 {
+		select {
+		case <-r.done:
+			break
+		case <-time.After(timeout):
+			return fmt.Errorf("%v did not stop within %v: %w", r.key, timeout, context.DeadlineExceeded)
+		}
+	} else {
+		<-r.done
+	}
+	if r.err != nil && errors.Is(r.err, context.Canceled) == false {
+		return fmt.Errorf("%w (in %v)", r.err, r.key)
+	}
+	return nil
 }
 
 /////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
+func (k unitKey) String() string {
+	if k.name == "" {
+		return fmt.Sprint(k.t)
+	} else {
+		return fmt.Sprintf("%v (%v)", k.t, k.name)
+	}
+}
+
 func (this *graph) String() string {
 	str := "<graph"
 	for k, v := range this.objs {
@@ -0,0 +1,807 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	config "github.com/djthorpe/gopi/v3/pkg/config"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+type recorder struct {
+	sync.Mutex
+	calls []string
+}
+
+type LeafUnit struct {
+	gopi.Unit
+	*recorder
+}
+
+type MiddleUnitA struct {
+	gopi.Unit
+	*LeafUnit
+	*recorder
+}
+
+type MiddleUnitB struct {
+	gopi.Unit
+	*LeafUnit
+	*recorder
+}
+
+type OrderApp struct {
+	gopi.Unit
+	*MiddleUnitA
+	*MiddleUnitB
+	*recorder
+}
+
+type StuckUnit struct {
+	gopi.Unit
+}
+
+type StuckApp struct {
+	gopi.Unit
+	*StuckUnit
+}
+
+/////////////////////////////////////////////////////////////////////
+// METHODS
+
+func (this *recorder) record(name string) {
+	this.Lock()
+	defer this.Unlock()
+	this.calls = append(this.calls, name)
+}
+
+func (this *recorder) index(name string) int {
+	this.Lock()
+	defer this.Unlock()
+	for i, call := range this.calls {
+		if call == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (this *LeafUnit) Dispose() error {
+	this.record("leaf")
+	return nil
+}
+
+func (this *MiddleUnitA) Dispose() error {
+	this.record("a")
+	return nil
+}
+
+func (this *MiddleUnitB) Dispose() error {
+	this.record("b")
+	return nil
+}
+
+func (this *OrderApp) Dispose() error {
+	this.record("app")
+	return nil
+}
+
+func (this *StuckUnit) Run(ctx context.Context) error {
+	// Ignore the context and never return
+	select {}
+}
+
+func (this *StuckApp) Run(ctx context.Context) error {
+	return nil
+}
+
+type SlowUnit struct {
+	gopi.Unit
+}
+
+type TimeoutApp struct {
+	gopi.Unit
+	*SlowUnit  `gopi:"timeout=1s"`
+	*StuckUnit `gopi:"timeout=100ms"`
+}
+
+func (this *SlowUnit) Run(ctx context.Context) error {
+	// Take longer than the graph timeout to stop
+	<-ctx.Done()
+	time.Sleep(200 * time.Millisecond)
+	return nil
+}
+
+func (this *TimeoutApp) Run(ctx context.Context) error {
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Graph_001(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(OrderApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Share a recorder between all the units
+	r := new(recorder)
+	app.recorder = r
+	app.MiddleUnitA.recorder = r
+	app.MiddleUnitB.recorder = r
+	app.MiddleUnitA.LeafUnit.recorder = r
+	if app.MiddleUnitA.LeafUnit != app.MiddleUnitB.LeafUnit {
+		t.Fatal("Expected shared leaf unit")
+	}
+
+	if err := g.Dispose(); err != nil {
+		t.Fatal(err)
+	}
+	if r.index("app") != 0 {
+		t.Error("Expected app to be disposed first:", r.calls)
+	}
+	if r.index("leaf") != len(r.calls)-1 {
+		t.Error("Expected leaf to be disposed last:", r.calls)
+	}
+	if r.index("a") < 0 || r.index("b") < 0 {
+		t.Error("Expected all units to be disposed:", r.calls)
+	}
+}
+
+func Test_Graph_002(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(StuckApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New(t.Name(), []string{"-graph.timeout", "100ms"})
+	if err := g.Define(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	} else if err := g.New(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// The application ends immediately but the unit does not stop
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := g.Run(ctx, true); errors.Is(err, context.DeadlineExceeded) == false {
+		t.Error("Expected deadline exceeded error, got:", err)
+	} else {
+		t.Log(err)
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// SELECT UNITS
+
+type Greeter interface {
+	Greet() string
+}
+
+type HelloUnit struct {
+	gopi.Unit
+}
+
+type GoodbyeUnit struct {
+	gopi.Unit
+}
+
+type GreeterApp struct {
+	gopi.Unit
+	Greeter
+}
+
+func (*HelloUnit) Greet() string   { return "hello" }
+func (*GoodbyeUnit) Greet() string { return "goodbye" }
+
+func init() {
+	RegisterNamedUnit("hello", reflect.TypeOf(&HelloUnit{}), reflect.TypeOf((*Greeter)(nil)))
+	RegisterNamedUnit("goodbye", reflect.TypeOf(&GoodbyeUnit{}), reflect.TypeOf((*Greeter)(nil)))
+}
+
+func Test_Graph_003(t *testing.T) {
+	if err := registerUnit("hello", reflect.TypeOf(&GoodbyeUnit{}), reflect.TypeOf((*Greeter)(nil))); errors.Is(err, gopi.ErrDuplicateEntry) == false {
+		t.Error("Expected duplicate entry error, got:", err)
+	}
+
+	// First registered is used without selection
+	g := NewGraph(t.Log)
+	app := new(GreeterApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	} else if app.Greeter == nil {
+		t.Fatal("Expected Greeter to be set")
+	} else if greet := app.Greet(); greet != "hello" {
+		t.Error("Unexpected unit selected:", greet)
+	}
+}
+
+func Test_Graph_004(t *testing.T) {
+	cfg := config.New(t.Name(), []string{"-unit.graph.Greeter=goodbye"})
+	g := NewGraph(t.Log)
+	app := new(GreeterApp)
+	if err := g.Select(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	} else if greet := app.Greet(); greet != "goodbye" {
+		t.Error("Unexpected unit selected:", greet)
+	}
+
+	// Flag is defined and consistent with selection
+	if err := g.Define(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	} else if err := g.New(cfg); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Graph_005(t *testing.T) {
+	g := NewGraph(t.Log)
+	if err := g.Select(config.New(t.Name(), []string{"-unit.graph.Greeter", "other"})); errors.Is(err, gopi.ErrNotFound) == false {
+		t.Error("Expected not found error, got:", err)
+	}
+	if err := g.SelectUnit("graph.Other", "hello"); errors.Is(err, gopi.ErrNotFound) == false {
+		t.Error("Expected not found error, got:", err)
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// NAMED INSTANCES
+
+type AmplifierUnit struct {
+	gopi.Unit
+	tty      *string
+	reloaded string
+}
+
+type AmplifierApp struct {
+	gopi.Unit
+	Zone1 *AmplifierUnit
+	Zone2 *AmplifierUnit `gopi:"name=zone2"`
+}
+
+func (this *AmplifierUnit) Define(cfg gopi.Config) error {
+	this.tty = cfg.FlagString("amp.tty", "/dev/ttyUSB0", "Amplifier device")
+	return nil
+}
+
+func (this *AmplifierUnit) Reload(cfg gopi.Config) error {
+	this.reloaded = cfg.GetString("amp.tty")
+	return nil
+}
+
+func Test_Graph_006(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(AmplifierApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	} else if app.Zone1 == app.Zone2 {
+		t.Fatal("Expected separate instances")
+	}
+
+	cfg := config.New(t.Name(), []string{"-zone2.amp.tty", "/dev/ttyUSB1"})
+	if err := g.Define(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	} else if err := g.New(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if *app.Zone1.tty != "/dev/ttyUSB0" {
+		t.Error("Unexpected value for zone1:", *app.Zone1.tty)
+	}
+	if *app.Zone2.tty != "/dev/ttyUSB1" {
+		t.Error("Unexpected value for zone2:", *app.Zone2.tty)
+	}
+}
+
+func Test_Graph_007(t *testing.T) {
+	type BadApp struct {
+		gopi.Unit
+		Amp *AmplifierUnit `gopi:"name=-bad"`
+	}
+	if err := NewGraph(t.Log).Create(new(BadApp)); errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// RESTART POLICIES
+
+type FlakyUnit struct {
+	gopi.Unit
+	sync.Mutex
+	runs   int
+	broken bool
+}
+
+type FlakyApp struct {
+	gopi.Unit
+	Flaky  *FlakyUnit `gopi:"restart=on-failure,backoff=10ms"`
+	Broken *FlakyUnit `gopi:"name=broken,restart=on-failure,retries=2,backoff=10ms,critical=false"`
+}
+
+func (this *FlakyUnit) Run(ctx context.Context) error {
+	this.Lock()
+	this.runs++
+	runs := this.runs
+	this.Unlock()
+
+	// Fail the first two times, then run until cancelled. The broken
+	// unit always fails
+	if runs <= 2 || this.broken {
+		return gopi.ErrUnexpectedResponse
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (this *FlakyUnit) Runs() int {
+	this.Lock()
+	defer this.Unlock()
+	return this.runs
+}
+
+func (this *FlakyApp) Run(ctx context.Context) error {
+	timer := time.NewTimer(500 * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type FailingUnit struct {
+	gopi.Unit
+	sync.Mutex
+	runs int
+}
+
+type FailingApp struct {
+	gopi.Unit
+	*FailingUnit `gopi:"restart=on-failure,retries=2,backoff=10ms"`
+}
+
+func (this *FailingUnit) Run(ctx context.Context) error {
+	this.Lock()
+	this.runs++
+	this.Unlock()
+
+	// Run for long enough for the delay to be reset, then fail
+	time.Sleep(50 * time.Millisecond)
+	return gopi.ErrUnexpectedResponse
+}
+
+func (this *FailingUnit) Runs() int {
+	this.Lock()
+	defer this.Unlock()
+	return this.runs
+}
+
+func (this *FailingApp) Run(ctx context.Context) error {
+	timer := time.NewTimer(2 * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func Test_Graph_008(t *testing.T) {
+	if _, err := parsePolicy(defaultPolicy(), map[string]string{"restart": "sometimes"}); errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	}
+	p, err := parsePolicy(defaultPolicy(), map[string]string{"restart": "always", "backoff": "1s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := p.delay(0); d != time.Second {
+		t.Error("Unexpected delay:", d)
+	}
+	if d := p.delay(3); d != 8*time.Second {
+		t.Error("Unexpected delay:", d)
+	}
+	if d := p.delay(100); d != MaxBackoff {
+		t.Error("Unexpected delay:", d)
+	}
+	if p.shouldRestart(nil, 0) == false {
+		t.Error("Expected restart")
+	}
+}
+
+func Test_Graph_009(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(FlakyApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	}
+	app.Broken.broken = true
+
+	// The flaky unit is restarted, and the broken unit gives up without
+	// cancelling the application
+	start := time.Now()
+	if err := g.Run(context.Background(), true); errors.Is(err, gopi.ErrUnexpectedResponse) == false {
+		t.Error("Expected unexpected response error, got:", err)
+	}
+	if time.Since(start) < 500*time.Millisecond {
+		t.Error("Expected application to run until completion")
+	}
+	if runs := app.Flaky.Runs(); runs != 3 {
+		t.Error("Unexpected number of runs:", runs)
+	}
+	if runs := app.Broken.Runs(); runs != 3 {
+		t.Error("Unexpected number of runs:", runs)
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// HEALTH CHECKS
+
+type HealthyUnit struct {
+	gopi.Unit
+}
+
+type SickUnit struct {
+	gopi.Unit
+}
+
+type HealthApp struct {
+	gopi.Unit
+	*HealthyUnit
+	*SickUnit
+	*LeafUnit
+}
+
+func (this *HealthyUnit) Health(ctx context.Context) error {
+	return nil
+}
+
+func (this *SickUnit) Health(ctx context.Context) error {
+	return gopi.ErrUnexpectedResponse
+}
+
+func (this *HealthApp) Run(ctx context.Context) error {
+	time.Sleep(100 * time.Millisecond)
+	return nil
+}
+
+func Test_Graph_010(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(HealthApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	} else if health := g.Health(); len(health) != 0 {
+		t.Error("Expected no health status before Run:", health)
+	}
+	if err := g.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	health := g.Health()
+	if len(health) != 2 {
+		t.Error("Unexpected health status:", health)
+	}
+	if err, exists := health["*graph.HealthyUnit"]; exists == false || err != nil {
+		t.Error("Unexpected health for healthy unit:", err)
+	}
+	if err, exists := health["*graph.SickUnit"]; exists == false || errors.Is(err, gopi.ErrUnexpectedResponse) == false {
+		t.Error("Unexpected health for sick unit:", err)
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// DUMP
+
+func Test_Graph_011(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(AmplifierApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Application object is last in lifecycle order
+	var buf bytes.Buffer
+	var result dump
+	if err := g.Dump(&buf, "json"); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	} else if len(result.Units) != 3 || len(result.Fields) != 2 {
+		t.Fatal("Unexpected dump:", buf.String())
+	} else if result.Units[2].Object == false || len(result.Objects) != 1 || result.Objects[0] != result.Units[2].Key {
+		t.Error("Unexpected objects:", buf.String())
+	} else if result.Units[1].Instance != "zone2" {
+		t.Error("Unexpected instance:", buf.String())
+	}
+
+	buf.Reset()
+	if err := g.Dump(&buf, "dot"); err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(buf.String(), `"*graph.AmplifierApp" -> "*graph.AmplifierUnit (zone2)" [label="Zone2"];`) == false {
+		t.Error("Unexpected dot output:", buf.String())
+	}
+
+	if err := g.Dump(&buf, "svg"); errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// OVERRIDES
+
+type fakeGreeter struct{}
+
+type FakeGreeterUnit struct {
+	gopi.Unit
+	*LeafUnit
+}
+
+func (*fakeGreeter) Greet() string     { return "fake" }
+func (*FakeGreeterUnit) Greet() string { return "fake unit" }
+
+func Test_Graph_012(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(GreeterApp)
+	if err := g.Override((*Greeter)(nil), new(fakeGreeter)); err != nil {
+		t.Fatal(err)
+	} else if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	} else if greet := app.Greet(); greet != "fake" {
+		t.Error("Unexpected unit:", greet)
+	} else if err := g.Override((*Greeter)(nil), new(fakeGreeter)); errors.Is(err, gopi.ErrOutOfOrder) == false {
+		t.Error("Expected out of order error, got:", err)
+	}
+
+	// Registered units are not changed
+	other := new(GreeterApp)
+	if err := NewGraph(t.Log).Create(other); err != nil {
+		t.Fatal(err)
+	} else if greet := other.Greet(); greet != "hello" {
+		t.Error("Unexpected unit:", greet)
+	}
+
+	// Lifecycle methods are not called on values which are not units
+	if err := g.Dispose(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Graph_013(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(GreeterApp)
+	fake := new(FakeGreeterUnit)
+	if err := g.Override((*Greeter)(nil), &HelloUnit{}); err != nil {
+		t.Error(err)
+	}
+	if err := g.Override((*Greeter)(nil), fake); err != nil {
+		t.Fatal(err)
+	} else if err := g.Override((*Greeter)(nil), "fake"); errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	} else if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	} else if app.Greeter != fake {
+		t.Error("Expected fake unit")
+	} else if fake.LeafUnit == nil {
+		t.Error("Expected fields of fake unit to be resolved")
+	}
+
+	// Fake unit is disposed
+	r := new(recorder)
+	fake.LeafUnit.recorder = r
+	if err := g.Dispose(); err != nil {
+		t.Error(err)
+	} else if r.index("leaf") != 0 {
+		t.Error("Expected leaf to be disposed:", r.calls)
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// RELOAD
+
+func Test_Graph_014(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(AmplifierApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New(t.Name(), []string{"-zone2.amp.tty", "/dev/ttyUSB1"})
+	if err := g.Define(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	} else if err := g.Reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Named instances are reloaded with their own flags
+	if app.Zone1.reloaded != "/dev/ttyUSB0" {
+		t.Error("Unexpected value for zone1:", app.Zone1.reloaded)
+	}
+	if app.Zone2.reloaded != "/dev/ttyUSB1" {
+		t.Error("Unexpected value for zone2:", app.Zone2.reloaded)
+	}
+}
+
+func Test_Graph_015(t *testing.T) {
+	cfg := config.New(t.Name(), nil)
+	named := withPrefix([]reflect.Value{reflect.ValueOf(cfg)}, "zone1")[0].Interface().(gopi.Config)
+	fn := func(context.Context, []string) ([]string, error) {
+		return nil, nil
+	}
+
+	// Flag completion is prefixed with the instance name
+	if err := named.Complete("-amp.tty", fn); err != nil {
+		t.Error(err)
+	} else if err := cfg.Complete("-zone1.amp.tty", fn); errors.Is(err, gopi.ErrDuplicateEntry) == false {
+		t.Error("Expected duplicate entry, got", err)
+	}
+
+	// Commands cannot be defined by named units
+	if err := named.Command("amp", "Amplifier", func(context.Context) error { return nil }); errors.Is(err, gopi.ErrNotImplemented) == false {
+		t.Error("Expected not implemented, got", err)
+	}
+	if err := named.Complete("amp", fn); errors.Is(err, gopi.ErrNotImplemented) == false {
+		t.Error("Expected not implemented, got", err)
+	}
+}
+
+func Test_Graph_016(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempdir)
+	path := filepath.Join(tempdir, "gopi.yaml")
+	if err := ioutil.WriteFile(path, []byte("unit:\n  graph.Greeter: goodbye\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unit is selected from the configuration file, and is consistent
+	// with the flag value when parsed
+	cfg := config.New(t.Name(), []string{"-config", path})
+	g := NewGraph(t.Log)
+	app := new(GreeterApp)
+	if err := g.Select(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	} else if greet := app.Greet(); greet != "goodbye" {
+		t.Error("Unexpected unit selected:", greet)
+	} else if err := g.Define(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	} else if err := g.New(cfg); err != nil {
+		t.Error(err)
+	}
+
+	// Environment overrides the configuration file, and the command
+	// line overrides the environment
+	os.Setenv(config.EnvName("unit.graph.Greeter"), "hello")
+	defer os.Unsetenv(config.EnvName("unit.graph.Greeter"))
+	for _, test := range []struct {
+		args  []string
+		greet string
+	}{
+		{[]string{"-config", path}, "hello"},
+		{[]string{"-config", path, "-unit.graph.Greeter", "goodbye"}, "goodbye"},
+	} {
+		g := NewGraph(t.Log)
+		app := new(GreeterApp)
+		if err := g.Select(config.New(t.Name(), test.args)); err != nil {
+			t.Error(err)
+		} else if err := g.Create(app); err != nil {
+			t.Error(err)
+		} else if greet := app.Greet(); greet != test.greet {
+			t.Error(test.args, "Unexpected unit selected:", greet)
+		}
+	}
+}
+
+func Test_Graph_017(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(TimeoutApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New(t.Name(), []string{"-graph.timeout", "50ms"})
+	if err := g.Define(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	} else if err := g.New(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// The slow unit stops within its own timeout, which is longer
+	// than the graph timeout, and the stuck unit does not
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := g.Run(ctx, true); errors.Is(err, context.DeadlineExceeded) == false {
+		t.Error("Expected deadline exceeded error, got:", err)
+	} else if strings.Contains(err.Error(), "StuckUnit did not stop within 100ms") == false {
+		t.Error("Unexpected error:", err)
+	} else if strings.Contains(err.Error(), "SlowUnit") {
+		t.Error("Unexpected error:", err)
+	}
+
+	// Timeout cannot be negative
+	if _, err := parsePolicy(defaultPolicy(), map[string]string{"timeout": "-1s"}); errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	}
+}
+
+func Test_Graph_018(t *testing.T) {
+	reset := backoffReset
+	backoffReset = 10 * time.Millisecond
+	defer func() { backoffReset = reset }()
+
+	g := NewGraph(t.Log)
+	app := new(FailingApp)
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	}
+
+	// The unit fails after the delay is reset, but still gives up
+	// after the number of retries and cancels the application
+	start := time.Now()
+	if err := g.Run(context.Background(), true); errors.Is(err, gopi.ErrUnexpectedResponse) == false {
+		t.Error("Expected unexpected response error, got:", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Expected critical unit to cancel the application")
+	}
+	if runs := app.FailingUnit.Runs(); runs != 3 {
+		t.Error("Unexpected number of runs:", runs)
+	}
+}
+
+func Test_Graph_019(t *testing.T) {
+	g := NewGraph(t.Log)
+	app := new(HealthApp)
+	if units := g.Units((*gopi.HealthChecker)(nil)); len(units) != 0 {
+		t.Error("Expected no units before Create:", units)
+	}
+	if err := g.Create(app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the units which report health are returned
+	units := g.Units((*gopi.HealthChecker)(nil))
+	if len(units) != 2 {
+		t.Fatal("Unexpected units:", units)
+	}
+	for _, unit := range units {
+		if unit != app.HealthyUnit && unit != app.SickUnit {
+			t.Error("Unexpected unit:", unit)
+		}
+	}
+
+	// Types which are not interfaces return nothing
+	if units := g.Units(app); len(units) != 0 {
+		t.Error("Unexpected units:", units)
+	}
+}
@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// health holds the result of the last health check for each unit
+// which implements gopi.HealthChecker, keyed by unit name
+type health struct {
+	sync.RWMutex
+
+	interval *time.Duration
+	status   map[string]error
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// DefaultHealthInterval is the time between unit health checks
+	DefaultHealthInterval = 30 * time.Second
+
+	// HealthTimeout is the maximum time a single health check can take
+	HealthTimeout = 5 * time.Second
+)
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Health returns the last health check result for each unit in the
+// global graph
+func Health() map[string]error {
+	return Global.Health()
+}
+
+// Health returns the last health check result for each unit which
+// implements a Health method. A nil value indicates the unit is healthy.
+// Units are not checked until Run is called
+func (this *graph) Health() map[string]error {
+	this.health.RLock()
+	defer this.health.RUnlock()
+
+	result := make(map[string]error, len(this.health.status))
+	for k, v := range this.health.status {
+		result[k] = v
+	}
+	return result
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *health) define(cfg gopi.Config) {
+	this.interval = cfg.FlagDuration("graph.health", DefaultHealthInterval, "Interval between unit health checks, or zero to disable")
+}
+
+func (this *health) checkInterval() time.Duration {
+	if this.interval == nil {
+		return DefaultHealthInterval
+	} else {
+		return *this.interval
+	}
+}
+
+// runHealth checks the health of units immediately and then on every
+// interval until the context is cancelled
+func (this *graph) runHealth(ctx context.Context) {
+	interval := this.health.checkInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		this.checkHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			continue
+		}
+	}
+}
+
+// checkHealth calls the Health method on units in dependency order
+// and stores the results
+func (this *graph) checkHealth(ctx context.Context) {
+	status := make(map[string]error)
+	for _, unit := range this.order {
+		if unit.Type().Implements(healthType) == false {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, HealthTimeout)
+		key := this.keyForUnit(unit).String()
+		status[key] = callFn("Health", unit, []reflect.Value{reflect.ValueOf(ctx)})
+		cancel()
+		if status[key] != nil && this.Logfn != nil {
+			this.Logfn("Health", "=>", key, ":", status[key])
+		}
+	}
+
+	this.health.Lock()
+	defer this.health.Unlock()
+	this.health.status = status
+}
@@ -0,0 +1,185 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// policy determines how a unit Run function is supervised. It is set
+// with struct tags on the fields which reference the unit, for example
+// `gopi:"restart=on-failure,retries=5,backoff=1s,critical=false,timeout=10s"`
+type policy struct {
+	restart  restart
+	retries  uint
+	backoff  time.Duration
+	critical bool
+	timeout  time.Duration // Shutdown timeout, or -1 for the graph timeout
+}
+
+type restart uint
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	restartNever restart = iota
+	restartOnFailure
+	restartAlways
+)
+
+const (
+	// DefaultBackoff is the delay before the first restart, which is
+	// doubled for every subsequent restart
+	DefaultBackoff = time.Second
+
+	// MaxBackoff is the maximum delay between restarts. When a Run
+	// function runs for longer than this, the delay is reset but the
+	// number of restarts is not
+	MaxBackoff = time.Minute
+)
+
+var (
+	// backoffReset is how long a Run function needs to run for the
+	// delay to be reset, which is shortened in tests
+	backoffReset = MaxBackoff
+)
+
+/////////////////////////////////////////////////////////////////////
+// NEW
+
+// defaultPolicy returns a policy which does not restart a unit, cancels
+// all units when the Run function returns an error, and uses the graph
+// shutdown timeout
+func defaultPolicy() policy {
+	return policy{restartNever, 0, DefaultBackoff, true, -1}
+}
+
+// parsePolicy updates a policy from struct tag options
+func parsePolicy(p policy, opts map[string]string) (policy, error) {
+	if value, exists := opts["restart"]; exists {
+		switch value {
+		case "never":
+			p.restart = restartNever
+		case "on-failure":
+			p.restart = restartOnFailure
+		case "always":
+			p.restart = restartAlways
+		default:
+			return p, gopi.ErrBadParameter.WithPrefix("restart=", value)
+		}
+	}
+	if value, exists := opts["retries"]; exists {
+		if retries, err := strconv.ParseUint(value, 10, 32); err != nil {
+			return p, gopi.ErrBadParameter.WithPrefix("retries=", value)
+		} else {
+			p.retries = uint(retries)
+		}
+	}
+	if value, exists := opts["backoff"]; exists {
+		if backoff, err := time.ParseDuration(value); err != nil || backoff <= 0 {
+			return p, gopi.ErrBadParameter.WithPrefix("backoff=", value)
+		} else {
+			p.backoff = backoff
+		}
+	}
+	if value, exists := opts["critical"]; exists {
+		if value == "" {
+			p.critical = true
+		} else if critical, err := strconv.ParseBool(value); err != nil {
+			return p, gopi.ErrBadParameter.WithPrefix("critical=", value)
+		} else {
+			p.critical = critical
+		}
+	}
+	if value, exists := opts["timeout"]; exists {
+		if timeout, err := time.ParseDuration(value); err != nil || timeout < 0 {
+			return p, gopi.ErrBadParameter.WithPrefix("timeout=", value)
+		} else {
+			p.timeout = timeout
+		}
+	}
+
+	// Return success
+	return p, nil
+}
+
+/////////////////////////////////////////////////////////////////////
+// METHODS
+
+// shouldRestart returns true if the Run function should be restarted after
+// returning with an error, where attempt is the number of restarts
+// so far, including restarts after the delay was reset. A zero value
+// for retries means restart indefinitely
+func (p policy) shouldRestart(err error, attempt uint) bool {
+	if p.retries != 0 && attempt >= p.retries {
+		return false
+	}
+	switch p.restart {
+	case restartOnFailure:
+		return err != nil
+	case restartAlways:
+		return true
+	default:
+		return false
+	}
+}
+
+// delay returns the delay before a restart, which doubles for each
+// attempt up to the maximum backoff
+func (p policy) delay(attempt uint) time.Duration {
+	delay := p.backoff
+	for i := uint(0); i < attempt && delay < MaxBackoff; i++ {
+		delay = delay * 2
+	}
+	if delay > MaxBackoff {
+		delay = MaxBackoff
+	}
+	return delay
+}
+
+// shutdownTimeout returns the time to wait for the Run function to return
+// when cancelled, which is the graph timeout unless set in the policy.
+// A zero value waits indefinitely
+func (p policy) shutdownTimeout(timeout time.Duration) time.Duration {
+	if p.timeout < 0 {
+		return timeout
+	} else {
+		return p.timeout
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (p policy) String() string {
+	str := "<policy"
+	str += " restart=" + fmt.Sprint(p.restart)
+	if p.retries > 0 {
+		str += " retries=" + fmt.Sprint(p.retries)
+	}
+	str += " backoff=" + fmt.Sprint(p.backoff)
+	str += " critical=" + fmt.Sprint(p.critical)
+	if p.timeout >= 0 {
+		str += " timeout=" + fmt.Sprint(p.timeout)
+	}
+	return str + ">"
+}
+
+func (r restart) String() string {
+	switch r {
+	case restartNever:
+		return "never"
+	case restartOnFailure:
+		return "on-failure"
+	case restartAlways:
+		return "always"
+	default:
+		return "[?? Invalid restart value]"
+	}
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"reflect"
+	"strings"
 
 	gopi "github.com/djthorpe/gopi/v3"
 	multierror "github.com/hashicorp/go-multierror"
@@ -11,11 +12,23 @@ import (
 // GLOBALS
 
 var (
-	unitType   = reflect.TypeOf((*gopi.Unit)(nil)).Elem()
-	stubType   = reflect.TypeOf((*gopi.ServiceStub)(nil)).Elem()
-	loggerType = reflect.TypeOf((*gopi.Logger)(nil)).Elem()
+	unitType      = reflect.TypeOf((*gopi.Unit)(nil)).Elem()
+	stubType      = reflect.TypeOf((*gopi.ServiceStub)(nil)).Elem()
+	loggerType    = reflect.TypeOf((*gopi.Logger)(nil)).Elem()
+	publisherType = reflect.TypeOf((*gopi.Publisher)(nil)).Elem()
+	healthType    = reflect.TypeOf((*gopi.HealthChecker)(nil)).Elem()
+	namedType     = reflect.TypeOf((*namedLogger)(nil)).Elem()
 )
 
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// namedLogger returns a logger with a name prefix, which is set on
+// the logger fields of each unit
+type namedLogger interface {
+	Named(string) gopi.LevelLogger
+}
+
 /////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
@@ -39,6 +52,22 @@ func forEachField(unit reflect.Value, immediate bool, fn func(reflect.StructFiel
 	return result
 }
 
+// fieldTag returns the options set in the gopi struct tag for a field,
+// which are comma-separated and of the form key=value or key
+func fieldTag(f reflect.StructField) map[string]string {
+	result := make(map[string]string)
+	for _, opt := range strings.Split(f.Tag.Get("gopi"), ",") {
+		if opt = strings.TrimSpace(opt); opt == "" {
+			continue
+		} else if kv := strings.SplitN(opt, "=", 2); len(kv) == 2 {
+			result[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		} else {
+			result[opt] = ""
+		}
+	}
+	return result
+}
+
 // isStructPtr returns true if the type is a pointer to a struct
 func isStructPtr(t reflect.Type) bool {
 	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
@@ -0,0 +1,256 @@
+package graph
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// impl is a named unit type registered for an interface
+type impl struct {
+	name string
+	t    reflect.Type
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// unitFlagPrefix is the prefix for flags which select a unit
+	// for an interface, for example -unit.gopi.GPIO=sysfs
+	unitFlagPrefix = "unit."
+)
+
+// valuer is implemented by configurations which can return values before
+// flags are defined
+type valuer interface {
+	LookupValue(string) (string, bool, error)
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Select chooses units for interfaces from -unit.<interface>=<name> values
+// on the command line, in the environment or in a configuration file, and
+// needs to be called before Create
+func Select(cfg gopi.Config) error {
+	return Global.Select(cfg)
+}
+
+// Select chooses units for interfaces from -unit.<interface>=<name> values
+// on the command line, in the environment or in a configuration file, and
+// needs to be called before Create
+func (this *graph) Select(cfg gopi.Config) error {
+	cfg_, ok := cfg.(valuer)
+	if ok == false {
+		return gopi.ErrNotImplemented.WithPrefix("Select")
+	}
+	for _, i := range this.registeredInterfaces() {
+		if value, exists, err := cfg_.LookupValue(unitFlagPrefix + i.String()); err != nil {
+			return err
+		} else if exists == false || value == "" {
+			continue
+		} else if err := this.SelectUnit(i.String(), value); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// SelectUnit chooses a named unit for an interface, where the interface
+// is identified by name (for example, "gopi.GPIO"). It needs to be
+// called before Create
+func (this *graph) SelectUnit(name, unit string) error {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+
+	// Cannot select once the graph has been created
+	if len(this.objs) != 0 {
+		return gopi.ErrOutOfOrder.WithPrefix("SelectUnit")
+	}
+
+	for i, impls := range iface {
+		if i.String() != name {
+			continue
+		}
+		for _, impl := range impls {
+			if impl.name == unit {
+				this.selected[i] = unit
+				return nil
+			}
+		}
+		return gopi.ErrNotFound.WithPrefix(name, ": ", unit)
+	}
+
+	// Interface not found
+	return gopi.ErrNotFound.WithPrefix(name)
+}
+
+// Override binds an interface to a value instead of a registered unit,
+// for example Override((*gopi.I2C)(nil), fake), which is intended for
+// testing. The registered units are not changed. When the value is a
+// unit, its own fields are resolved and lifecycle methods are called.
+// It needs to be called before Create
+func (this *graph) Override(i, unit interface{}) error {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+
+	// Cannot override once the graph has been created
+	if len(this.objs) != 0 {
+		return gopi.ErrOutOfOrder.WithPrefix("Override")
+	}
+
+	// Check parameters
+	t := reflect.TypeOf(i)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Interface {
+		return gopi.ErrBadParameter.WithPrefix("Override: ", reflect.TypeOf(i))
+	}
+	v := reflect.ValueOf(unit)
+	if unit == nil || v.Type().Implements(t) == false {
+		return gopi.ErrBadParameter.WithPrefix("Override: ", t, ": ", reflect.TypeOf(unit))
+	} else if v.Kind() == reflect.Ptr && v.IsNil() {
+		return gopi.ErrBadParameter.WithPrefix("Override: ", t, ": ", reflect.TypeOf(unit))
+	}
+
+	// Set the override
+	this.overrides[t] = v
+
+	// Return success
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// unitTypeForInterface returns the unit type which is used for an
+// interface. When the interface is overridden, the type of the override
+// is returned. When several units are registered, the selected unit
+// is returned, then the default unit, then the first one registered
+func (this *graph) unitTypeForInterface(i reflect.Type) reflect.Type {
+	if v, exists := this.overrides[i]; exists {
+		return v.Type()
+	}
+	impls, exists := iface[i]
+	if exists == false || len(impls) == 0 {
+		return nil
+	}
+	if impl := findImpl(impls, this.selected[i]); impl != nil {
+		return impl.t
+	}
+	if impl := findImpl(impls, defaults[i]); impl != nil {
+		return impl.t
+	}
+	return impls[0].t
+}
+
+// defineUnitFlags defines a flag for each interface which has more than
+// one unit registered, which selects the unit
+func (this *graph) defineUnitFlags(cfg gopi.Config) {
+	for _, i := range this.ambiguousInterfaces() {
+		names := make([]string, 0, len(iface[i]))
+		for _, impl := range iface[i] {
+			names = append(names, impl.name)
+		}
+		name := this.unitNameForInterface(i)
+		usage := fmt.Sprintf("Unit for %v (%v)", i, strings.Join(names, ", "))
+		cfg.FlagString(unitFlagPrefix+i.String(), name, usage)
+	}
+}
+
+// checkUnitFlags returns an error if a unit flag value does not match the
+// unit which was used when the graph was created
+func (this *graph) checkUnitFlags(cfg gopi.Config) error {
+	for _, i := range this.ambiguousInterfaces() {
+		key := unitFlagPrefix + i.String()
+		if value := cfg.GetString(key); value != "" && value != this.unitNameForInterface(i) {
+			return gopi.ErrBadParameter.WithPrefix("-", key, ": ", value)
+		}
+	}
+	// Return success
+	return nil
+}
+
+// ambiguousInterfaces returns the interfaces with more than one unit
+// registered, sorted by name
+func (this *graph) ambiguousInterfaces() []reflect.Type {
+	result := []reflect.Type{}
+	for _, i := range this.registeredInterfaces() {
+		if len(iface[i]) > 1 {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// registeredInterfaces returns the interfaces with units registered,
+// sorted by name
+func (this *graph) registeredInterfaces() []reflect.Type {
+	result := make([]reflect.Type, 0, len(iface))
+	for i := range iface {
+		result = append(result, i)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].String() < result[b].String()
+	})
+	return result
+}
+
+// unitNameForInterface returns the name of the unit used for an interface
+func (this *graph) unitNameForInterface(i reflect.Type) string {
+	t := this.unitTypeForInterface(i)
+	for _, impl := range iface[i] {
+		if impl.t == t {
+			return impl.name
+		}
+	}
+	return ""
+}
+
+// overrideForType returns the value which overrides an interface
+// with a type, or false
+func (this *graph) overrideForType(t reflect.Type) (reflect.Value, bool) {
+	for _, v := range this.overrides {
+		if v.Type() == t {
+			return v, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// findImpl returns a unit by name, or nil
+func findImpl(impls []impl, name string) *impl {
+	if name == "" {
+		return nil
+	}
+	for i := range impls {
+		if impls[i].name == name {
+			return &impls[i]
+		}
+	}
+	return nil
+}
+
+// unitName returns the default name for a unit type, which is the
+// name of the package in which it is defined
+func unitName(t reflect.Type) string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.PkgPath() == "" {
+		return ""
+	} else {
+		return path.Base(t.PkgPath())
+	}
+}
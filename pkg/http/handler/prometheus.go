@@ -0,0 +1,479 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Prometheus renders the latest value of each measurement emitted
+// through the publisher in Prometheus text exposition format
+type Prometheus struct {
+	gopi.Unit
+	gopi.Server
+	gopi.Publisher
+	gopi.Logger
+	sync.RWMutex
+
+	runtime *bool
+	expire  *time.Duration
+	version gopi.Version
+	latest  map[string]series
+}
+
+// series is the latest measurement for a name and set of tags, and
+// the time it was received
+type series struct {
+	gopi.Measurement
+	ts time.Time
+}
+
+type prometheus struct {
+	*Prometheus
+}
+
+// family is a set of samples with the same metric name and type
+type family struct {
+	name    string
+	typ     string
+	help    string
+	samples []sample
+}
+
+// sample is a value with labels. The suffix is appended to the family
+// name for histograms and summaries, which have several series
+type sample struct {
+	suffix string
+	labels string
+	value  float64
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+	prometheusGauge       = "gauge"
+	prometheusCounter     = "counter"
+	prometheusHistogram   = "histogram"
+	prometheusSummary     = "summary"
+)
+
+const (
+	DefaultPrometheusExpire = 10 * time.Minute
+)
+
+/////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func (this *Prometheus) Define(cfg gopi.Config) error {
+	this.runtime = cfg.FlagBool("http.runtime", false, "Include Go runtime metrics")
+	this.expire = cfg.FlagDuration("http.expire", DefaultPrometheusExpire, "Remove series which have not been updated within this period, or zero to disable")
+	return nil
+}
+
+func (this *Prometheus) New(cfg gopi.Config) error {
+	this.version = cfg.Version()
+	this.latest = make(map[string]series)
+
+	// Return success
+	return nil
+}
+
+func (this *Prometheus) Run(ctx context.Context) error {
+	if this.Publisher == nil {
+		return gopi.ErrInternalAppError.WithPrefix("Publisher")
+	}
+
+	ch := this.Publisher.SubscribeType((*gopi.Measurement)(nil))
+	defer this.Publisher.Unsubscribe(ch)
+
+	// Remove stale series periodically, so that the number of series
+	// does not grow without bound as tag values change
+	var expire <-chan time.Time
+	if *this.expire > 0 {
+		ticker := time.NewTicker(*this.expire / 2)
+		defer ticker.Stop()
+		expire = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case evt := <-ch:
+			if m, ok := evt.(gopi.Measurement); ok {
+				this.set(m)
+			}
+		case now := <-expire:
+			this.prune(now.Add(-*this.expire))
+		}
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// METHODS
+
+// Serve registers a service which renders metrics at the named path,
+// which is usually /metrics
+func (this *Prometheus) Serve(path string) error {
+	if this.Server == nil {
+		return gopi.ErrInternalAppError.WithPrefix("Serve")
+	} else if path == "" {
+		return gopi.ErrBadParameter.WithPrefix("Serve")
+	}
+	return this.Server.RegisterService(path, &prometheus{this})
+}
+
+// Write renders metrics in text exposition format
+func (this *Prometheus) Write(w io.Writer) error {
+	families := this.families()
+	families = append(families, this.build()...)
+	if *this.runtime {
+		families = append(families, goruntime()...)
+	}
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+func (this *prometheus) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", prometheusContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if req.Method == http.MethodGet {
+		if err := this.Prometheus.Write(w); err != nil {
+			this.Print("Metrics: ", err)
+		}
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *Prometheus) String() string {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	str := "<http.prometheus"
+	str += fmt.Sprint(" series=", len(this.latest))
+	if *this.runtime {
+		str += " runtime=true"
+	}
+	return str + ">"
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// set stores the latest value of a measurement, keyed by name and tags
+func (this *Prometheus) set(m gopi.Measurement) {
+	key := m.Name()
+	for _, tag := range m.Tags() {
+		key += "\x00" + tag.Name() + "=" + fmt.Sprint(tag.Value())
+	}
+
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+	this.latest[key] = series{m, time.Now()}
+}
+
+// prune removes series which were last received before a time
+func (this *Prometheus) prune(before time.Time) {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+	for key, s := range this.latest {
+		if s.ts.Before(before) {
+			delete(this.latest, key)
+		}
+	}
+}
+
+// families returns a family for each metric field in the stored
+// measurements, ordered by name. Fields which are not numeric
+// are ignored, and where the same name has different types the
+// first type is used
+func (this *Prometheus) families() []*family {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	keys := make([]string, 0, len(this.latest))
+	for key := range this.latest {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	index := make(map[string]*family)
+	result := []*family{}
+	for _, key := range keys {
+		m := this.latest[key].Measurement
+		labels := promLabels(m.Tags())
+		for _, field := range m.Metrics() {
+			typ, samples := promSamples(field, labels)
+			if typ == "" {
+				continue
+			}
+			name := promName(m.Name() + "_" + field.Name())
+			if typ == prometheusCounter && strings.HasSuffix(name, "_total") == false {
+				name += "_total"
+			}
+			f, exists := index[name]
+			if exists == false {
+				f = &family{name: name, typ: typ}
+				index[name] = f
+				result = append(result, f)
+			} else if f.typ != typ {
+				continue
+			}
+			f.samples = append(f.samples, samples...)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+	return result
+}
+
+// build returns build and version information
+func (this *Prometheus) build() []*family {
+	if this.version == nil {
+		return nil
+	}
+	tag, branch, hash := this.version.Version()
+	info := &family{name: "gopi_build_info", typ: prometheusGauge, help: "Build and version information"}
+	info.samples = append(info.samples, sample{"", promLabel(map[string]string{
+		"name":      this.version.Name(),
+		"tag":       tag,
+		"branch":    branch,
+		"hash":      hash,
+		"goversion": this.version.GoVersion(),
+	}), 1})
+	result := []*family{info}
+	if ts := this.version.BuildTime(); ts.IsZero() == false {
+		result = append(result, &family{
+			name:    "gopi_build_time_seconds",
+			typ:     prometheusGauge,
+			help:    "Time of process compilation",
+			samples: []sample{{"", "", float64(ts.Unix())}},
+		})
+	}
+	return result
+}
+
+// goruntime returns metrics from the Go runtime
+func goruntime() []*family {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	metric := func(name, typ, help string, value float64) *family {
+		return &family{name: name, typ: typ, help: help, samples: []sample{{"", "", value}}}
+	}
+	return []*family{
+		metric("go_goroutines", prometheusGauge, "Number of goroutines", float64(runtime.NumGoroutine())),
+		metric("go_memstats_alloc_bytes", prometheusGauge, "Bytes allocated and in use", float64(stats.Alloc)),
+		metric("go_memstats_sys_bytes", prometheusGauge, "Bytes obtained from the system", float64(stats.Sys)),
+		metric("go_memstats_heap_objects", prometheusGauge, "Number of allocated objects", float64(stats.HeapObjects)),
+		metric("go_memstats_mallocs_total", prometheusCounter, "Number of mallocs", float64(stats.Mallocs)),
+		metric("go_memstats_frees_total", prometheusCounter, "Number of frees", float64(stats.Frees)),
+		metric("go_gc_cycles_total", prometheusCounter, "Number of completed garbage collection cycles", float64(stats.NumGC)),
+		metric("go_gc_pause_seconds_total", prometheusCounter, "Garbage collection pause time", float64(stats.PauseTotalNs)/float64(time.Second)),
+	}
+}
+
+func (this *family) write(w io.Writer) error {
+	if this.help != "" {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n", this.name, this.help); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "# TYPE %s %s\n", this.name, this.typ); err != nil {
+		return err
+	}
+	for _, s := range this.samples {
+		if _, err := fmt.Fprintf(w, "%s%s%s %s\n", this.name, s.suffix, s.labels, promFloat(s.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promSamples returns the metric type and samples for a field. Counters,
+// histograms and summaries are rendered with their own types, other fields
+// with a single value. Returns an empty type if the field cannot be
+// represented
+func promSamples(field gopi.Field, labels map[string]string) (string, []sample) {
+	switch v := field.Value().(type) {
+	case gopi.MetricCounter:
+		return prometheusCounter, []sample{{"", promLabel(labels), v.Total}}
+	case gopi.MetricHistogram:
+		samples := make([]sample, 0, len(v.Buckets)+3)
+		for _, bucket := range v.Buckets {
+			samples = append(samples, sample{"_bucket", promLabel(promWith(labels, "le", promFloat(bucket.UpperBound))), float64(bucket.Count)})
+		}
+		return prometheusHistogram, append(samples,
+			sample{"_bucket", promLabel(promWith(labels, "le", "+Inf")), float64(v.Count)},
+			sample{"_sum", promLabel(labels), v.Sum},
+			sample{"_count", promLabel(labels), float64(v.Count)},
+		)
+	case gopi.MetricSummary:
+		samples := make([]sample, 0, len(v.Quantiles)+2)
+		for _, q := range v.Quantiles {
+			samples = append(samples, sample{"", promLabel(promWith(labels, "quantile", promFloat(q.Quantile))), q.Value})
+		}
+		return prometheusSummary, append(samples,
+			sample{"_sum", promLabel(labels), v.Sum},
+			sample{"_count", promLabel(labels), float64(v.Count)},
+		)
+	default:
+		if value, typ, ok := promValue(field); ok {
+			return typ, []sample{{"", promLabel(labels), value}}
+		}
+		return "", nil
+	}
+}
+
+// promValue returns the value of a field and the metric type. Numbers,
+// booleans and times are gauges, since only fields defined as counters
+// are known to be monotonic. Returns false if the field cannot be
+// represented
+func promValue(field gopi.Field) (float64, string, bool) {
+	switch v := field.Value().(type) {
+	case uint64:
+		return float64(v), prometheusGauge, true
+	case uint8:
+		return float64(v), prometheusGauge, true
+	case uint16:
+		return float64(v), prometheusGauge, true
+	case uint32:
+		return float64(v), prometheusGauge, true
+	case int8:
+		return float64(v), prometheusGauge, true
+	case int16:
+		return float64(v), prometheusGauge, true
+	case int32:
+		return float64(v), prometheusGauge, true
+	case int64:
+		return float64(v), prometheusGauge, true
+	case float32:
+		return float64(v), prometheusGauge, true
+	case float64:
+		return v, prometheusGauge, true
+	case bool:
+		if v {
+			return 1, prometheusGauge, true
+		} else {
+			return 0, prometheusGauge, true
+		}
+	case time.Time:
+		if v.IsZero() {
+			return 0, "", false
+		}
+		return float64(v.UnixNano()) / float64(time.Second), prometheusGauge, true
+	default:
+		return 0, "", false
+	}
+}
+
+// promLabels returns labels from tags
+func promLabels(tags []gopi.Field) map[string]string {
+	labels := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if tag.IsNil() {
+			continue
+		}
+		labels[tag.Name()] = fmt.Sprint(tag.Value())
+	}
+	return labels
+}
+
+// promWith returns a copy of labels with an additional label
+func promWith(labels map[string]string, key, value string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
+
+// promLabel returns labels ordered by name, or an empty string
+// if there are no labels
+func promLabel(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if name := strings.Replace(promName(key), ":", "_", -1); name != "" {
+			pairs = append(pairs, name+"=\""+promEscape(labels[key])+"\"")
+		}
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// promName replaces characters which are not allowed in metric names
+func promName(name string) string {
+	str := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == ':':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if str != "" && str[0] >= '0' && str[0] <= '9' {
+		str = "_" + str
+	}
+	return str
+}
+
+func promEscape(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return strings.Replace(value, "\n", `\n`, -1)
+}
+
+func promFloat(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,175 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	_ "github.com/djthorpe/gopi/v3/pkg/http"
+	"github.com/djthorpe/gopi/v3/pkg/http/handler"
+	"github.com/djthorpe/gopi/v3/pkg/metrics"
+	"github.com/djthorpe/gopi/v3/pkg/tool"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type PApp struct {
+	gopi.Unit
+	gopi.Server
+	gopi.Metrics
+	*handler.Prometheus
+}
+
+func (this *PApp) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Prometheus_001(t *testing.T) {
+	tool.Test(t, nil, new(PApp), func(app *PApp) {
+		if app.Prometheus == nil {
+			t.Error("nil Prometheus unit")
+		} else {
+			t.Log(app.Prometheus)
+		}
+	})
+}
+
+func Test_Prometheus_002(t *testing.T) {
+	tool.Test(t, nil, new(PApp), func(app *PApp) {
+		host := metrics.NewField("host", `rpi"4`)
+		if _, err := app.Metrics.NewMeasurement("test", "temp float64, sent uint64, on bool, label string", host); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if err := app.Metrics.Emit("test", nil, 21.5, uint64(10), true, "ok"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		var str strings.Builder
+		if err := app.Prometheus.Write(&str); err != nil {
+			t.Fatal(err)
+		}
+		t.Log(str.String())
+		for _, line := range []string{
+			"# TYPE test_temp gauge\n",
+			"test_temp{host=\"rpi\\\"4\"} 21.5\n",
+			"# TYPE test_sent gauge\n",
+			"test_sent{host=\"rpi\\\"4\"} 10\n",
+			"test_on{host=\"rpi\\\"4\"} 1\n",
+			"# TYPE gopi_build_info gauge\n",
+		} {
+			if strings.Contains(str.String(), line) == false {
+				t.Errorf("Missing %q", line)
+			}
+		}
+		if strings.Contains(str.String(), "test_label") {
+			t.Error("Unexpected string field")
+		}
+		if strings.Contains(str.String(), "go_goroutines") {
+			t.Error("Unexpected runtime metrics")
+		}
+	})
+}
+
+func Test_Prometheus_003(t *testing.T) {
+	tool.Test(t, []string{"-http.runtime"}, new(PApp), func(app *PApp) {
+		if err := app.Prometheus.Serve("/metrics"); err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		app.Server.(http.Handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if w.Code != http.StatusOK {
+			t.Error("Unexpected status", w.Code)
+		} else if ct := w.Header().Get("Content-Type"); strings.HasPrefix(ct, "text/plain") == false {
+			t.Error("Unexpected content type", ct)
+		} else if strings.Contains(w.Body.String(), "go_goroutines ") == false {
+			t.Error("Missing runtime metrics", w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		app.Server.(http.Handler).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Error("Unexpected status", w.Code)
+		}
+	})
+}
+
+func Test_Prometheus_004(t *testing.T) {
+	tool.Test(t, nil, new(PApp), func(app *PApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "requests counter, latency histogram(0.1,1), size summary(0.5), level gauge"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if err := app.Metrics.Emit("test", nil, 3, 0.5, 100, 7); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		var str strings.Builder
+		if err := app.Prometheus.Write(&str); err != nil {
+			t.Fatal(err)
+		}
+		t.Log(str.String())
+		for _, line := range []string{
+			"# TYPE test_requests_total counter\n",
+			"test_requests_total 3\n",
+			"# TYPE test_latency histogram\n",
+			"test_latency_bucket{le=\"0.1\"} 0\n",
+			"test_latency_bucket{le=\"1\"} 1\n",
+			"test_latency_bucket{le=\"+Inf\"} 1\n",
+			"test_latency_sum 0.5\n",
+			"test_latency_count 1\n",
+			"# TYPE test_size summary\n",
+			"test_size{quantile=\"0.5\"} 100\n",
+			"test_size_count 1\n",
+			"# TYPE test_level gauge\n",
+			"test_level 7\n",
+		} {
+			if strings.Contains(str.String(), line) == false {
+				t.Errorf("Missing %q", line)
+			}
+		}
+	})
+}
+
+func Test_Prometheus_005(t *testing.T) {
+	tool.Test(t, []string{"-http.expire", "500ms"}, new(PApp), func(app *PApp) {
+		if _, err := app.Metrics.NewMeasurement("test", "value float64", metrics.NewField("id", "")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		for i := 0; i < 10; i++ {
+			if err := app.Metrics.Emit("test", []gopi.Field{metrics.NewField("id", fmt.Sprint(i))}, float64(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		var str strings.Builder
+		if err := app.Prometheus.Write(&str); err != nil {
+			t.Fatal(err)
+		} else if strings.Count(str.String(), "test_value{") != 10 {
+			t.Error("Expected 10 series", str.String())
+		}
+
+		// Series are removed once they have not been updated
+		time.Sleep(time.Second)
+		str.Reset()
+		if err := app.Prometheus.Write(&str); err != nil {
+			t.Fatal(err)
+		} else if strings.Contains(str.String(), "test_value") {
+			t.Error("Unexpected series", str.String())
+		}
+	})
+}
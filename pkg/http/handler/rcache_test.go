@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/djthorpe/gopi/v3"
+	_ "github.com/djthorpe/gopi/v3/pkg/http"
 	"github.com/djthorpe/gopi/v3/pkg/http/handler"
 	"github.com/djthorpe/gopi/v3/pkg/http/renderer"
 	"github.com/djthorpe/gopi/v3/pkg/tool"
@@ -17,6 +18,7 @@ import (
 type RApp struct {
 	gopi.Unit
 	*handler.RenderCache
+	*renderer.HttpTextRenderer
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -41,7 +43,7 @@ func Test_rcache_001(t *testing.T) {
 
 func Test_rcache_002(t *testing.T) {
 	tool.Test(t, nil, new(RApp), func(app *RApp) {
-		if err := app.RenderCache.Register(renderer.NewTextRenderer(CONTENT, "page.tmpl")); err != nil {
+		if err := app.RenderCache.Register(app.HttpTextRenderer); err != nil {
 			t.Error(err)
 		} else {
 			t.Log(app.RenderCache)
@@ -51,12 +53,11 @@ func Test_rcache_002(t *testing.T) {
 
 func Test_rcache_003(t *testing.T) {
 	tool.Test(t, nil, new(RApp), func(app *RApp) {
-		app.RenderCache.Register(renderer.NewTextRenderer(CONTENT, "page.tmpl"))
 		for i := 0; i < 2000; i++ {
 			url := fmt.Sprint("http://localhost/page.tmpl?", i)
 			if req, err := http.NewRequest(http.MethodGet, url, nil); err != nil {
 				t.Error(err)
-			} else if r := app.RenderCache.Get(req); r == nil {
+			} else if r := app.RenderCache.Get(CONTENT, req); r == nil {
 				t.Error("Expected renderer for", url)
 			} else {
 				t.Log(url, "=>", r)
@@ -67,14 +68,13 @@ func Test_rcache_003(t *testing.T) {
 
 func Test_rcache_004(t *testing.T) {
 	tool.Test(t, nil, new(RApp), func(app *RApp) {
-		app.RenderCache.Register(renderer.NewTextRenderer(CONTENT, "page.tmpl"))
 		for i := 0; i < 2000; i++ {
 			url := fmt.Sprint("http://localhost/page.tmpl?", i)
 			if req, err := http.NewRequest(http.MethodGet, url, nil); err != nil {
 				t.Error(err)
-			} else if r := app.RenderCache.Get(req); r == nil {
+			} else if r := app.RenderCache.Get(CONTENT, req); r == nil {
 				t.Error("Expected renderer for", url)
-			} else if ctx, err := app.RenderCache.Render(r, req); err != nil {
+			} else if ctx, err := app.RenderCache.Render(CONTENT, r, req); err != nil {
 				t.Error(r)
 			} else if ctx.Content == nil {
 				t.Error("Expected content to be returned")
@@ -51,48 +51,18 @@ func (this *TemplateCache) New(gopi.Config) error {
 		return nil
 	}
 
-	// Check folder argument
-	if stat, err := os.Stat(*this.folder); os.IsNotExist(err) {
-		return gopi.ErrNotFound.WithPrefix(*this.folder)
-	} else if err != nil {
-		return gopi.ErrBadParameter.WithPrefix(*this.folder)
-	} else if stat.IsDir() == false {
-		return gopi.ErrBadParameter.WithPrefix(*this.folder)
-	}
-
-	// Read all templates in from a folder, every template needs to
-	// be parsed without error
-	files, err := ioutil.ReadDir(*this.folder)
-	if err != nil {
-		return err
-	}
-
-	// Create cache of templates
-	this.t = make(map[string]tcached, len(files))
+	// Read templates
+	return this.load()
+}
 
-	// Read templates into cache. Collect parse errors
-	// into results
-	var result error
-	for _, file := range files {
-		if strings.HasPrefix(file.Name(), ".") {
-			continue
-		}
-		if file.Mode().IsRegular() == false {
-			continue
-		}
-		path := filepath.Join(*this.folder, file.Name())
-		if tmpl, err := template.New(file.Name()).Funcs(this.funcmap()).ParseFiles(path); err != nil {
-			result = multierror.Append(result, err)
-		} else {
-			tmpl = tmpl.Funcs(this.funcmap())
-			key := tmpl.Name()
-			this.t[key] = tcached{file, tmpl}
-			this.Debugf("Parsed template: %q", tmpl.Name())
-		}
+// Reload re-reads all templates from the folder, which may have been
+// changed in the configuration. Templates are only replaced if every
+// template is parsed without error
+func (this *TemplateCache) Reload(gopi.Config) error {
+	if *this.folder == "" {
+		return nil
 	}
-
-	// Return any errors
-	return result
+	return this.load()
 }
 
 /////////////////////////////////////////////////////////////////////
@@ -157,6 +127,61 @@ func (this *TemplateCache) String() string {
 /////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// load reads all templates in from a folder
+func (this *TemplateCache) load() error {
+	// Check folder argument
+	if stat, err := os.Stat(*this.folder); os.IsNotExist(err) {
+		return gopi.ErrNotFound.WithPrefix(*this.folder)
+	} else if err != nil {
+		return gopi.ErrBadParameter.WithPrefix(*this.folder)
+	} else if stat.IsDir() == false {
+		return gopi.ErrBadParameter.WithPrefix(*this.folder)
+	}
+
+	// Read all templates in from a folder, every template needs to
+	// be parsed without error
+	files, err := ioutil.ReadDir(*this.folder)
+	if err != nil {
+		return err
+	}
+
+	// Create cache of templates
+	t := make(map[string]tcached, len(files))
+
+	// Read templates into cache. Collect parse errors
+	// into results
+	var result error
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		if file.Mode().IsRegular() == false {
+			continue
+		}
+		path := filepath.Join(*this.folder, file.Name())
+		if tmpl, err := template.New(file.Name()).Funcs(this.funcmap()).ParseFiles(path); err != nil {
+			result = multierror.Append(result, err)
+		} else {
+			tmpl = tmpl.Funcs(this.funcmap())
+			key := tmpl.Name()
+			t[key] = tcached{file, tmpl}
+			this.Debugf("Parsed template: %q", tmpl.Name())
+		}
+	}
+
+	// Replace the cache if there were no errors
+	if result != nil {
+		return result
+	}
+
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+	this.t = t
+
+	// Return success
+	return nil
+}
+
 func (this *TemplateCache) get(name string) tcached {
 	this.RWMutex.RLock()
 	defer this.RWMutex.RUnlock()
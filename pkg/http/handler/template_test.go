@@ -17,7 +17,9 @@ import (
 
 type TApp struct {
 	gopi.Unit
+	gopi.Server
 	*handler.Templates
+	*renderer.HttpTextRenderer
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -38,12 +40,8 @@ func Test_templates_001(t *testing.T) {
 
 func Test_templates_002(t *testing.T) {
 	tool.Test(t, []string{"-http.templates", TEMPLATES}, new(TApp), func(app *TApp) {
-		// Register a text renderer
-		if err := app.Templates.RegisterRenderer(renderer.NewTextRenderer(CONTENT, "page.tmpl")); err != nil {
-			t.Error(err)
-		}
 		// Serve content from /
-		if err := app.Templates.Serve("/"); err != nil {
+		if err := app.Templates.Serve("/", CONTENT); err != nil {
 			t.Error(err)
 		}
 		// Start Server
@@ -69,12 +67,8 @@ func Test_templates_002(t *testing.T) {
 
 func Test_templates_003(t *testing.T) {
 	tool.Test(t, []string{"-http.templates", TEMPLATES}, new(TApp), func(app *TApp) {
-		// Register a text renderer
-		if err := app.Templates.RegisterRenderer(renderer.NewTextRenderer(CONTENT, "page.tmpl")); err != nil {
-			t.Error(err)
-		}
 		// Serve content from /
-		if err := app.Templates.Serve("/"); err != nil {
+		if err := app.Templates.Serve("/", CONTENT); err != nil {
 			t.Error(err)
 		}
 		// Start Server
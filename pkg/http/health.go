@@ -0,0 +1,67 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// health serves the aggregated health of units as JSON, with status
+// 200 when all units are healthy and 503 otherwise
+type health struct {
+	fn func() map[string]error
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Units  map[string]string `json:"units"`
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	healthPath = "/healthz"
+)
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// RegisterHealth registers the /healthz endpoint with a function
+// which returns the health of each unit
+func (this *Server) RegisterHealth(fn func() map[string]error) error {
+	if fn == nil {
+		return gopi.ErrBadParameter.WithPrefix("RegisterHealth")
+	}
+	return this.RegisterService(healthPath, &health{fn})
+}
+
+func (this *health) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	code := http.StatusOK
+	response := healthResponse{"ok", make(map[string]string)}
+	for name, err := range this.fn() {
+		if err == nil {
+			response.Units[name] = "ok"
+		} else {
+			response.Units[name] = err.Error()
+			response.Status = "error"
+			code = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(code)
+	if req.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(response)
+	}
+}
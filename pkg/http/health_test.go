@@ -0,0 +1,86 @@
+package http_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/djthorpe/gopi/v3"
+	server "github.com/djthorpe/gopi/v3/pkg/http"
+	"github.com/djthorpe/gopi/v3/pkg/tool"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type HealthApp struct {
+	gopi.Unit
+	*server.Server
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Units  map[string]string `json:"units"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Health_001(t *testing.T) {
+	tool.Test(t, nil, new(HealthApp), func(app *HealthApp) {
+		units := map[string]error{"a": nil, "b": nil}
+		if err := app.Server.RegisterHealth(nil); errors.Is(err, gopi.ErrBadParameter) == false {
+			t.Error("Expected bad parameter error, got:", err)
+		} else if err := app.Server.RegisterHealth(func() map[string]error { return units }); err != nil {
+			t.Fatal(err)
+		}
+
+		// All units are healthy
+		if code, body := getHealth(t, app.Server, http.MethodGet); code != http.StatusOK {
+			t.Error("Unexpected status code:", code)
+		} else if body.Status != "ok" || len(body.Units) != 2 || body.Units["a"] != "ok" || body.Units["b"] != "ok" {
+			t.Error("Unexpected response:", body)
+		}
+
+		// One unit is unhealthy
+		units["b"] = gopi.ErrUnexpectedResponse
+		if code, body := getHealth(t, app.Server, http.MethodGet); code != http.StatusServiceUnavailable {
+			t.Error("Unexpected status code:", code)
+		} else if body.Status != "error" || body.Units["a"] != "ok" || body.Units["b"] != gopi.ErrUnexpectedResponse.Error() {
+			t.Error("Unexpected response:", body)
+		}
+
+		// HEAD returns the status code without a body, and other
+		// methods are not allowed
+		if code, _ := getHealth(t, app.Server, http.MethodHead); code != http.StatusServiceUnavailable {
+			t.Error("Unexpected status code:", code)
+		}
+		if code, _ := getHealth(t, app.Server, http.MethodPost); code != http.StatusMethodNotAllowed {
+			t.Error("Unexpected status code:", code)
+		}
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// getHealth makes a request for the health endpoint, and returns the
+// status code and decoded body for GET requests
+func getHealth(t *testing.T, handler http.Handler, method string) (int, healthResponse) {
+	t.Helper()
+	var body healthResponse
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(method, "/healthz", nil))
+	if method == http.MethodGet {
+		if w.Header().Get("Content-Type") != "application/json" {
+			t.Error("Unexpected content type:", w.Header().Get("Content-Type"))
+		} else if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+	} else if method == http.MethodHead && w.Body.Len() != 0 {
+		t.Error("Unexpected body for HEAD request:", w.Body.String())
+	}
+	return w.Code, body
+}
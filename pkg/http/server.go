@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -33,6 +34,8 @@ type Server struct {
 	mux        *http.ServeMux
 	timeout    *time.Duration
 	handler    http.Handler
+	tlscert    *tls.Certificate
+	certlock   sync.RWMutex // Additional lock just for tlscert value
 }
 
 type Transport interface {
@@ -58,6 +61,8 @@ func (this *Server) New(cfg gopi.Config) error {
 			return fmt.Errorf("Invalid SSL certificate")
 		} else if _, err := os.Stat(*this.key); os.IsNotExist(err) {
 			return fmt.Errorf("Invalid SSL private key")
+		} else if err := this.loadCertificate(*this.cert, *this.key); err != nil {
+			return err
 		} else {
 			this.ssl = true
 		}
@@ -93,6 +98,18 @@ func (this *Server) Dispose() error {
 	return result
 }
 
+// Reload replaces the SSL certificate and key with the files named in
+// the configuration. SSL cannot be enabled or disabled without restarting
+func (this *Server) Reload(gopi.Config) error {
+	if this.ssl == false && *this.cert == "" && *this.key == "" {
+		return nil
+	} else if this.ssl == false || *this.cert == "" || *this.key == "" {
+		return gopi.ErrOutOfOrder.WithPrefix("Reload: SSL cannot be changed without restarting")
+	} else {
+		return this.loadCertificate(*this.cert, *this.key)
+	}
+}
+
 /////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
@@ -203,6 +220,9 @@ func (this *Server) StartInBackground(network, addr string) error {
 			WriteTimeout:      *this.timeout,
 			IdleTimeout:       *this.timeout,
 		}
+		if this.ssl {
+			this.httpserver.TLSConfig = &tls.Config{GetCertificate: this.getCertificate}
+		}
 	}
 
 	// Timeout for server is 500ms
@@ -215,7 +235,7 @@ func (this *Server) StartInBackground(network, addr string) error {
 		if this.fcgiserver != nil {
 			result = this.fcgiserver.ListenAndServe()
 		} else if this.ssl {
-			result = this.httpserver.ListenAndServeTLS(*this.cert, *this.key)
+			result = this.httpserver.ListenAndServeTLS("", "")
 		} else {
 			result = this.httpserver.ListenAndServe()
 		}
@@ -344,3 +364,23 @@ func (this *Server) String() string {
 	}
 	return str + ">"
 }
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *Server) loadCertificate(cert, key string) error {
+	if pair, err := tls.LoadX509KeyPair(cert, key); err != nil {
+		return err
+	} else {
+		this.certlock.Lock()
+		defer this.certlock.Unlock()
+		this.tlscert = &pair
+	}
+	return nil
+}
+
+func (this *Server) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	this.certlock.RLock()
+	defer this.certlock.RUnlock()
+	return this.tlscert, nil
+}
@@ -19,6 +19,14 @@ type App struct {
 	gopi.HttpTemplate
 }
 
+type RendererApp struct {
+	gopi.Unit
+	gopi.Server
+	gopi.HttpTemplate
+	Text  *renderer.HttpTextRenderer
+	Index *renderer.HttpIndexRenderer
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // CONSTANTS
 
@@ -54,14 +62,10 @@ func Test_Server_002(t *testing.T) {
 }
 
 func Test_Server_003(t *testing.T) {
-	tool.Test(t, []string{"-http.templates", HTTP_ROOT}, new(App), func(app *App) {
+	tool.Test(t, []string{"-http.templates", HTTP_ROOT}, new(RendererApp), func(app *RendererApp) {
 		if err := app.Server.StartInBackground("tcp", ":0"); err != nil {
 			t.Error(err)
-		} else if err := app.HttpTemplate.RegisterRenderer(renderer.NewTextRenderer(HTTP_ROOT, "page.tmpl")); err != nil {
-			t.Error(err)
-		} else if err := app.HttpTemplate.RegisterRenderer(renderer.NewIndexRenderer(HTTP_ROOT, "index.tmpl")); err != nil {
-			t.Error(err)
-		} else if err := app.HttpTemplate.Serve("/"); err != nil {
+		} else if err := app.HttpTemplate.Serve("/", HTTP_ROOT); err != nil {
 			t.Error(err)
 		}
 
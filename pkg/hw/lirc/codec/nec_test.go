@@ -0,0 +1,66 @@
+package codec_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/hw/lirc/codec"
+	"github.com/djthorpe/gopi/v3/pkg/tool"
+
+	_ "github.com/djthorpe/gopi/v3/pkg/event/record"
+)
+
+type App struct {
+	gopi.Unit
+	gopi.Publisher
+	gopi.EventRecorder
+}
+
+func (app *App) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Replay a recorded NEC keypress and repeat code
+func Test_NEC_001(t *testing.T) {
+	tool.Test(t, nil, new(App), func(app *App) {
+		fh, err := os.Open("testdata/nec.jsonl")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fh.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		ch := app.Publisher.SubscribeType(&codec.CodecEvent{})
+		defer app.Publisher.Unsubscribe(ch)
+
+		// Run the codec and replay the events
+		nec := codec.NewNEC(gopi.INPUT_DEVICE_NEC_32)
+		go nec.Run(ctx, app.Publisher)
+		time.Sleep(100 * time.Millisecond)
+		if err := app.EventRecorder.Replay(ctx, fh, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check for keypress and repeat
+		for _, action := range []gopi.InputType{gopi.INPUT_EVENT_KEYPRESS, gopi.INPUT_EVENT_KEYREPEAT} {
+			select {
+			case evt := <-ch:
+				if evt := evt.(*codec.CodecEvent); evt.Type != action {
+					t.Error("Unexpected event", evt)
+				} else if evt.Code != 0xFFEF {
+					t.Error("Unexpected code", evt)
+				} else {
+					t.Log(evt)
+				}
+			case <-ctx.Done():
+				t.Fatal("Timeout waiting for", action)
+			}
+		}
+	})
+}
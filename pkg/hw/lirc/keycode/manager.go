@@ -57,25 +57,11 @@ func (this *Manager) New(cfg gopi.Config) error {
 	if this.Publisher == nil {
 		return gopi.ErrBadParameter.WithPrefix("Publisher")
 	}
-	if ext := "." + strings.Trim(strings.TrimSpace(*this.ext), "."); ext == "." {
-		return gopi.ErrBadParameter.WithPrefix("-lirc.ext")
-	} else if *this.folder != "" {
-		if stat, err := os.Stat(*this.folder); os.IsNotExist(err) {
-			if folder, err := filepath.Abs(*this.folder); err != nil {
-				return gopi.ErrBadParameter.WithPrefix(*this.folder)
-			} else {
-				return gopi.ErrBadParameter.WithPrefix(folder)
-			}
-		} else if err != nil {
-			return err
-		} else if stat.IsDir() == false {
-			return gopi.ErrBadParameter.WithPrefix(*this.folder)
-		} else if db, err := this.readAll(*this.folder, ext); err != nil {
-			return err
-		} else {
-			this.db = db
-			*this.ext = ext
-		}
+	if db, ext, err := this.readFolder(); err != nil {
+		return err
+	} else {
+		this.db = db
+		*this.ext = ext
 	}
 
 	// Add codecs
@@ -98,6 +84,27 @@ func (this *Manager) Dispose() error {
 	return nil
 }
 
+// Reload writes any modified databases and then reads the databases
+// again, from the folder set in the configuration
+func (this *Manager) Reload(gopi.Config) error {
+	if err := this.writeDirty(); err != nil {
+		return err
+	}
+
+	db, ext, err := this.readFolder()
+	if err != nil {
+		return err
+	}
+
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	this.db = db
+	*this.ext = ext
+
+	// Return success
+	return nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // RUN
 
@@ -277,6 +284,33 @@ func (this *Manager) writeDirty() error {
 	return result
 }
 
+// readFolder checks the folder and extension flags, and reads the
+// databases from the folder, if set. It returns the databases and the
+// extension for database files
+func (this *Manager) readFolder() ([]*keycodedb, string, error) {
+	ext := "." + strings.Trim(strings.TrimSpace(*this.ext), ".")
+	if ext == "." {
+		return nil, "", gopi.ErrBadParameter.WithPrefix("-lirc.ext")
+	} else if *this.folder == "" {
+		return nil, ext, nil
+	}
+	if stat, err := os.Stat(*this.folder); os.IsNotExist(err) {
+		if folder, err := filepath.Abs(*this.folder); err != nil {
+			return nil, "", gopi.ErrBadParameter.WithPrefix(*this.folder)
+		} else {
+			return nil, "", gopi.ErrBadParameter.WithPrefix(folder)
+		}
+	} else if err != nil {
+		return nil, "", err
+	} else if stat.IsDir() == false {
+		return nil, "", gopi.ErrBadParameter.WithPrefix(*this.folder)
+	} else if db, err := this.readAll(*this.folder, ext); err != nil {
+		return nil, "", err
+	} else {
+		return db, ext, nil
+	}
+}
+
 func (this *Manager) readAll(path, ext string) ([]*keycodedb, error) {
 	dbs := []*keycodedb{}
 
@@ -0,0 +1,166 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// entry is a single log message
+type entry struct {
+	time   time.Time
+	level  gopi.LogLevel
+	name   string
+	msg    string
+	fields []interface{}
+}
+
+// formatter encodes an entry as a line of output
+type formatter func(*entry) []byte
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	formatText   = "text"
+	formatLogfmt = "logfmt"
+	formatJSON   = "json"
+)
+
+const (
+	// Key for field when a value is provided without a key
+	keyMissing = "!BADKEY"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func newFormatter(format string) (formatter, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case formatText:
+		return func(e *entry) []byte { return encodeText(e, true) }, nil
+	case formatLogfmt:
+		return encodeLogfmt, nil
+	case formatJSON:
+		return encodeJSON, nil
+	default:
+		return nil, gopi.ErrBadParameter.WithPrefix("-log.format: ", format)
+	}
+}
+
+// encodeText returns a line of the form:
+//   15:04:05 INFO name: message key=value
+func encodeText(e *entry, withTime bool) []byte {
+	var b bytes.Buffer
+	if withTime {
+		b.WriteString(e.time.Format("15:04:05 "))
+	}
+	b.WriteString(strings.ToUpper(e.level.String()))
+	b.WriteByte(' ')
+	if e.name != "" {
+		b.WriteString(e.name)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.msg)
+	e.eachField(func(k string, v interface{}) {
+		b.WriteByte(' ')
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(v))
+	})
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// encodeLogfmt returns a line of the form:
+//   time=2006-01-02T15:04:05Z level=info name=name msg="message" key=value
+func encodeLogfmt(e *entry) []byte {
+	var b bytes.Buffer
+	b.WriteString("time=" + e.time.Format(time.RFC3339Nano))
+	b.WriteString(" level=" + e.level.String())
+	if e.name != "" {
+		b.WriteString(" name=" + logfmtValue(e.name))
+	}
+	b.WriteString(" msg=" + logfmtValue(e.msg))
+	e.eachField(func(k string, v interface{}) {
+		b.WriteString(" " + k + "=" + logfmtValue(v))
+	})
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// encodeJSON returns a JSON object on a single line, with the time,
+// level, name and message followed by the fields in order
+func encodeJSON(e *entry) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"time":` + strconv.Quote(e.time.Format(time.RFC3339Nano)))
+	b.WriteString(`,"level":` + strconv.Quote(e.level.String()))
+	if e.name != "" {
+		b.WriteString(`,"name":` + jsonValue(e.name))
+	}
+	b.WriteString(`,"msg":` + jsonValue(e.msg))
+	e.eachField(func(k string, v interface{}) {
+		b.WriteString("," + jsonValue(k) + ":" + jsonValue(v))
+	})
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// eachField calls a function for each key-value pair. A value without
+// a key, or with a key which is not a string, is given a placeholder key
+func (e *entry) eachField(fn func(string, interface{})) {
+	for i := 0; i < len(e.fields); i += 2 {
+		if i+1 == len(e.fields) {
+			fn(keyMissing, e.fields[i])
+		} else if k, ok := e.fields[i].(string); ok && k != "" {
+			fn(k, e.fields[i+1])
+		} else {
+			fn(keyMissing, e.fields[i])
+			i--
+		}
+	}
+}
+
+// logfmtValue returns a value which is quoted if it is empty or
+// contains spaces, quotes or equals signs
+func logfmtValue(v interface{}) string {
+	str := stringValue(v)
+	if str == "" || strings.ContainsAny(str, " =\"\t\r\n") {
+		return strconv.Quote(str)
+	} else {
+		return str
+	}
+}
+
+func jsonValue(v interface{}) string {
+	switch v.(type) {
+	case error, fmt.Stringer:
+		v = stringValue(v)
+	}
+	if data, err := json.Marshal(v); err != nil {
+		return strconv.Quote(fmt.Sprint(v))
+	} else {
+		return string(data)
+	}
+}
+
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
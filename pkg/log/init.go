@@ -9,4 +9,5 @@ import (
 
 func init() {
 	graph.RegisterUnit(reflect.TypeOf(&Log{}), reflect.TypeOf((*gopi.Logger)(nil)))
+	graph.RegisterUnit(reflect.TypeOf(&Log{}), reflect.TypeOf((*gopi.LevelLogger)(nil)))
 }
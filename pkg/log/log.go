@@ -2,9 +2,11 @@ package log
 
 import (
 	"fmt"
-	"log"
+	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/djthorpe/gopi/v3"
 )
@@ -14,76 +16,107 @@ type Log struct {
 	gopi.Unit
 
 	// Flags
-	debug *bool
+	debug    *bool
+	level    *string
+	format   *string
+	sink     *string
+	path     *string
+	maxsize  *uint
+	maxfiles *uint
+
 	t     *testing.T
+	lvl   gopi.LogLevel
+	fmtfn formatter
+	out   sink
+}
+
+// logger adds a name prefix and key-value fields to messages
+type logger struct {
+	log    *Log
+	name   string
+	fields []interface{}
 }
 
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	DefaultMaxSize  = 10 // Megabytes before a log file is rotated
+	DefaultMaxFiles = 5  // Number of rotated log files kept
+)
+
 ///////////////////////////////////////////////////////////////////////////////
 // Implement gopi.Unit
 
 func (this *Log) Define(cfg gopi.Config) error {
 	this.debug = cfg.FlagBool("debug", false, "Set debugging flag")
+	this.level = cfg.FlagString("log.level", "info", "Log level (error, warn, info, debug, trace)")
+	this.format = cfg.FlagString("log.format", formatText, "Log format (text, logfmt, json)")
+	this.sink = cfg.FlagString("log.sink", sinkStderr, "Log output (stderr, file, syslog, journald)")
+	this.path = cfg.FlagString("log.file", "", "Path to log file, for file output")
+	this.maxsize = cfg.FlagUint("log.maxsize", DefaultMaxSize, "Size of log file in megabytes before rotation")
+	this.maxfiles = cfg.FlagUint("log.maxfiles", DefaultMaxFiles, "Number of rotated log files kept")
 	return nil
 }
 
-func (*Log) New(gopi.Config) error {
-	log.SetFlags(log.Ltime)
+func (this *Log) New(cfg gopi.Config) error {
+	this.Lock()
+	defer this.Unlock()
+
+	// Set level, format and output
+	if level, err := parseLevel(*this.level); err != nil {
+		return err
+	} else {
+		this.lvl = level
+	}
+	if fn, err := newFormatter(*this.format); err != nil {
+		return err
+	} else {
+		this.fmtfn = fn
+	}
+	if out, err := this.newSink(*this.sink, cfg.Version().Name()); err != nil {
+		return err
+	} else {
+		this.out = out
+	}
+
+	// Return success
 	return nil
 }
 
-///////////////////////////////////////////////////////////////////////////////
-// IMPLEMENTATION
-
-func (this *Log) Print(args ...interface{}) {
+func (this *Log) Dispose() error {
 	this.Lock()
 	defer this.Unlock()
-	if this.t != nil {
-		this.t.Log(args...)
-	} else {
-		log.Print(args...)
+
+	var result error
+	if this.out != nil {
+		result = this.out.Close()
 	}
+	this.out = nil
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// IMPLEMENTATION gopi.Logger
+
+func (this *Log) Print(args ...interface{}) {
+	this.write(gopi.LOG_INFO, "", nil, fmt.Sprint(args...))
 }
 
 func (this *Log) IsDebug() bool {
-	if this.debug == nil {
-		return false
-	} else {
-		return *this.debug
-	}
+	return this.IsLevel(gopi.LOG_DEBUG)
 }
 
 func (this *Log) Debug(args ...interface{}) {
-	if this.IsDebug() {
-		this.Lock()
-		defer this.Unlock()
-		if this.t != nil {
-			this.t.Log(args...)
-		} else {
-			log.Print(args...)
-		}
-	}
+	this.write(gopi.LOG_DEBUG, "", nil, fmt.Sprint(args...))
 }
 
-func (this *Log) Printf(fmt string, args ...interface{}) {
-	this.Lock()
-	defer this.Unlock()
-	if this.t != nil {
-		this.t.Logf(fmt, args...)
-	} else {
-		log.Printf(fmt, args...)
-	}
+func (this *Log) Printf(format string, args ...interface{}) {
+	this.write(gopi.LOG_INFO, "", nil, fmt.Sprintf(format, args...))
 }
 
-func (this *Log) Debugf(fmt string, args ...interface{}) {
-	if this.IsDebug() {
-		this.Lock()
-		defer this.Unlock()
-		if this.t != nil {
-			this.t.Logf(fmt, args...)
-		} else {
-			log.Printf(fmt, args...)
-		}
-	}
+func (this *Log) Debugf(format string, args ...interface{}) {
+	this.write(gopi.LOG_DEBUG, "", nil, fmt.Sprintf(format, args...))
 }
 
 func (this *Log) T() *testing.T {
@@ -97,6 +130,147 @@ func (this *Log) SetT(t *testing.T) {
 	*this.debug = true
 }
 
+///////////////////////////////////////////////////////////////////////////////
+// IMPLEMENTATION gopi.LevelLogger
+
+func (this *Log) Log(level gopi.LogLevel, msg string, kv ...interface{}) {
+	this.write(level, "", kv, msg)
+}
+
+func (this *Log) Error(msg string, kv ...interface{}) {
+	this.write(gopi.LOG_ERROR, "", kv, msg)
+}
+
+func (this *Log) Warn(msg string, kv ...interface{}) {
+	this.write(gopi.LOG_WARN, "", kv, msg)
+}
+
+func (this *Log) Info(msg string, kv ...interface{}) {
+	this.write(gopi.LOG_INFO, "", kv, msg)
+}
+
+func (this *Log) Trace(msg string, kv ...interface{}) {
+	this.write(gopi.LOG_TRACE, "", kv, msg)
+}
+
+// IsLevel returns true if messages at a level are output. The debug
+// flag sets the level to at least debug
+func (this *Log) IsLevel(level gopi.LogLevel) bool {
+	return level != gopi.LOG_NONE && level <= this.Level()
+}
+
+// Level returns the level at which messages are output
+func (this *Log) Level() gopi.LogLevel {
+	level := this.lvl
+	if level == gopi.LOG_NONE {
+		level = gopi.LOG_INFO
+	}
+	if this.debug != nil && *this.debug && level < gopi.LOG_DEBUG {
+		level = gopi.LOG_DEBUG
+	}
+	return level
+}
+
+func (this *Log) With(kv ...interface{}) gopi.LevelLogger {
+	return &logger{this, "", kv}
+}
+
+func (this *Log) Named(name string) gopi.LevelLogger {
+	return &logger{this, name, nil}
+}
+
+func (this *logger) Print(args ...interface{}) {
+	this.log.write(gopi.LOG_INFO, this.name, this.fields, fmt.Sprint(args...))
+}
+
+func (this *logger) Debug(args ...interface{}) {
+	this.log.write(gopi.LOG_DEBUG, this.name, this.fields, fmt.Sprint(args...))
+}
+
+func (this *logger) Printf(format string, args ...interface{}) {
+	this.log.write(gopi.LOG_INFO, this.name, this.fields, fmt.Sprintf(format, args...))
+}
+
+func (this *logger) Debugf(format string, args ...interface{}) {
+	this.log.write(gopi.LOG_DEBUG, this.name, this.fields, fmt.Sprintf(format, args...))
+}
+
+func (this *logger) IsDebug() bool {
+	return this.log.IsDebug()
+}
+
+func (this *logger) IsLevel(level gopi.LogLevel) bool {
+	return this.log.IsLevel(level)
+}
+
+func (this *logger) T() *testing.T {
+	return this.log.T()
+}
+
+func (this *logger) Log(level gopi.LogLevel, msg string, kv ...interface{}) {
+	this.log.write(level, this.name, append(this.fields[:len(this.fields):len(this.fields)], kv...), msg)
+}
+
+func (this *logger) Error(msg string, kv ...interface{}) {
+	this.Log(gopi.LOG_ERROR, msg, kv...)
+}
+
+func (this *logger) Warn(msg string, kv ...interface{}) {
+	this.Log(gopi.LOG_WARN, msg, kv...)
+}
+
+func (this *logger) Info(msg string, kv ...interface{}) {
+	this.Log(gopi.LOG_INFO, msg, kv...)
+}
+
+func (this *logger) Trace(msg string, kv ...interface{}) {
+	this.Log(gopi.LOG_TRACE, msg, kv...)
+}
+
+func (this *logger) With(kv ...interface{}) gopi.LevelLogger {
+	return &logger{this.log, this.name, append(this.fields[:len(this.fields):len(this.fields)], kv...)}
+}
+
+func (this *logger) Named(name string) gopi.LevelLogger {
+	if this.name != "" {
+		name = this.name + "." + name
+	}
+	return &logger{this.log, name, this.fields}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// write formats and outputs a message if the level is enabled. When
+// testing, messages are written to the test log
+func (this *Log) write(level gopi.LogLevel, name string, kv []interface{}, msg string) {
+	if this.IsLevel(level) == false {
+		return
+	}
+
+	this.Lock()
+	defer this.Unlock()
+
+	e := &entry{time.Now(), level, name, msg, kv}
+	if this.t != nil {
+		this.t.Log(strings.TrimSuffix(string(encodeText(e, false)), "\n"))
+	} else if this.out == nil {
+		os.Stderr.Write(encodeText(e, true))
+	} else if err := this.out.Write(e, this.fmtfn); err != nil {
+		fmt.Fprintln(os.Stderr, "Log:", err)
+	}
+}
+
+func parseLevel(value string) (gopi.LogLevel, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for level := gopi.LOG_ERROR; level <= gopi.LOG_TRACE; level++ {
+		if level.String() == value {
+			return level, nil
+		}
+	}
+	return gopi.LOG_NONE, gopi.ErrBadParameter.WithPrefix("-log.level: ", value)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
@@ -105,9 +279,24 @@ func (this *Log) String() string {
 	if this == nil {
 		str += " nil"
 	} else {
-		if this.debug != nil {
-			str += " debug=" + fmt.Sprint(*this.debug)
+		str += " level=" + fmt.Sprint(this.Level())
+		if this.format != nil {
+			str += " format=" + *this.format
 		}
+		if this.sink != nil {
+			str += " sink=" + *this.sink
+		}
+	}
+	return str + ">"
+}
+
+func (this *logger) String() string {
+	str := "<log"
+	if this.name != "" {
+		str += " name=" + this.name
+	}
+	if len(this.fields) > 0 {
+		str += " fields=" + fmt.Sprint(this.fields)
 	}
 	return str + ">"
 }
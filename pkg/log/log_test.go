@@ -1,9 +1,17 @@
 package log_test
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/config"
+	"github.com/djthorpe/gopi/v3/pkg/graph"
 	"github.com/djthorpe/gopi/v3/pkg/log"
 )
 
@@ -12,6 +20,112 @@ type app struct {
 	*log.Log
 }
 
+type unitapp struct {
+	gopi.Unit
+	gopi.Logger
+	*LoggerUnit `gopi:"name=amp"`
+}
+
+type LoggerUnit struct {
+	gopi.Unit
+	gopi.LevelLogger
+}
+
 func Test_Log_000(t *testing.T) {
 	t.Log("Test_Log_000")
 }
+
+func Test_Log_001(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	tests := []struct {
+		format string
+		expect string
+	}{
+		{"text", "WARN rotel: message key=value err=\"not found\" !BADKEY=extra\n"},
+		{"logfmt", " level=warn name=rotel msg=message key=value err=\"not found\" !BADKEY=extra\n"},
+		{"json", `,"level":"warn","name":"rotel","msg":"message","key":"value","err":"not found","!BADKEY":"extra"}` + "\n"},
+	}
+	for _, test := range tests {
+		os.Remove(path)
+		logger := newLog(t, "-log.format", test.format, "-log.sink", "file", "-log.file", path)
+		logger.Named("rotel").With("key", "value").Warn("message", "err", errors.New("not found"), "extra")
+		logger.Named("rotel").Debug("not output")
+		if err := logger.Dispose(); err != nil {
+			t.Fatal(err)
+		} else if data, err := ioutil.ReadFile(path); err != nil {
+			t.Fatal(err)
+		} else if strings.HasSuffix(string(data), test.expect) == false {
+			t.Errorf("%v: Unexpected output %q", test.format, string(data))
+		}
+	}
+}
+
+func Test_Log_002(t *testing.T) {
+	logger := newLog(t, "-log.level", "warn")
+	if logger.IsLevel(gopi.LOG_WARN) == false || logger.IsLevel(gopi.LOG_INFO) || logger.IsDebug() {
+		t.Error("Unexpected level", logger)
+	}
+	logger = newLog(t, "-log.level", "warn", "-debug")
+	if logger.IsDebug() == false || logger.IsLevel(gopi.LOG_TRACE) {
+		t.Error("Unexpected level", logger)
+	}
+	logger = newLog(t, "-log.level", "trace")
+	if logger.IsLevel(gopi.LOG_TRACE) == false {
+		t.Error("Unexpected level", logger)
+	}
+	cfg := config.New(t.Name(), []string{"-log.level", "verbose"})
+	logger = new(log.Log)
+	if err := logger.Define(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	} else if err := logger.New(cfg); errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	}
+}
+
+func Test_Log_003(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	logger := newLog(t, "-log.sink", "file", "-log.file", path, "-log.maxsize", "1", "-log.maxfiles", "2")
+	msg := strings.Repeat("x", 1024)
+	for i := 0; i < 3*1024; i++ {
+		logger.Print(msg)
+	}
+	if err := logger.Dispose(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{path, path + ".1", path + ".2"} {
+		if info, err := os.Stat(path); err != nil {
+			t.Error(err)
+		} else if info.Size() > 1<<20 {
+			t.Error("Unexpected size", path, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); os.IsNotExist(err) == false {
+		t.Error("Expected rotated file to be removed")
+	}
+}
+
+func Test_Log_004(t *testing.T) {
+	obj := new(unitapp)
+	if err := graph.NewGraph(nil).Create(obj); err != nil {
+		t.Fatal(err)
+	} else if _, ok := obj.Logger.(*log.Log); ok == false {
+		t.Error("Expected application object to use unit, got:", obj.Logger)
+	} else if str := fmt.Sprint(obj.LoggerUnit.LevelLogger); str != "<log name=log_test.amp>" {
+		t.Error("Unexpected logger:", str)
+	}
+}
+
+func newLog(t *testing.T, args ...string) *log.Log {
+	cfg := config.New(t.Name(), args)
+	logger := new(log.Log)
+	if err := logger.Define(cfg); err != nil {
+		t.Fatal(err)
+	} else if err := cfg.Parse(); err != nil {
+		t.Fatal(err)
+	} else if err := logger.New(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return logger
+}
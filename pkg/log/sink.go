@@ -0,0 +1,150 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// sink writes log entries to an output
+type sink interface {
+	Write(*entry, formatter) error
+	Close() error
+}
+
+// writer writes formatted entries to a stream
+type writer struct {
+	io.Writer
+}
+
+// file writes formatted entries to a file, which is rotated when it
+// reaches a maximum size. Rotated files have a numeric suffix, where
+// the file with suffix .1 is the most recent
+type file struct {
+	path     string
+	maxsize  int64
+	maxfiles uint
+	fh       *os.File
+	size     int64
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	sinkStderr   = "stderr"
+	sinkFile     = "file"
+	sinkSyslog   = "syslog"
+	sinkJournald = "journald"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func (this *Log) newSink(name, tag string) (sink, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case sinkStderr:
+		return &writer{os.Stderr}, nil
+	case sinkFile:
+		if *this.path == "" {
+			return nil, gopi.ErrBadParameter.WithPrefix("-log.file")
+		}
+		return newFile(*this.path, int64(*this.maxsize)<<20, *this.maxfiles)
+	case sinkSyslog:
+		return newSyslog(tag)
+	case sinkJournald:
+		return newJournald(tag)
+	default:
+		return nil, gopi.ErrBadParameter.WithPrefix("-log.sink: ", name)
+	}
+}
+
+func newFile(path string, maxsize int64, maxfiles uint) (*file, error) {
+	this := &file{path: path, maxsize: maxsize, maxfiles: maxfiles}
+	if err := this.open(); err != nil {
+		return nil, err
+	}
+	return this, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *writer) Write(e *entry, fn formatter) error {
+	_, err := this.Writer.Write(fn(e))
+	return err
+}
+
+func (this *writer) Close() error {
+	// Standard error is not closed
+	return nil
+}
+
+func (this *file) Write(e *entry, fn formatter) error {
+	data := fn(e)
+	if this.maxsize > 0 && this.size > 0 && this.size+int64(len(data)) > this.maxsize {
+		if err := this.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := this.fh.Write(data)
+	this.size += int64(n)
+	return err
+}
+
+func (this *file) Close() error {
+	if this.fh == nil {
+		return nil
+	}
+	err := this.fh.Close()
+	this.fh = nil
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *file) open() error {
+	if fh, err := os.OpenFile(this.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return err
+	} else if info, err := fh.Stat(); err != nil {
+		fh.Close()
+		return err
+	} else {
+		this.fh, this.size = fh, info.Size()
+	}
+	return nil
+}
+
+// rotate closes the file, renames it and the existing rotated files,
+// removing the oldest, and then opens a new file
+func (this *file) rotate() error {
+	if err := this.Close(); err != nil {
+		return err
+	}
+	if this.maxfiles == 0 {
+		if err := os.Remove(this.path); err != nil && os.IsNotExist(err) == false {
+			return err
+		}
+	} else {
+		for i := this.maxfiles; i > 1; i-- {
+			if err := os.Rename(rotatedPath(this.path, i-1), rotatedPath(this.path, i)); err != nil && os.IsNotExist(err) == false {
+				return err
+			}
+		}
+		if err := os.Rename(this.path, rotatedPath(this.path, 1)); err != nil && os.IsNotExist(err) == false {
+			return err
+		}
+	}
+	return this.open()
+}
+
+func rotatedPath(path string, n uint) string {
+	return fmt.Sprint(path, ".", n)
+}
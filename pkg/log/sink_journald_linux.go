@@ -0,0 +1,119 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// journald writes entries to the systemd journal using the native
+// protocol, where the fields of each entry are journal fields
+type journald struct {
+	conn *net.UnixConn
+	tag  string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	journaldSocket = "/run/systemd/journal/socket"
+)
+
+var (
+	reJournaldField = regexp.MustCompile("[^A-Z0-9_]")
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func newJournald(tag string) (sink, error) {
+	addr := &net.UnixAddr{Name: journaldSocket, Net: "unixgram"}
+	if conn, err := net.DialUnix("unixgram", nil, addr); err != nil {
+		return nil, err
+	} else {
+		return &journald{conn, tag}, nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Write sends an entry to the journal. The formatter is not used, since
+// fields are sent separately from the message
+func (this *journald) Write(e *entry, _ formatter) error {
+	var b bytes.Buffer
+	msg := e.msg
+	if e.name != "" {
+		msg = e.name + ": " + msg
+	}
+	journaldField(&b, "MESSAGE", msg)
+	journaldField(&b, "PRIORITY", strconv.Itoa(journaldPriority(e.level)))
+	if this.tag != "" {
+		journaldField(&b, "SYSLOG_IDENTIFIER", this.tag)
+	}
+	if e.name != "" {
+		journaldField(&b, "GOPI_NAME", e.name)
+	}
+	e.eachField(func(k string, v interface{}) {
+		journaldField(&b, journaldKey(k), stringValue(v))
+	})
+	_, err := this.conn.Write(b.Bytes())
+	return err
+}
+
+func (this *journald) Close() error {
+	return this.conn.Close()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// journaldField appends a field. Values which contain a newline are
+// written with a length prefix
+func journaldField(b *bytes.Buffer, k, v string) {
+	b.WriteString(k)
+	if strings.Contains(v, "\n") {
+		b.WriteByte('\n')
+		binary.Write(b, binary.LittleEndian, uint64(len(v)))
+	} else {
+		b.WriteByte('=')
+	}
+	b.WriteString(v)
+	b.WriteByte('\n')
+}
+
+// journaldKey returns a field name which consists of upper case letters,
+// digits and underscores and does not start with an underscore
+func journaldKey(k string) string {
+	k = strings.TrimLeft(reJournaldField.ReplaceAllString(strings.ToUpper(k), "_"), "_")
+	if k == "" {
+		return "GOPI_FIELD"
+	} else if k[0] >= '0' && k[0] <= '9' {
+		return "GOPI_" + k
+	} else {
+		return k
+	}
+}
+
+// journaldPriority returns the syslog priority for a level
+func journaldPriority(level gopi.LogLevel) int {
+	switch level {
+	case gopi.LOG_ERROR:
+		return 3
+	case gopi.LOG_WARN:
+		return 4
+	case gopi.LOG_INFO:
+		return 6
+	default:
+		return 7
+	}
+}
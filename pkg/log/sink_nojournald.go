@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package log
+
+import (
+	"github.com/djthorpe/gopi/v3"
+)
+
+func newJournald(string) (sink, error) {
+	return nil, gopi.ErrNotImplemented.WithPrefix("-log.sink: ", sinkJournald)
+}
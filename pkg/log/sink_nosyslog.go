@@ -0,0 +1,12 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package log
+
+import (
+	"github.com/djthorpe/gopi/v3"
+)
+
+func newSyslog(string) (sink, error) {
+	return nil, gopi.ErrNotImplemented.WithPrefix("-log.sink: ", sinkSyslog)
+}
@@ -0,0 +1,48 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package log
+
+import (
+	"log/syslog"
+	"strings"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// logsyslog writes formatted entries to the system log daemon, which
+// adds the time and tag to each message
+type logsyslog struct {
+	*syslog.Writer
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func newSyslog(tag string) (sink, error) {
+	if w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag); err != nil {
+		return nil, err
+	} else {
+		return &logsyslog{w}, nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *logsyslog) Write(e *entry, fn formatter) error {
+	msg := strings.TrimSuffix(string(fn(e)), "\n")
+	switch e.level {
+	case gopi.LOG_ERROR:
+		return this.Writer.Err(msg)
+	case gopi.LOG_WARN:
+		return this.Writer.Warning(msg)
+	case gopi.LOG_INFO:
+		return this.Writer.Info(msg)
+	default:
+		return this.Writer.Debug(msg)
+	}
+}
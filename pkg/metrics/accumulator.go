@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// accumulator holds the state of a counter, histogram or summary
+// metric, which changes as values are observed
+type accumulator struct {
+	bounds    []float64 // Histogram upper bounds or summary quantiles
+	count     uint64
+	sum       float64   // Number of observations and their sum, or total for a counter
+	buckets   []uint64  // Histogram observations for each bound
+	window    []float64 // Recent summary observations
+	next      int       // Position in window for the next observation
+	quantiles []gopi.MetricQuantile
+
+	// Counter rate, and total at the time it was calculated
+	rate float64
+	prev float64
+	ts   time.Time
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Number of recent observations used to calculate summary quantiles
+	summaryWindow = 1024
+)
+
+var (
+	defaultBuckets   = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	defaultQuantiles = []float64{0.5, 0.9, 0.99}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+func newAccumulator(k kind) *accumulator {
+	switch k {
+	case kHistogram:
+		return &accumulator{bounds: defaultBuckets, buckets: make([]uint64, len(defaultBuckets))}
+	case kSummary:
+		return &accumulator{bounds: defaultQuantiles}
+	default:
+		return &accumulator{}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// METHODS
+
+// setBounds sets histogram upper bounds, which must be in increasing
+// order, or summary quantiles which must be between zero and one
+func (this *accumulator) setBounds(k kind, bounds []float64) error {
+	if len(bounds) == 0 {
+		return gopi.ErrBadParameter.WithPrefix(k)
+	}
+	for i, bound := range bounds {
+		switch {
+		case math.IsNaN(bound) || math.IsInf(bound, 0):
+			return gopi.ErrBadParameter.WithPrefix(k, ": ", bound)
+		case k == kHistogram && i > 0 && bound <= bounds[i-1]:
+			return gopi.ErrBadParameter.WithPrefix(k, ": ", bound)
+		case k == kSummary && (bound <= 0 || bound >= 1):
+			return gopi.ErrBadParameter.WithPrefix(k, ": ", bound)
+		case k != kHistogram && k != kSummary:
+			return gopi.ErrBadParameter.WithPrefix(k)
+		}
+	}
+	this.bounds = bounds
+	if k == kHistogram {
+		this.buckets = make([]uint64, len(bounds))
+	}
+	return nil
+}
+
+// observe adds a value to a counter, or an observation to a
+// histogram or summary
+func (this *accumulator) observe(k kind, v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return gopi.ErrBadParameter.WithPrefix(k, ": ", v)
+	}
+	switch k {
+	case kCounter:
+		if v < 0 {
+			return gopi.ErrBadParameter.WithPrefix(k, ": ", v)
+		}
+		this.sum += v
+	case kHistogram:
+		this.count++
+		this.sum += v
+		if i := sort.SearchFloat64s(this.bounds, v); i < len(this.buckets) {
+			this.buckets[i]++
+		}
+	case kSummary:
+		this.count++
+		this.sum += v
+		this.quantiles = nil
+		if len(this.window) < summaryWindow {
+			this.window = append(this.window, v)
+		} else {
+			this.window[this.next] = v
+			this.next = (this.next + 1) % summaryWindow
+		}
+	default:
+		return gopi.ErrBadParameter.WithPrefix(k)
+	}
+	return nil
+}
+
+// set replaces the state with a value, for example when decoding
+func (this *accumulator) set(k kind, v interface{}) error {
+	switch value := v.(type) {
+	case gopi.MetricCounter:
+		if k != kCounter {
+			return gopi.ErrBadParameter.WithPrefix(k)
+		}
+		this.sum, this.rate = value.Total, value.Rate
+	case gopi.MetricHistogram:
+		if k != kHistogram {
+			return gopi.ErrBadParameter.WithPrefix(k)
+		}
+		this.bounds = make([]float64, len(value.Buckets))
+		this.buckets = make([]uint64, len(value.Buckets))
+		prev := uint64(0)
+		for i, bucket := range value.Buckets {
+			if bucket.Count < prev {
+				return gopi.ErrBadParameter.WithPrefix(k)
+			}
+			this.bounds[i], this.buckets[i] = bucket.UpperBound, bucket.Count-prev
+			prev = bucket.Count
+		}
+		this.count, this.sum = value.Count, value.Sum
+	case gopi.MetricSummary:
+		if k != kSummary {
+			return gopi.ErrBadParameter.WithPrefix(k)
+		}
+		this.bounds = make([]float64, len(value.Quantiles))
+		for i, q := range value.Quantiles {
+			this.bounds[i] = q.Quantile
+		}
+		this.count, this.sum = value.Count, value.Sum
+		this.window, this.next = nil, 0
+		this.quantiles = append([]gopi.MetricQuantile{}, value.Quantiles...)
+	default:
+		return gopi.ErrBadParameter.WithPrefix(k)
+	}
+	return nil
+}
+
+// tick calculates the rate of a counter since it was last calculated
+func (this *accumulator) tick(ts time.Time) {
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	if this.ts.IsZero() == false && ts.After(this.ts) {
+		this.rate = (this.sum - this.prev) / ts.Sub(this.ts).Seconds()
+	}
+	this.prev, this.ts = this.sum, ts
+}
+
+// value returns the current value for the kind
+func (this *accumulator) value(k kind) interface{} {
+	switch k {
+	case kCounter:
+		return gopi.MetricCounter{Total: this.sum, Rate: this.rate}
+	case kHistogram:
+		result := gopi.MetricHistogram{Count: this.count, Sum: this.sum}
+		total := uint64(0)
+		for i, bound := range this.bounds {
+			total += this.buckets[i]
+			result.Buckets = append(result.Buckets, gopi.MetricBucket{UpperBound: bound, Count: total})
+		}
+		return result
+	case kSummary:
+		return gopi.MetricSummary{Count: this.count, Sum: this.sum, Quantiles: this.quantile()}
+	default:
+		return nil
+	}
+}
+
+// copy returns a copy of the state. When freeze is true, summary
+// quantiles are calculated and the observations are not copied
+func (this *accumulator) copy(freeze bool) *accumulator {
+	that := *this
+	that.buckets = append([]uint64{}, this.buckets...)
+	if freeze {
+		that.quantiles = this.quantile()
+		that.window, that.next = nil, 0
+	} else {
+		that.window = append([]float64{}, this.window...)
+	}
+	return &that
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// quantile returns the value for each quantile from the recent
+// observations, using the nearest rank
+func (this *accumulator) quantile() []gopi.MetricQuantile {
+	if len(this.window) == 0 {
+		return this.quantiles
+	}
+	values := append([]float64{}, this.window...)
+	sort.Float64s(values)
+	result := make([]gopi.MetricQuantile, len(this.bounds))
+	for i, q := range this.bounds {
+		rank := int(math.Ceil(q*float64(len(values)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		result[i] = gopi.MetricQuantile{Quantile: q, Value: values[rank]}
+	}
+	return result
+}
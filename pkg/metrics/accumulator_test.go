@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+func Test_Accumulator_001(t *testing.T) {
+	good := []string{
+		"a counter",
+		"a gauge",
+		"a histogram",
+		"a histogram(0.1,1,10)",
+		"a,b summary(0.5,0.99) c uint32",
+		"a histogram(-1,0,1), b counter",
+	}
+	for _, v := range good {
+		if m, err := parseMetrics(v); err != nil {
+			t.Errorf("%q: %v", v, err)
+		} else {
+			t.Log(m)
+		}
+	}
+	bad := []string{
+		"a histogram(",
+		"a histogram()",
+		"a histogram(1,0)",
+		"a summary(0.5,1)",
+		"a uint32(1)",
+		"a counter(1)",
+	}
+	for _, v := range bad {
+		if _, err := parseMetrics(v); err == nil {
+			t.Errorf("%q: Expected error", v)
+		}
+	}
+}
+
+func Test_Accumulator_002(t *testing.T) {
+	m, err := NewMeasurement("test", "events counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Counters accumulate in the measurement, and the rate is
+	// calculated between each emit
+	if err := m.Set("events", 5); err != nil {
+		t.Error(err)
+	} else if that, err := m.Clone(ts, nil, uint64(5)); err != nil {
+		t.Error(err)
+	} else if v := that.Get("events"); v != (gopi.MetricCounter{Total: 10}) {
+		t.Error("Unexpected value", v)
+	}
+	if that, err := m.Clone(ts.Add(10*time.Second), nil, nil); err != nil {
+		t.Error(err)
+	} else if v := that.Get("events"); v != (gopi.MetricCounter{Total: 10}) {
+		t.Error("Unexpected value", v)
+	}
+	if that, err := m.Clone(ts.Add(20*time.Second), nil, 20); err != nil {
+		t.Error(err)
+	} else if v := that.Get("events"); v != (gopi.MetricCounter{Total: 30, Rate: 2}) {
+		t.Error("Unexpected value", v)
+	}
+
+	// Counters can't decrease
+	if _, err := m.Clone(ts, nil, -1); err == nil {
+		t.Error("Expected error")
+	}
+}
+
+func Test_Accumulator_003(t *testing.T) {
+	m, err := NewMeasurement("test", "latency histogram(0.1,1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []interface{}{0.05, 100 * time.Millisecond, float32(0.5), 5} {
+		if err := m.Set("latency", v); err != nil {
+			t.Error(err)
+		}
+	}
+	that, err := m.Clone(time.Time{}, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := that.Get("latency").(gopi.MetricHistogram)
+	if v.Count != 5 || v.Sum != 7.65 {
+		t.Error("Unexpected value", v)
+	} else if len(v.Buckets) != 2 || v.Buckets[0] != (gopi.MetricBucket{UpperBound: 0.1, Count: 2}) || v.Buckets[1] != (gopi.MetricBucket{UpperBound: 1, Count: 3}) {
+		t.Error("Unexpected buckets", v.Buckets)
+	}
+
+	// Emitted values do not change
+	m.Set("latency", 1)
+	if v := that.Get("latency").(gopi.MetricHistogram); v.Count != 5 {
+		t.Error("Unexpected value", v)
+	}
+}
+
+func Test_Accumulator_004(t *testing.T) {
+	m, err := NewMeasurement("test", "latency summary(0.5,0.9)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := m.Get("latency").(gopi.MetricSummary); v.Count != 0 || len(v.Quantiles) != 0 {
+		t.Error("Unexpected value", v)
+	}
+	for i := 1; i <= 9; i++ {
+		m.Set("latency", i)
+	}
+	that, err := m.Clone(time.Time{}, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := that.Get("latency").(gopi.MetricSummary)
+	if v.Count != 10 || v.Sum != 55 {
+		t.Error("Unexpected value", v)
+	} else if len(v.Quantiles) != 2 || v.Quantiles[0] != (gopi.MetricQuantile{Quantile: 0.5, Value: 5}) || v.Quantiles[1] != (gopi.MetricQuantile{Quantile: 0.9, Value: 9}) {
+		t.Error("Unexpected quantiles", v.Quantiles)
+	}
+}
+
+func Test_Accumulator_005(t *testing.T) {
+	// Values can be set directly, for example when decoding
+	h := gopi.MetricHistogram{Count: 3, Sum: 1.5, Buckets: []gopi.MetricBucket{{UpperBound: 0.5, Count: 1}, {UpperBound: 1, Count: 2}}}
+	if f := NewField("h", h); f == nil {
+		t.Error("Unexpected nil field")
+	} else if f.Kind() != "histogram" {
+		t.Error("Unexpected kind", f.Kind())
+	} else if v := f.Value().(gopi.MetricHistogram); v.Count != 3 || v.Sum != 1.5 || len(v.Buckets) != 2 || v.Buckets[1].Count != 2 {
+		t.Error("Unexpected value", v)
+	}
+	if f := NewField("c", gopi.MetricCounter{Total: 1, Rate: 0.5}); f == nil || f.Value() != (gopi.MetricCounter{Total: 1, Rate: 0.5}) {
+		t.Error("Unexpected value", f)
+	} else if err := f.SetValue(gopi.MetricSummary{}); err == nil {
+		t.Error("Expected error")
+	}
+	if f := NewField("g"); f == nil {
+		t.Error("Unexpected nil field")
+	} else if err := f.(*field).SetKind("gauge"); err != nil {
+		t.Error(err)
+	} else if err := f.SetValue(uint8(42)); err != nil {
+		t.Error(err)
+	} else if f.Value() != float64(42) {
+		t.Error("Unexpected value", f.Value())
+	}
+}
+
+func Test_Accumulator_006(t *testing.T) {
+	m, err := NewMeasurement("test", "a counter, b histogram(0.5), c summary(0.999), d gauge, e string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	that, err := m.Clone(time.Time{}, nil, 1, 0.25, 2, 3, "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"a", "a_rate", "b_count", "b_sum", "b_le_0.5", "b_le_inf", "c_count", "c_sum", "c_p99.9", "d", "e"}
+	kinds := []string{"float64", "float64", "uint64", "float64", "uint64", "uint64", "uint64", "float64", "float64", "float64", "string"}
+	fields := Expand(that.Metrics())
+	if len(fields) != len(names) {
+		t.Fatal("Unexpected fields", fields)
+	}
+	for i, f := range fields {
+		if f.Name() != names[i] || f.Kind() != kinds[i] {
+			t.Errorf("Unexpected field %v, expected %q %v", f, names[i], kinds[i])
+		}
+	}
+}
+
+func Test_Accumulator_007(t *testing.T) {
+	m, err := NewMeasurement("test", "latency histogram(1), req counter", NewField("path", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := []gopi.Field{NewField("path", "/a")}, []gopi.Field{NewField("path", "/b")}
+
+	// Values accumulate separately for each set of tag values
+	for _, tags := range [][]gopi.Field{a, b, a} {
+		if _, err := m.Clone(time.Time{}, tags, 0.5, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if that, err := m.Clone(time.Time{}, b, nil, nil); err != nil {
+		t.Error(err)
+	} else if v := that.Get("req").(gopi.MetricCounter); v.Total != 1 {
+		t.Error("Unexpected value", v)
+	} else if v := that.Get("latency").(gopi.MetricHistogram); v.Count != 1 {
+		t.Error("Unexpected value", v)
+	}
+
+	// Values are not accumulated when any value is invalid
+	if _, err := m.Clone(time.Time{}, a, 0.5, -1); err == nil {
+		t.Error("Expected error")
+	} else if that, err := m.Clone(time.Time{}, a, nil, nil); err != nil {
+		t.Error(err)
+	} else if v := that.Get("req").(gopi.MetricCounter); v.Total != 2 {
+		t.Error("Unexpected value", v)
+	} else if v := that.Get("latency").(gopi.MetricHistogram); v.Count != 2 {
+		t.Error("Unexpected value", v)
+	}
+}
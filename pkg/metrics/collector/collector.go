@@ -0,0 +1,230 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+type collector struct {
+	gopi.Unit
+	gopi.Platform
+	gopi.Metrics
+	gopi.Logger
+	sync.Mutex
+
+	// Flags
+	interval         *time.Duration
+	collect, process *string
+
+	enabled []*source
+	names   map[string]bool // Names of processes to collect
+	cpu     map[int]cpu     // CPU time for processes at the last collection
+}
+
+// source defines a measurement and the function which emits it
+type source struct {
+	name    string
+	metrics string
+	tags    []string
+	fn      func(*collector, string) error
+}
+
+type cpu struct {
+	used time.Duration
+	ts   time.Time
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	DefaultInterval = 30 * time.Second
+	Prefix          = "system_"
+)
+
+/////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func (this *collector) Define(cfg gopi.Config) error {
+	this.interval = cfg.FlagDuration("metrics.interval", DefaultInterval, "Interval for collecting system metrics, or zero to disable")
+	this.collect = cfg.FlagString("metrics.collect", "", "Comma-separated system metrics to collect, or empty for all ("+strings.Join(names(), ",")+")")
+	this.process = cfg.FlagString("metrics.process", "", "Comma-separated names of processes to collect in addition to this process")
+	return nil
+}
+
+func (this *collector) New(gopi.Config) error {
+	if this.Metrics == nil {
+		return gopi.ErrInternalAppError.WithPrefix("Metrics")
+	}
+
+	// Enable sources
+	sources := sources()
+	if *this.collect == "" {
+		for _, name := range names() {
+			this.enabled = append(this.enabled, sources[name])
+		}
+	} else {
+		for _, name := range strings.Split(*this.collect, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if src, exists := sources[name]; exists == false {
+				return gopi.ErrBadParameter.WithPrefix("-metrics.collect: ", name)
+			} else {
+				this.enabled = append(this.enabled, src)
+			}
+		}
+	}
+
+	// Define measurements, with the host tag
+	for _, src := range this.enabled {
+		tags := []gopi.Field{this.Metrics.HostTag()}
+		for _, tag := range src.tags {
+			tags = append(tags, this.Metrics.Field(tag, ""))
+		}
+		if _, err := this.Metrics.NewMeasurement(Prefix+src.name, src.metrics, tags...); err != nil {
+			return err
+		}
+	}
+
+	// Set names of processes
+	this.names = make(map[string]bool)
+	for _, name := range strings.Split(*this.process, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			this.names[name] = true
+		}
+	}
+	this.cpu = make(map[int]cpu)
+
+	// Return success
+	return nil
+}
+
+func (this *collector) Run(ctx context.Context) error {
+	if *this.interval == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			if err := this.Collect(); err != nil {
+				this.Print("Collect: ", err)
+			}
+			timer.Reset(*this.interval)
+		}
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Collect emits measurements from the enabled collectors
+func (this *collector) Collect() error {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
+	var result error
+	for _, src := range this.enabled {
+		if err := src.fn(this, Prefix+src.name); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%v: %w", src.name, err))
+		}
+	}
+	return result
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *collector) String() string {
+	str := "<metrics.collector"
+	str += fmt.Sprint(" interval=", *this.interval)
+	for _, src := range this.enabled {
+		str += " " + Prefix + src.name
+	}
+	return str + ">"
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// sources returns the collectors available for this platform
+func sources() map[string]*source {
+	result := map[string]*source{
+		"load":        {"load", "load1,load5,load15 float64", nil, (*collector).collectLoad},
+		"uptime":      {"uptime", "uptime float64", nil, (*collector).collectUptime},
+		"temperature": {"temperature", "celcius float32", []string{"zone"}, (*collector).collectTemperature},
+	}
+	for _, src := range platformSources() {
+		result[src.name] = src
+	}
+	return result
+}
+
+// names returns the names of available collectors, in order
+func names() []string {
+	result := []string{}
+	for name := range sources() {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (this *collector) collectLoad(name string) error {
+	if this.Platform == nil {
+		return gopi.ErrNotImplemented
+	}
+	l1, l5, l15 := this.Platform.LoadAverages()
+	return this.Metrics.Emit(name, nil, l1, l5, l15)
+}
+
+func (this *collector) collectUptime(name string) error {
+	if this.Platform == nil {
+		return gopi.ErrNotImplemented
+	}
+	return this.Metrics.Emit(name, nil, this.Platform.Uptime().Seconds())
+}
+
+func (this *collector) collectTemperature(name string) error {
+	if this.Platform == nil {
+		return gopi.ErrNotImplemented
+	}
+	var result error
+	for zone, value := range this.Platform.TemperatureZones() {
+		tags := []gopi.Field{this.Metrics.Field("zone", zone)}
+		if err := this.Metrics.Emit(name, tags, value); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result
+}
+
+// percent returns the CPU usage for a process since the last collection,
+// or since the process started when first collected
+func (this *collector) percent(pid int, used, running time.Duration) float64 {
+	now := time.Now()
+	prev, exists := this.cpu[pid]
+	this.cpu[pid] = cpu{used, now}
+	if exists {
+		used, running = used-prev.used, now.Sub(prev.ts)
+	}
+	if running <= 0 || used < 0 {
+		return 0
+	}
+	return 100 * float64(used) / float64(running)
+}
@@ -0,0 +1,11 @@
+// +build darwin
+
+package collector
+
+/////////////////////////////////////////////////////////////////////
+// SOURCES
+
+// Memory, disk, network and process metrics are not currently supported
+func platformSources() []*source {
+	return nil
+}
@@ -0,0 +1,133 @@
+// +build linux
+// +build !darwin
+
+package collector
+
+import (
+	"fmt"
+	"os"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	linux "github.com/djthorpe/gopi/v3/pkg/sys/linux"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+/////////////////////////////////////////////////////////////////////
+// SOURCES
+
+func platformSources() []*source {
+	return []*source{
+		{"memory", "total,free,available,buffers,cached,swap_total,swap_free int64", nil, (*collector).collectMemory},
+		{"disk", "total,free,avail,files,files_free int64", []string{"path", "device", "type"}, (*collector).collectDisk},
+		{"network", "rx_bytes,rx_packets,rx_errors,rx_dropped,tx_bytes,tx_packets,tx_errors,tx_dropped uint64", []string{"interface"}, (*collector).collectNetwork},
+		{"process", "cpu float64, rss int64, threads uint32", []string{"pid", "name"}, (*collector).collectProcess},
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *collector) collectMemory(name string) error {
+	info, err := linux.MemInfo()
+	if err != nil {
+		return err
+	}
+	return this.Metrics.Emit(name, nil,
+		int64(info["MemTotal"]), int64(info["MemFree"]), int64(info["MemAvailable"]),
+		int64(info["Buffers"]), int64(info["Cached"]),
+		int64(info["SwapTotal"]), int64(info["SwapFree"]),
+	)
+}
+
+func (this *collector) collectDisk(name string) error {
+	mounts, err := linux.Mounts()
+	if err != nil {
+		return err
+	}
+
+	var result error
+	paths := make(map[string]bool, len(mounts))
+	for _, mount := range mounts {
+		// Ignore filesystems mounted more than once
+		if paths[mount.Path] {
+			continue
+		} else {
+			paths[mount.Path] = true
+		}
+		if usage, err := linux.Usage(mount.Path); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%v: %w", mount.Path, err))
+		} else if err := this.Metrics.Emit(name, []gopi.Field{
+			this.Metrics.Field("path", mount.Path),
+			this.Metrics.Field("device", mount.Device),
+			this.Metrics.Field("type", mount.Type),
+		}, int64(usage.Total), int64(usage.Free), int64(usage.Avail), int64(usage.Files), int64(usage.FilesFree)); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result
+}
+
+func (this *collector) collectNetwork(name string) error {
+	stats, err := linux.NetDevStats()
+	if err != nil {
+		return err
+	}
+
+	var result error
+	for _, stat := range stats {
+		if err := this.Metrics.Emit(name, []gopi.Field{
+			this.Metrics.Field("interface", stat.Name),
+		}, stat.RxBytes, stat.RxPackets, stat.RxErrors, stat.RxDropped, stat.TxBytes, stat.TxPackets, stat.TxErrors, stat.TxDropped); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result
+}
+
+// collectProcess emits measurements for this process and any processes
+// with matching names
+func (this *collector) collectProcess(name string) error {
+	pids := []int{os.Getpid()}
+	if len(this.names) > 0 {
+		if all, err := linux.Processes(); err != nil {
+			return err
+		} else {
+			pids = append(pids, all...)
+		}
+	}
+
+	var result error
+	seen := make(map[int]bool, len(pids))
+	uptime := linux.Uptime()
+	for i, pid := range pids {
+		if seen[pid] {
+			continue
+		}
+		stat, err := linux.ProcessStat(pid)
+		if err != nil {
+			// Processes can exit while being read
+			if i == 0 {
+				result = multierror.Append(result, err)
+			}
+			continue
+		} else if i > 0 && this.names[stat.Name] == false {
+			continue
+		}
+		seen[pid] = true
+		if err := this.Metrics.Emit(name, []gopi.Field{
+			this.Metrics.Field("pid", fmt.Sprint(pid)),
+			this.Metrics.Field("name", stat.Name),
+		}, this.percent(pid, stat.CPU, uptime-stat.Start), int64(stat.RSS), uint32(stat.Threads)); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Remove processes which have exited
+	for pid := range this.cpu {
+		if seen[pid] == false {
+			delete(this.cpu, pid)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,87 @@
+// +build linux
+
+package collector_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	_ "github.com/djthorpe/gopi/v3/pkg/metrics/collector"
+	tool "github.com/djthorpe/gopi/v3/pkg/tool"
+)
+
+type App struct {
+	gopi.Unit
+	gopi.Publisher
+	gopi.Metrics
+	gopi.MetricCollector
+}
+
+func (this *App) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// receive returns measurements emitted within a duration, keyed by name
+func receive(app *App, d time.Duration) map[string][]gopi.Measurement {
+	ch := app.Publisher.SubscribeType((*gopi.Measurement)(nil))
+	defer app.Publisher.Unsubscribe(ch)
+
+	result := make(map[string][]gopi.Measurement)
+	timeout := time.After(d)
+	for {
+		select {
+		case evt := <-ch:
+			m := evt.(gopi.Measurement)
+			result[m.Name()] = append(result[m.Name()], m)
+		case <-timeout:
+			return result
+		}
+	}
+}
+
+func Test_Collector_001(t *testing.T) {
+	tool.Test(t, []string{"-metrics.interval=0"}, new(App), func(app *App) {
+		if app.MetricCollector == nil {
+			t.Fatal("nil MetricCollector")
+		} else {
+			t.Log(app.MetricCollector)
+		}
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			if err := app.MetricCollector.Collect(); err != nil {
+				t.Error(err)
+			}
+		}()
+		result := receive(app, 300*time.Millisecond)
+		for _, name := range []string{"system_load", "system_uptime", "system_memory", "system_network", "system_process"} {
+			if len(result[name]) == 0 {
+				t.Error("Missing measurement", name)
+			}
+		}
+		host, _ := os.Hostname()
+		for _, m := range result["system_network"] {
+			if m.Get("host") != host || m.Get("interface") == "" {
+				t.Error("Unexpected tags", m)
+			}
+		}
+		if m := result["system_process"]; len(m) != 1 || m[0].Get("pid") == "" || m[0].Get("rss") == int64(0) {
+			t.Error("Unexpected process measurements", m)
+		} else {
+			t.Log(m[0])
+		}
+	})
+}
+
+func Test_Collector_002(t *testing.T) {
+	args := []string{"-metrics.interval=100ms", "-metrics.collect=load, memory"}
+	tool.Test(t, args, new(App), func(app *App) {
+		result := receive(app, 350*time.Millisecond)
+		if len(result) != 2 || len(result["system_load"]) < 2 || len(result["system_memory"]) < 2 {
+			t.Error("Unexpected measurements", result)
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package collector
+
+import (
+	"reflect"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	graph "github.com/djthorpe/gopi/v3/pkg/graph"
+	_ "github.com/djthorpe/gopi/v3/pkg/hw/platform"
+	_ "github.com/djthorpe/gopi/v3/pkg/metrics"
+)
+
+func init() {
+	graph.RegisterUnit(reflect.TypeOf(&collector{}), reflect.TypeOf((*gopi.MetricCollector)(nil)))
+}
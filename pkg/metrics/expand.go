@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Expand returns fields with scalar values, for writing to flat formats
+// such as line protocol or CSV. Gauges are returned as float64 values,
+// and counters, histograms and summaries are expanded into fields
+// with a suffix:
+//
+//	counter:   <name> and <name>_rate
+//	histogram: <name>_count, <name>_sum, <name>_le_<bound> and <name>_le_inf
+//	summary:   <name>_count, <name>_sum and <name>_p<percentile>
+//
+// Other fields are returned unchanged
+func Expand(fields []gopi.Field) []gopi.Field {
+	result := make([]gopi.Field, 0, len(fields))
+	for _, f := range fields {
+		if f == nil || f.IsNil() {
+			result = append(result, f)
+			continue
+		}
+		name := f.Name()
+		switch value := f.Value().(type) {
+		case gopi.MetricCounter:
+			result = append(result, newField(name, value.Total), newField(name+"_rate", value.Rate))
+		case gopi.MetricHistogram:
+			result = append(result, newField(name+"_count", value.Count), newField(name+"_sum", value.Sum))
+			for _, bucket := range value.Buckets {
+				result = append(result, newField(name+"_le_"+formatFloat(bucket.UpperBound), bucket.Count))
+			}
+			result = append(result, newField(name+"_le_inf", value.Count))
+		case gopi.MetricSummary:
+			result = append(result, newField(name+"_count", value.Count), newField(name+"_sum", value.Sum))
+			for _, q := range value.Quantiles {
+				result = append(result, newField(name+"_p"+formatFloat(q.Quantile*100), q.Value))
+			}
+		default:
+			if f.Kind() == kGauge.String() {
+				result = append(result, newField(name, value))
+			} else {
+				result = append(result, f)
+			}
+		}
+	}
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// newField returns a field without checking the name, which can
+// include characters such as a decimal point
+func newField(name string, value interface{}) gopi.Field {
+	this := &field{name: name}
+	this.SetValue(value)
+	return this
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -17,6 +17,7 @@ type field struct {
 	name  string
 	value interface{}
 	kind
+	acc *accumulator // State for counter, histogram and summary kinds
 	sync.RWMutex
 }
 
@@ -49,6 +50,10 @@ const (
 	kFloat32
 	kFloat64
 	kTime
+	kGauge
+	kCounter
+	kHistogram
+	kSummary
 	kMax
 )
 
@@ -96,7 +101,7 @@ func (this *field) Kind() string {
 }
 
 func (this *field) IsNil() bool {
-	return this.kind == kNone || this.value == nil
+	return this.kind == kNone || (this.value == nil && this.acc == nil)
 }
 
 func (this *field) Value() interface{} {
@@ -104,7 +109,9 @@ func (this *field) Value() interface{} {
 	defer this.RWMutex.RUnlock()
 
 	// Returns zero-value for type if nil
-	if this.IsNil() {
+	if this.acc != nil {
+		return this.acc.value(this.kind)
+	} else if this.IsNil() {
 		return this.kind.ZeroValue()
 	} else {
 		return this.value
@@ -115,6 +122,21 @@ func (this *field) SetValue(v interface{}) error {
 	this.RWMutex.Lock()
 	defer this.RWMutex.Unlock()
 
+	// Counters, histograms and summaries accumulate values, and
+	// gauges accept any number
+	if this.kind.accumulates() {
+		return this.accumulate(v)
+	} else if this.kind == kGauge {
+		if v == nil {
+			this.value = nil
+		} else if value, ok := ToFloat(v); ok == false {
+			return gopi.ErrBadParameter.WithPrefix(this.name)
+		} else {
+			this.value = value
+		}
+		return nil
+	}
+
 	if v == nil {
 		this.value = nil
 		return nil
@@ -198,6 +220,13 @@ func (this *field) SetValue(v interface{}) error {
 		}
 		this.value = v
 		this.kind = kTime
+	case gopi.MetricCounter, gopi.MetricHistogram, gopi.MetricSummary:
+		if this.kind != kNone {
+			return gopi.ErrBadParameter.WithPrefix(this.name)
+		}
+		this.kind = kindOf(v)
+		this.acc = newAccumulator(this.kind)
+		return this.accumulate(v)
 	default:
 		return gopi.ErrBadParameter.WithPrefix(this.name)
 	}
@@ -224,6 +253,26 @@ func (this *field) SetKind(k string) error {
 	} else {
 		this.value = nil
 		this.kind = kind
+		this.acc = nil
+		if kind.accumulates() {
+			this.acc = newAccumulator(kind)
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// SetBounds sets the upper bounds for a histogram or the
+// quantiles for a summary
+func (this *field) SetBounds(bounds []float64) error {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+
+	if this.acc == nil {
+		return gopi.ErrBadParameter.WithPrefix(this.name)
+	} else if err := this.acc.setBounds(this.kind, bounds); err != nil {
+		return fmt.Errorf("%v: %w", this.name, err)
 	}
 
 	// Return success
@@ -233,11 +282,24 @@ func (this *field) SetKind(k string) error {
 func (this *field) Copy() gopi.Field {
 	this.RWMutex.RLock()
 	defer this.RWMutex.RUnlock()
-	return &field{
-		name:  this.name,
-		value: this.value,
-		kind:  this.kind,
+	return this.copy(false)
+}
+
+// Snapshot accumulates a value and returns a copy of the field. For
+// counters, the rate is calculated since the last snapshot
+func (this *field) Snapshot(v interface{}, ts time.Time) (gopi.Field, error) {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+
+	if this.kind.accumulates() == false {
+		return nil, gopi.ErrBadParameter.WithPrefix(this.name)
+	} else if err := this.accumulate(v); err != nil {
+		return nil, err
 	}
+	if this.kind == kCounter {
+		this.acc.tick(ts)
+	}
+	return this.copy(true), nil
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -262,6 +324,109 @@ func (this *field) String() string {
 	return str + ">"
 }
 
+// accumulate adds a number to a counter or observes a number for
+// a histogram or summary, or replaces the state with a value
+func (this *field) accumulate(v interface{}) error {
+	if v == nil {
+		return nil
+	} else if value, ok := ToFloat(v); ok {
+		if err := this.acc.observe(this.kind, value); err != nil {
+			return fmt.Errorf("%v: %w", this.name, err)
+		}
+	} else if err := this.acc.set(this.kind, v); err != nil {
+		return fmt.Errorf("%v: %w", this.name, err)
+	}
+	return nil
+}
+
+// reset returns a copy of the field without any observations, with
+// the same histogram bounds or summary quantiles
+func (this *field) reset() *field {
+	this.RWMutex.RLock()
+	defer this.RWMutex.RUnlock()
+
+	that := this.copy(false)
+	if this.acc != nil {
+		that.acc = newAccumulator(this.kind)
+	}
+	if this.kind == kHistogram || this.kind == kSummary {
+		that.acc.setBounds(this.kind, this.acc.bounds)
+	}
+	return that
+}
+
+// replace sets the accumulated values from another field
+func (this *field) replace(other *field) {
+	this.RWMutex.Lock()
+	defer this.RWMutex.Unlock()
+	this.acc = other.acc
+}
+
+func (this *field) copy(freeze bool) *field {
+	that := &field{
+		name:  this.name,
+		value: this.value,
+		kind:  this.kind,
+	}
+	if this.acc != nil {
+		that.acc = this.acc.copy(freeze)
+	}
+	return that
+}
+
+// ToFloat returns a number as a float64, or false if the value
+// is not a number. Durations are returned in seconds
+func ToFloat(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case uint8:
+		return float64(value), true
+	case uint16:
+		return float64(value), true
+	case uint32:
+		return float64(value), true
+	case uint64:
+		return float64(value), true
+	case int8:
+		return float64(value), true
+	case int16:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case uint:
+		return float64(value), true
+	case float32:
+		return float64(value), true
+	case float64:
+		return value, true
+	case time.Duration:
+		return value.Seconds(), true
+	default:
+		return 0, false
+	}
+}
+
+func kindOf(v interface{}) kind {
+	switch v.(type) {
+	case gopi.MetricCounter:
+		return kCounter
+	case gopi.MetricHistogram:
+		return kHistogram
+	case gopi.MetricSummary:
+		return kSummary
+	default:
+		return kNone
+	}
+}
+
+// accumulates returns true for kinds which hold state
+func (k kind) accumulates() bool {
+	return k == kCounter || k == kHistogram || k == kSummary
+}
+
 func (k kind) String() string {
 	switch k {
 	case kNone:
@@ -292,6 +457,14 @@ func (k kind) String() string {
 		return "float64"
 	case kTime:
 		return "time.Time"
+	case kGauge:
+		return "gauge"
+	case kCounter:
+		return "counter"
+	case kHistogram:
+		return "histogram"
+	case kSummary:
+		return "summary"
 	default:
 		return "[?? Invalid kind]"
 	}
@@ -325,6 +498,14 @@ func (k kind) ZeroValue() interface{} {
 		return float64(0)
 	case kTime:
 		return time.Time{}
+	case kGauge:
+		return float64(0)
+	case kCounter:
+		return gopi.MetricCounter{}
+	case kHistogram:
+		return gopi.MetricHistogram{}
+	case kSummary:
+		return gopi.MetricSummary{}
 	default:
 		return nil
 	}
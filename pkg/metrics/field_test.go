@@ -77,3 +77,24 @@ func Test_Field_002(t *testing.T) {
 		}
 	}
 }
+
+func Test_Field_003(t *testing.T) {
+	tests := []struct {
+		v  interface{}
+		f  float64
+		ok bool
+	}{
+		{int(-2), -2, true},
+		{uint(2), 2, true},
+		{uint64(3), 3, true},
+		{float32(0.5), 0.5, true},
+		{1500 * time.Millisecond, 1.5, true},
+		{"1", 0, false},
+		{true, 0, false},
+	}
+	for _, test := range tests {
+		if f, ok := ToFloat(test.v); ok != test.ok || f != test.f {
+			t.Errorf("Unexpected value for %T: %v, %v", test.v, f, ok)
+		}
+	}
+}
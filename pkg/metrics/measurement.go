@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"text/scanner"
 	"time"
 
@@ -20,6 +21,10 @@ type measurement struct {
 	metrics []gopi.Field
 	tags    []gopi.Field
 	fields  map[string]gopi.Field
+
+	// Counters, histograms and summaries for each set of tag values
+	sync.Mutex
+	series map[string][]*field
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -29,6 +34,7 @@ const (
 	stateIdent = iota
 	stateIdent2
 	stateValue
+	stateBounds
 	stateDone
 )
 
@@ -92,6 +98,27 @@ func NewMeasurement(name, metrics string, tags ...gopi.Field) (*measurement, err
 	return this, nil
 }
 
+// NewMeasurementWithFields returns a data point with a timestamp, tags
+// and metrics, for example one which has been decoded or returned from
+// a query. The fields are not copied, and a tag is returned by Get when
+// a metric has the same name
+func NewMeasurementWithFields(name string, ts time.Time, tags, metrics []gopi.Field) gopi.Measurement {
+	this := new(measurement)
+	this.name = name
+	this.ts = ts
+	this.tags = tags
+	this.metrics = metrics
+	this.fields = make(map[string]gopi.Field, len(tags)+len(metrics))
+	for _, fields := range [][]gopi.Field{metrics, tags} {
+		for _, field := range fields {
+			if field != nil {
+				this.fields[field.Name()] = field
+			}
+		}
+	}
+	return this
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
@@ -118,9 +145,13 @@ func (this *measurement) Get(name string) interface{} {
 		return field.Value()
 	}
 }
+
 func (this *measurement) Set(name string, value interface{}) error {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
 	if field, exists := this.fields[name]; exists == false {
-		return nil
+		return gopi.ErrNotFound.WithPrefix(name)
 	} else {
 		return field.SetValue(value)
 	}
@@ -138,23 +169,39 @@ func (this *measurement) Clone(ts time.Time, tags []gopi.Field, values ...interf
 	that.fields = make(map[string]gopi.Field, len(this.fields))
 
 	// Index new tags and use them instead of defaults
+	values_ := make(map[string]gopi.Field, len(tags))
 	for _, tag := range tags {
-		fmt.Println("TODO: Clone", tag)
+		if tag == nil {
+			continue
+		} else if this.isTag(tag.Name()) == false {
+			return nil, gopi.ErrNotFound.WithPrefix("Clone: ", tag.Name())
+		} else {
+			values_[tag.Name()] = tag
+		}
 	}
 
 	// Clone tags
 	that.tags = make([]gopi.Field, len(this.tags))
 	for i, value := range this.tags {
 		field := value.Copy()
+		key := field.Name()
+		if tag, exists := values_[key]; exists {
+			if err := field.SetValue(tag.Value()); err != nil {
+				return nil, fmt.Errorf("Clone: %q: %w", key, err)
+			}
+		}
 		that.tags[i] = field
-		that.fields[field.Name()] = field
+		that.fields[key] = field
 	}
 
 	// Clone metrics and set new values
 	that.metrics = make([]gopi.Field, len(this.metrics))
 	for i, value := range this.metrics {
+		if f, ok := value.(*field); ok && f.kind.accumulates() {
+			continue
+		}
+		key := value.Name()
 		field := value.Copy()
-		key := field.Name()
 		if err := field.SetValue(values[i]); err != nil {
 			return nil, fmt.Errorf("Clone: %q: %w", key, err)
 		}
@@ -162,6 +209,11 @@ func (this *measurement) Clone(ts time.Time, tags []gopi.Field, values ...interf
 		that.fields[key] = field
 	}
 
+	// Accumulate counters, histograms and summaries for the tag values
+	if err := this.accumulate(that, values); err != nil {
+		return nil, err
+	}
+
 	// Return success
 	return that, nil
 }
@@ -195,6 +247,10 @@ func parseMetrics(src string) ([]gopi.Field, error) {
 	state := stateIdent
 	metrics := []gopi.Field{}
 
+	// Fields with the last kind, and bounds for histograms and summaries
+	last := []*field{}
+	bounds, bound := []float64{}, ""
+
 	// Scan tokens
 	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
 		value := s.TokenText()
@@ -203,6 +259,9 @@ func parseMetrics(src string) ([]gopi.Field, error) {
 			if value == "," {
 				state = stateIdent
 				break
+			} else if value == "(" && len(last) > 0 {
+				state = stateBounds
+				break
 			}
 			fallthrough
 		case stateIdent:
@@ -217,14 +276,39 @@ func parseMetrics(src string) ([]gopi.Field, error) {
 				state = stateIdent
 				break
 			}
+			last = last[:0]
 			for _, f := range metrics {
 				if f.Kind() == "nil" {
 					if err := f.(*field).SetKind(value); err != nil {
 						return nil, gopi.ErrBadParameter.WithPrefix(value)
 					}
+					last = append(last, f.(*field))
 				}
 			}
 			state = stateIdent2
+		case stateBounds:
+			// Bounds are numbers separated by commas, where negative
+			// numbers are scanned as two tokens
+			if value == "-" && bound == "" {
+				bound = value
+				break
+			} else if value != "," && value != ")" {
+				bound += value
+				break
+			} else if v, err := strconv.ParseFloat(bound, 64); err != nil {
+				return nil, gopi.ErrBadParameter.WithPrefix(bound)
+			} else {
+				bounds, bound = append(bounds, v), ""
+			}
+			if value == ")" {
+				for _, f := range last {
+					if err := f.SetBounds(bounds); err != nil {
+						return nil, err
+					}
+				}
+				last, bounds = last[:0], []float64{}
+				state = stateIdent2
+			}
 		default:
 			return nil, gopi.ErrInternalAppError
 		}
@@ -244,6 +328,79 @@ func parseMetrics(src string) ([]gopi.Field, error) {
 	return metrics, nil
 }
 
+// accumulate values for counters, histograms and summaries, which are kept
+// separately for each set of tag values, and sets the metrics in the cloned
+// measurement. The metrics in this measurement hold the values for the
+// default tag values. Values are accumulated in copies, which are kept only
+// when all values are valid
+func (this *measurement) accumulate(that *measurement, values []interface{}) error {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
+	key := tagKey(that.tags)
+	isDefault := key == tagKey(this.tags)
+	series, exists := this.series[key]
+	next, accumulated := make([]*field, len(this.metrics)), false
+	for i, value := range this.metrics {
+		f, ok := value.(*field)
+		if ok == false || f.kind.accumulates() == false {
+			continue
+		}
+		var acc *field
+		if isDefault {
+			acc = f.Copy().(*field)
+		} else if exists {
+			acc = series[i].Copy().(*field)
+		} else {
+			acc = f.reset()
+		}
+		if field, err := acc.Snapshot(values[i], that.ts); err != nil {
+			return fmt.Errorf("Clone: %q: %w", acc.Name(), err)
+		} else {
+			next[i], accumulated = acc, true
+			that.metrics[i] = field
+			that.fields[field.Name()] = field
+		}
+	}
+
+	// Keep the accumulated values
+	if accumulated == false {
+		return nil
+	} else if isDefault {
+		for i, acc := range next {
+			if acc != nil {
+				this.metrics[i].(*field).replace(acc)
+			}
+		}
+	} else {
+		if this.series == nil {
+			this.series = make(map[string][]*field)
+		}
+		this.series[key] = next
+	}
+
+	// Return success
+	return nil
+}
+
+// tagKey returns the names and values of tags as a string
+func tagKey(tags []gopi.Field) string {
+	key := ""
+	for _, tag := range tags {
+		key += tag.Name() + "=" + fmt.Sprint(tag.Value()) + "\x00"
+	}
+	return key
+}
+
+func (this *measurement) isTag(name string) bool {
+	for _, tag := range this.tags {
+		if tag.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
 func duplicateName(fields []gopi.Field) string {
 	m := make(map[string]bool, len(fields))
 	for _, field := range fields {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/djthorpe/gopi/v3"
 )
@@ -139,3 +140,43 @@ func Test_Measurement_007(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func Test_Measurement_008(t *testing.T) {
+	// Check tags are replaced when cloning
+	m, err := NewMeasurement("test", "m1 uint8", NewField("tag", "default"), NewField("host", "rpi4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if that, err := m.Clone(time.Time{}, []gopi.Field{NewField("tag", "value")}, uint8(1)); err != nil {
+		t.Error(err)
+	} else if that.Get("tag") != "value" || that.Get("host") != "rpi4" || that.Get("m1") != uint8(1) {
+		t.Error("Unexpected clone", that)
+	} else if m.Get("tag") != "default" {
+		t.Error("Unexpected change to measurement", m)
+	}
+	if _, err := m.Clone(time.Time{}, []gopi.Field{NewField("m1", "value")}, uint8(1)); errors.Is(err, gopi.ErrNotFound) == false {
+		t.Error("Expected error for undefined tag", err)
+	}
+}
+
+func Test_Measurement_009(t *testing.T) {
+	// Check a measurement with fields
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMeasurementWithFields("test", ts, []gopi.Field{NewField("host", "rpi4")}, []gopi.Field{NewField("value", 1.5), NewField("host", "other")})
+	if m.Name() != "test" || m.Time().Equal(ts) == false {
+		t.Error("Unexpected measurement", m)
+	} else if len(m.Tags()) != 1 || len(m.Metrics()) != 2 {
+		t.Error("Unexpected fields", m)
+	} else if m.Get("host") != "rpi4" || m.Get("value") != 1.5 || m.Get("other") != nil {
+		t.Error("Unexpected values", m)
+	}
+
+	// Check unknown fields cannot be set
+	if err := m.Set("value", 2.5); err != nil {
+		t.Error(err)
+	} else if m.Get("value") != 2.5 {
+		t.Error("Unexpected value", m.Get("value"))
+	} else if err := m.Set("other", 1); errors.Is(err, gopi.ErrNotFound) == false {
+		t.Error("Expected not found error, got", err)
+	}
+}
@@ -155,13 +155,13 @@ func Test_Metrics_008(t *testing.T) {
 func Test_Metrics_009(t *testing.T) {
 	tool.Test(t, nil, new(App), func(app *App) {
 		app.NewMeasurement("test", "a bool")
-		if err := app.Emit("test", true); err != nil {
+		if err := app.Emit("test", nil, true); err != nil {
 			t.Error(err)
 		}
-		if err := app.Emit("test", false); err != nil {
+		if err := app.Emit("test", nil, false); err != nil {
 			t.Error(err)
 		}
-		if err := app.Emit("test", 100); err == nil {
+		if err := app.Emit("test", nil, 100); err == nil {
 			t.Error("Expected error")
 		} else {
 			t.Log("Expected error", err)
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Query contains the parameters of a query for a measurement which are
+// common to metric readers, which embed it to implement gopi.MetricQuery.
+// Invalid parameters are retained in Err and returned when the query
+// is executed
+type Query struct {
+	Name     string
+	Group    []string   // Tags to group by
+	Fields   []string   // Metrics to select, or all metrics when empty
+	Tags     []QueryTag // Tag values to match, in the order they were added
+	From, To time.Time  // Time range, where a zero time is unbounded
+	Period   time.Duration
+	Fn       gopi.MetricAggregate
+	Interval time.Duration
+	Max      uint // Maximum number of data points for each group
+	Err      error
+}
+
+// QueryTag is the value of a tag which data points match
+type QueryTag struct {
+	Name, Value string
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// NEW
+
+// NewQuery returns query parameters for a measurement, grouped by tags
+func NewQuery(name string, tags ...string) Query {
+	this := Query{Name: name, Group: tags}
+	if name == "" {
+		this.Err = gopi.ErrBadParameter.WithPrefix("NewQuery: ", strconv.Quote(name))
+	}
+	return this
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// SetFields appends the names of metrics to select
+func (this *Query) SetFields(fields ...string) {
+	this.Fields = append(this.Fields, fields...)
+}
+
+// SetTag sets the value of a tag which data points match
+func (this *Query) SetTag(tag string, value interface{}) {
+	if tag == "" || value == nil {
+		this.Err = gopi.ErrBadParameter.WithPrefix("Where: ", strconv.Quote(tag))
+	} else {
+		this.Tags = append(this.Tags, QueryTag{tag, fmt.Sprint(value)})
+	}
+}
+
+// SetRange sets the time range from the first time until, but not
+// including, the second time
+func (this *Query) SetRange(from, to time.Time) {
+	if from.IsZero() == false && to.IsZero() == false && to.Before(from) {
+		this.Err = gopi.ErrBadParameter.WithPrefix("Between")
+	} else {
+		this.From, this.To, this.Period = from, to, 0
+	}
+}
+
+// SetPeriod sets the time range to a duration until now
+func (this *Query) SetPeriod(d time.Duration) {
+	if d <= 0 {
+		this.Err = gopi.ErrBadParameter.WithPrefix("Since")
+	} else {
+		this.From, this.To, this.Period = time.Time{}, time.Time{}, d
+	}
+}
+
+// SetAggregate sets the aggregate function and interval
+func (this *Query) SetAggregate(fn gopi.MetricAggregate, interval time.Duration) {
+	if fn > gopi.METRIC_AGGREGATE_LAST {
+		this.Err = gopi.ErrBadParameter.WithPrefix("Aggregate: ", fn)
+	} else if interval < 0 || (fn == gopi.METRIC_AGGREGATE_NONE && interval != 0) {
+		this.Err = gopi.ErrBadParameter.WithPrefix("Aggregate: ", interval)
+	} else {
+		this.Fn, this.Interval = fn, interval
+	}
+}
+
+// Bounds returns the time range for the query, where a zero
+// time is unbounded
+func (this *Query) Bounds(now time.Time) (time.Time, time.Time) {
+	if this.Period != 0 {
+		return now.Add(-this.Period), time.Time{}
+	} else {
+		return this.From, this.To
+	}
+}
+
+// Match returns true if the tags of a measurement have the
+// values in the query
+func (this *Query) Match(m gopi.Measurement) bool {
+	for _, want := range this.Tags {
+		matched := false
+		for _, tag := range m.Tags() {
+			if tag.Name() == want.Name && tag.IsNil() == false && fmt.Sprint(tag.Value()) == want.Value {
+				matched = true
+				break
+			}
+		}
+		if matched == false {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+func Test_Query_001(t *testing.T) {
+	if q := NewQuery(""); q.Err == nil {
+		t.Error("Expected error for empty name")
+	}
+	q := NewQuery("temp", "host")
+	q.SetAggregate(gopi.METRIC_AGGREGATE_NONE, time.Minute)
+	if q.Err == nil {
+		t.Error("Expected error for interval without aggregate")
+	}
+	q = NewQuery("temp")
+	q.SetPeriod(-time.Hour)
+	if q.Err == nil {
+		t.Error("Expected error for negative period")
+	}
+	q = NewQuery("temp")
+	ts := time.Now()
+	q.SetRange(ts, ts.Add(-time.Second))
+	if q.Err == nil {
+		t.Error("Expected error for range")
+	}
+	q = NewQuery("temp")
+	q.SetPeriod(time.Hour)
+	if from, to := q.Bounds(ts); from.Equal(ts.Add(-time.Hour)) == false || to.IsZero() == false {
+		t.Error("Unexpected bounds", from, to)
+	}
+}
+
+func Test_Query_002(t *testing.T) {
+	m, err := NewMeasurement("temp", "value float64", NewField("host", "rpi"), NewField("room", "hall"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewQuery("temp")
+	if q.Match(m) == false {
+		t.Error("Expected match without tags")
+	}
+	q.SetTag("host", "rpi")
+	if q.Match(m) == false {
+		t.Error("Expected match for host")
+	}
+	q.SetTag("room", "kitchen")
+	if q.Match(m) {
+		t.Error("Unexpected match for room")
+	}
+}
@@ -0,0 +1,438 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/event/codec"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+type bridge struct {
+	gopi.Unit
+	gopi.Logger
+	gopi.Publisher
+
+	// Devices which are controlled by command topics, when
+	// included in the application
+	gopi.RotelManager
+	gopi.CastManager
+	gopi.ArgonOne
+
+	// Flags
+	broker    *string
+	clientId  *string
+	user      *string
+	password  *string
+	prefix    *string
+	events    *string
+	qos       *uint
+	keepalive *time.Duration
+	ca        *string
+	cert      *string
+	key       *string
+	insecure  *bool
+
+	sync.RWMutex
+	url      *url.URL
+	tls      *tls.Config
+	names    map[string]bool
+	client   *client
+	handlers []handler
+	cmds     chan *publish
+}
+
+type handler struct {
+	topic string
+	fn    gopi.MQTTHandler
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	DefaultBroker    = "tcp://localhost:1883"
+	DefaultPrefix    = "gopi"
+	DefaultKeepalive = 30 * time.Second
+)
+
+const (
+	// Status topic, which is set to online when connected and
+	// offline by the will message
+	topicStatus   = "status"
+	statusOnline  = "online"
+	statusOffline = "offline"
+
+	// Timeout for connecting and acknowledgements
+	timeout = 10 * time.Second
+
+	// Backoff when reconnecting
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+
+	// Size of the queue for received commands
+	cmdQueueSize = 20
+)
+
+/////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func (this *bridge) Define(cfg gopi.Config) error {
+	this.broker = cfg.FlagString("mqtt.broker", DefaultBroker, "Broker URL (tcp://host:port or ssl://host:port)")
+	this.clientId = cfg.FlagString("mqtt.client", "", "Client identifier, defaults to gopi-<hostname>")
+	this.user = cfg.FlagString("mqtt.user", "", "User name")
+	this.password = cfg.FlagString("mqtt.password", "", "Password")
+	this.prefix = cfg.FlagString("mqtt.prefix", DefaultPrefix, "Prefix for topics")
+	this.events = cfg.FlagString("mqtt.events", "", "Comma-separated names of events to publish")
+	this.qos = cfg.FlagUint("mqtt.qos", 0, "Quality of service for published messages (0, 1)")
+	this.keepalive = cfg.FlagDuration("mqtt.keepalive", DefaultKeepalive, "Keepalive interval")
+	this.ca = cfg.FlagString("mqtt.ca", "", "Path to CA certificate for TLS")
+	this.cert = cfg.FlagString("mqtt.cert", "", "Path to client certificate for TLS")
+	this.key = cfg.FlagString("mqtt.key", "", "Path to client key for TLS")
+	this.insecure = cfg.FlagBool("mqtt.insecure", false, "Skip verification of broker certificate")
+	return nil
+}
+
+func (this *bridge) New(gopi.Config) error {
+	this.Require(this.Logger, this.Publisher)
+
+	// Set broker
+	if broker, err := url.Parse(*this.broker); err != nil {
+		return gopi.ErrBadParameter.WithPrefix("-mqtt.broker: ", err)
+	} else if broker.Host == "" {
+		return gopi.ErrBadParameter.WithPrefix("-mqtt.broker: ", *this.broker)
+	} else {
+		this.url = broker
+	}
+
+	// Set quality of service
+	if *this.qos > 1 {
+		return gopi.ErrBadParameter.WithPrefix("-mqtt.qos: ", *this.qos)
+	}
+
+	// Set client identifier
+	if *this.clientId == "" {
+		host, _ := os.Hostname()
+		*this.clientId = "gopi-" + strings.SplitN(host, ".", 2)[0]
+	}
+
+	// Set TLS configuration
+	if config, err := this.tlsConfig(); err != nil {
+		return err
+	} else {
+		this.tls = config
+	}
+
+	// Set names of events to publish
+	this.names = make(map[string]bool)
+	for _, name := range strings.Split(*this.events, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			this.names[name] = true
+		}
+	}
+
+	// Register command handlers for devices
+	this.cmds = make(chan *publish, cmdQueueSize)
+	this.handleDevices()
+
+	// Return success
+	return nil
+}
+
+func (this *bridge) Run(ctx context.Context) error {
+	ch := this.Publisher.Subscribe()
+	defer this.Publisher.Unsubscribe(ch)
+
+	delay := minBackoff
+	for {
+		// Connect to broker
+		client, err := this.connect(ctx)
+		if err != nil {
+			this.Print("MQTT: ", err, " (retrying in ", delay, ")")
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+			if delay *= 2; delay > maxBackoff {
+				delay = maxBackoff
+			}
+			continue
+		}
+
+		// Process events and commands until disconnected
+		delay = minBackoff
+		err = this.serve(ctx, client, ch)
+		this.setClient(nil)
+		if ctx.Err() != nil {
+			client.Publish(this.topic(topicStatus), []byte(statusOffline), 1, true)
+			client.Close()
+			return nil
+		}
+		client.Close()
+		this.Print("MQTT: Disconnected: ", err)
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *bridge) Publish(topic string, payload []byte, retain bool) error {
+	this.RLock()
+	client := this.client
+	this.RUnlock()
+	if client == nil {
+		return gopi.ErrOutOfOrder.WithPrefix("Publish: Not connected")
+	}
+	return client.Publish(this.topic(topic), payload, byte(*this.qos), retain)
+}
+
+func (this *bridge) Handle(topic string, fn gopi.MQTTHandler) error {
+	if topic == "" || fn == nil {
+		return gopi.ErrBadParameter.WithPrefix("Handle")
+	}
+
+	this.Lock()
+	defer this.Unlock()
+
+	// Replace an existing handler
+	for i := range this.handlers {
+		if this.handlers[i].topic == topic {
+			this.handlers[i].fn = fn
+			return nil
+		}
+	}
+	this.handlers = append(this.handlers, handler{topic, fn})
+
+	// Subscribe when already connected
+	if this.client != nil {
+		go func(client *client) {
+			if err := client.Subscribe([]string{this.topic(topic)}, 1); err != nil {
+				this.Print("MQTT: Subscribe: ", err)
+			}
+		}(this.client)
+	}
+
+	// Return success
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// connect returns a client, after publishing the online status,
+// subscribing to command topics and publishing device state
+func (this *bridge) connect(ctx context.Context) (*client, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := dial(ctx, this.url, this.tls)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClient(conn, &connect{
+		clientId:  *this.clientId,
+		keepalive: uint16(*this.keepalive / time.Second),
+		user:      *this.user,
+		password:  *this.password,
+		will:      &will{this.topic(topicStatus), []byte(statusOffline), 1, true},
+	}, timeout, this.receive)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Publish status and subscribe to command topics
+	if err := client.Publish(this.topic(topicStatus), []byte(statusOnline), 1, true); err != nil {
+		client.Close()
+		return nil, err
+	} else if err := client.Subscribe(this.commandTopics(), 1); err != nil {
+		client.Close()
+		return nil, err
+	}
+	this.setClient(client)
+	this.Debug("MQTT: Connected to ", this.url.Host)
+
+	// Publish current state of devices
+	this.publishState(ctx)
+
+	// Return success
+	return client, nil
+}
+
+// serve publishes events and calls command handlers until the context
+// is cancelled or the connection ends
+func (this *bridge) serve(ctx context.Context, client *client, ch <-chan gopi.Event) error {
+	var tick <-chan time.Time
+	if *this.keepalive > 0 {
+		ticker := time.NewTicker(*this.keepalive / 2)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-client.Done():
+			return client.Err()
+		case <-tick:
+			if err := client.Ping(); err != nil {
+				return err
+			}
+		case evt := <-ch:
+			if err := this.publishEvent(evt); err != nil {
+				this.Print("MQTT: ", evt.Name(), ": ", err)
+			}
+		case msg := <-this.cmds:
+			if err := this.command(ctx, msg); err != nil {
+				this.Print("MQTT: ", msg.topic, ": ", err)
+			}
+		}
+	}
+}
+
+// receive queues a message from the broker. It is called from the read
+// loop of the client, so does not block
+func (this *bridge) receive(msg *publish) {
+	select {
+	case this.cmds <- msg:
+	default:
+		this.Print("MQTT: Dropped message on ", msg.topic)
+	}
+}
+
+// command calls the handlers which match a message topic
+func (this *bridge) command(ctx context.Context, msg *publish) error {
+	topic := strings.TrimPrefix(msg.topic, this.topic(""))
+
+	this.RLock()
+	handlers := make([]gopi.MQTTHandler, 0, 1)
+	for _, h := range this.handlers {
+		if topicMatches(h.topic, topic) {
+			handlers = append(handlers, h.fn)
+		}
+	}
+	this.RUnlock()
+
+	var result error
+	for _, fn := range handlers {
+		if err := fn(ctx, topic, msg.payload); err != nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// publishEvent publishes measurements, device state and selected events
+func (this *bridge) publishEvent(evt gopi.Event) error {
+	switch evt := evt.(type) {
+	case gopi.Measurement:
+		if data, err := measurementPayload(evt); err != nil {
+			return err
+		} else {
+			return this.Publish("measurement/"+topicName(evt.Name()), data, false)
+		}
+	case gopi.RotelEvent:
+		if err := this.publishRotel(evt, evt.Flags()); err != nil {
+			return err
+		}
+	case gopi.CastEvent:
+		if err := this.publishCast(evt.Cast(), evt.Flags()); err != nil {
+			return err
+		}
+	}
+	if this.names[evt.Name()] {
+		if name, data, err := codec.Encode(evt); errors.Is(err, gopi.ErrNotImplemented) {
+			return nil
+		} else if err != nil {
+			return err
+		} else {
+			return this.Publish("event/"+name+"/"+topicName(evt.Name()), data, false)
+		}
+	}
+	return nil
+}
+
+func (this *bridge) commandTopics() []string {
+	this.RLock()
+	defer this.RUnlock()
+	topics := make([]string, 0, len(this.handlers))
+	for _, h := range this.handlers {
+		topics = append(topics, this.topic(h.topic))
+	}
+	return topics
+}
+
+func (this *bridge) setClient(client *client) {
+	this.Lock()
+	defer this.Unlock()
+	this.client = client
+}
+
+// topic returns a topic with the prefix
+func (this *bridge) topic(topic string) string {
+	if prefix := strings.Trim(*this.prefix, "/"); prefix == "" {
+		return topic
+	} else {
+		return prefix + "/" + topic
+	}
+}
+
+func (this *bridge) tlsConfig() (*tls.Config, error) {
+	if *this.ca == "" && *this.cert == "" && *this.insecure == false {
+		return nil, nil
+	}
+	config := &tls.Config{
+		InsecureSkipVerify: *this.insecure,
+	}
+	if *this.ca != "" {
+		pool := x509.NewCertPool()
+		if data, err := ioutil.ReadFile(*this.ca); err != nil {
+			return nil, err
+		} else if pool.AppendCertsFromPEM(data) == false {
+			return nil, gopi.ErrBadParameter.WithPrefix("-mqtt.ca: ", *this.ca)
+		}
+		config.RootCAs = pool
+	}
+	if *this.cert != "" {
+		if cert, err := tls.LoadX509KeyPair(*this.cert, *this.key); err != nil {
+			return nil, err
+		} else {
+			config.Certificates = []tls.Certificate{cert}
+		}
+	}
+	return config, nil
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *bridge) String() string {
+	str := "<mqtt"
+	if this.url != nil {
+		str += " broker=" + strconv.Quote(this.url.String())
+	}
+	if this.prefix != nil {
+		str += " prefix=" + strconv.Quote(*this.prefix)
+	}
+	this.RLock()
+	if this.client != nil {
+		str += " connected"
+	}
+	this.RUnlock()
+	return str + ">"
+}
@@ -0,0 +1,288 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+	"github.com/djthorpe/gopi/v3/pkg/metrics"
+	"github.com/djthorpe/gopi/v3/pkg/tool"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type App struct {
+	gopi.Unit
+	gopi.Publisher
+	gopi.MQTTBridge
+}
+
+// fakeRotel returns amplifier state and records the volume
+type fakeRotel struct {
+	gopi.RotelManager
+	sync.Mutex
+	volume uint
+}
+
+// observer subscribes to topics on a broker and receives messages
+type observer struct {
+	*client
+	ch chan *publish
+}
+
+func (app *App) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (this *fakeRotel) Power() bool             { return true }
+func (this *fakeRotel) Source() string          { return "cd" }
+func (this *fakeRotel) Freq() string            { return "" }
+func (this *fakeRotel) Muted() bool             { return false }
+func (this *fakeRotel) Bypass() bool            { return false }
+func (this *fakeRotel) Bass() int               { return 0 }
+func (this *fakeRotel) Treble() int             { return 0 }
+func (this *fakeRotel) Balance() (string, uint) { return "", 0 }
+func (this *fakeRotel) Speakers() []string      { return []string{"A"} }
+func (this *fakeRotel) Dimmer() uint            { return 0 }
+
+func (this *fakeRotel) Volume() uint {
+	this.Lock()
+	defer this.Unlock()
+	return this.volume
+}
+
+func (this *fakeRotel) SetVolume(value uint) error {
+	this.Lock()
+	defer this.Unlock()
+	this.volume = value
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Topic_001(t *testing.T) {
+	tests := []struct {
+		filter, topic string
+		match         bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/c", false},
+		{"a/+", "a/b", true},
+		{"a/+", "a/b/c", false},
+		{"a/#", "a/b/c", true},
+		{"#", "a", true},
+		{"a/+/c", "a/b/c", true},
+		{"a/b/c", "a/b", false},
+	}
+	for _, test := range tests {
+		if match := topicMatches(test.filter, test.topic); match != test.match {
+			t.Error("Unexpected match", test.filter, test.topic, match)
+		}
+	}
+	if name := topicName(" Living Room/TV+# "); name != "Living Room_TV__" {
+		t.Error("Unexpected name", name)
+	}
+}
+
+func Test_Client_001(t *testing.T) {
+	b := newBroker(t)
+	defer b.Close()
+
+	o := newObserver(t, b, "test/#")
+	defer o.Close()
+	if err := o.Publish("test/a", []byte("hello"), 1, true); err != nil {
+		t.Error(err)
+	} else if msg := o.next(t, "test/a"); string(msg.payload) != "hello" {
+		t.Error("Unexpected payload", string(msg.payload))
+	}
+	if err := o.Ping(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Client_002(t *testing.T) {
+	// The server acknowledges the connection but does not respond to PINGREQ
+	server, conn := net.Pipe()
+	defer server.Close()
+	go func() {
+		r := bufio.NewReader(server)
+		if _, err := readPacket(r); err != nil {
+			return
+		}
+		(&packet{pktConnack, 0, []byte{0, 0}}).write(server)
+		for {
+			if _, err := readPacket(r); err != nil {
+				return
+			}
+		}
+	}()
+
+	client, err := newClient(conn, &connect{clientId: t.Name(), keepalive: 1}, time.Second, func(*publish) {})
+	if err != nil {
+		t.Fatal(err)
+	} else if err := client.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-client.Done():
+		if errors.Is(client.Err(), gopi.ErrUnexpectedResponse) == false {
+			t.Error("Unexpected error", client.Err())
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("Timeout waiting for connection to close")
+	}
+}
+
+func Test_Bridge_001(t *testing.T) {
+	b := newBroker(t)
+	defer b.Close()
+
+	args := []string{"-mqtt.broker", b.URL()}
+	tool.Test(t, args, new(App), func(app *App) {
+		if app.MQTTBridge == nil {
+			t.Fatal("nil MQTTBridge unit")
+		} else {
+			t.Log(app.MQTTBridge)
+		}
+
+		// Wait for status
+		o := newObserver(t, b, "gopi/#")
+		defer o.Close()
+		o.expect(t, "gopi/status", statusOnline)
+
+		// Publish a measurement
+		m, err := metrics.NewMeasurement("test", "value uint8", metrics.NewField("host", "pi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Set("value", uint8(42))
+		app.Publisher.Emit(m, true)
+
+		var result measurementData
+		msg := o.next(t, "gopi/measurement/test")
+		if err := json.Unmarshal(msg.payload, &result); err != nil {
+			t.Error(err)
+		} else if result.Tags["host"] != "pi" || result.Metrics["value"] != float64(42) {
+			t.Error("Unexpected measurement", string(msg.payload))
+		}
+	})
+}
+
+func Test_Bridge_002(t *testing.T) {
+	b := newBroker(t)
+	defer b.Close()
+
+	rotel := &fakeRotel{volume: 10}
+	args := []string{"-mqtt.broker", b.URL()}
+	tool.Test(t, args, new(App), func(app *App) {
+		// Retained amplifier state is published on connect
+		o := newObserver(t, b, "gopi/rotel/+")
+		defer o.Close()
+		o.expect(t, "gopi/rotel/volume", "10")
+
+		// Set volume with a command topic
+		if err := o.Publish("gopi/rotel/volume/set", []byte("20"), 1, false); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for rotel.Volume() != 20 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if volume := rotel.Volume(); volume != 20 {
+			t.Error("Unexpected volume", volume)
+		}
+	}, tool.WithUnit((*gopi.RotelManager)(nil), rotel))
+}
+
+func Test_Bridge_003(t *testing.T) {
+	b := newBroker(t)
+	defer b.Close()
+
+	// A client which disconnects without DISCONNECT has the will
+	// message published
+	broker, _ := url.Parse(b.URL())
+	conn, err := dial(context.Background(), broker, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newClient(conn, &connect{
+		clientId: "will",
+		will:     &will{"gopi/status", []byte(statusOffline), 1, true},
+	}, timeout, func(*publish) {})
+	if err != nil {
+		t.Fatal(err)
+	} else if err := c.Publish("gopi/status", []byte(statusOnline), 1, true); err != nil {
+		t.Fatal(err)
+	}
+	c.conn.(*net.TCPConn).SetLinger(0)
+	c.conn.Close()
+	<-c.Done()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if value, _ := b.Retained("gopi/status"); string(value) == statusOffline {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected will message")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func newObserver(t *testing.T, b *broker, topic string) *observer {
+	t.Helper()
+	broker, _ := url.Parse(b.URL())
+	conn, err := dial(context.Background(), broker, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &observer{ch: make(chan *publish, 100)}
+	if c, err := newClient(conn, &connect{clientId: "observer"}, timeout, func(msg *publish) {
+		o.ch <- msg
+	}); err != nil {
+		t.Fatal(err)
+	} else {
+		o.client = c
+	}
+	if err := o.Subscribe([]string{topic}, 1); err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+// next returns the next message on a topic
+func (o *observer) next(t *testing.T, topic string) *publish {
+	t.Helper()
+	return o.expect(t, topic, "")
+}
+
+// expect returns the next message on a topic with a payload, or with
+// any payload when empty
+func (o *observer) expect(t *testing.T, topic, payload string) *publish {
+	t.Helper()
+	timer := time.NewTimer(2 * time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case msg := <-o.ch:
+			if msg.topic == topic && (payload == "" || string(msg.payload) == payload) {
+				return msg
+			}
+		case <-timer.C:
+			t.Fatal("Timeout waiting for", topic, payload)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package mqtt
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// broker is an in-process broker which supports QoS 0 and 1, retained
+// messages and will messages for MQTT 3.1.1
+type broker struct {
+	sync.Mutex
+	net.Listener
+	t        *testing.T
+	retained map[string][]byte
+	sessions map[*session]bool
+}
+
+type session struct {
+	sync.Mutex
+	conn    net.Conn
+	filters []string
+	will    *will
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// BROKER
+
+func newBroker(t *testing.T) *broker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	this := &broker{
+		Listener: listener,
+		t:        t,
+		retained: make(map[string][]byte),
+		sessions: make(map[*session]bool),
+	}
+	go this.accept()
+	return this
+}
+
+func (this *broker) URL() string {
+	return "tcp://" + this.Addr().String()
+}
+
+// Retained returns a retained message
+func (this *broker) Retained(topic string) ([]byte, bool) {
+	this.Lock()
+	defer this.Unlock()
+	value, exists := this.retained[topic]
+	return value, exists
+}
+
+func (this *broker) accept() {
+	for {
+		conn, err := this.Accept()
+		if err != nil {
+			return
+		}
+		go this.serve(conn)
+	}
+}
+
+func (this *broker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	// Read CONNECT
+	p, err := readPacket(r)
+	if err != nil || p.t != pktConnect {
+		this.t.Log("broker: expected CONNECT:", err)
+		return
+	}
+	s, err := parseConnect(p)
+	if err != nil {
+		this.t.Log("broker:", err)
+		return
+	}
+	s.conn = conn
+	s.send(&packet{pktConnack, 0, []byte{0, 0}})
+
+	this.Lock()
+	this.sessions[s] = true
+	this.Unlock()
+
+	// Process packets until disconnect or error
+	clean := false
+	for clean == false {
+		p, err := readPacket(r)
+		if err != nil {
+			break
+		}
+		switch p.t {
+		case pktPublish:
+			if msg, err := parsePublish(p); err != nil {
+				this.t.Log("broker:", err)
+			} else {
+				if msg.qos > 0 {
+					s.send(pubackPacket(msg.id))
+				}
+				this.publish(msg.topic, msg.payload, msg.retain)
+			}
+		case pktSubscribe:
+			this.subscribe(s, p)
+		case pktPingreq:
+			s.send(&packet{pktPingresp, 0, nil})
+		case pktDisconnect:
+			clean = true
+		}
+	}
+
+	// Remove session and publish will message
+	this.Lock()
+	delete(this.sessions, s)
+	this.Unlock()
+	if clean == false && s.will != nil {
+		this.publish(s.will.topic, s.will.payload, s.will.retain)
+	}
+}
+
+func (this *broker) publish(topic string, payload []byte, retain bool) {
+	this.Lock()
+	if retain {
+		this.retained[topic] = payload
+	}
+	sessions := make([]*session, 0, len(this.sessions))
+	for s := range this.sessions {
+		sessions = append(sessions, s)
+	}
+	this.Unlock()
+
+	for _, s := range sessions {
+		if s.matches(topic) {
+			s.send(publishPacket(topic, payload, 0, false, 0))
+		}
+	}
+}
+
+func (this *broker) subscribe(s *session, p *packet) {
+	r := &reader{data: p.data}
+	id := r.uint16()
+	codes := []byte{}
+	filters := []string{}
+	for r.err == nil && len(r.data) > 0 {
+		filter := r.string()
+		qos := r.byte() & 0x03
+		filters = append(filters, filter)
+		codes = append(codes, qos)
+	}
+	if r.err != nil {
+		this.t.Log("broker: SUBSCRIBE:", r.err)
+		return
+	}
+
+	s.Lock()
+	s.filters = append(s.filters, filters...)
+	s.Unlock()
+
+	data := appendUint16(nil, id)
+	s.send(&packet{pktSuback, 0, append(data, codes...)})
+
+	// Send retained messages
+	this.Lock()
+	defer this.Unlock()
+	for topic, payload := range this.retained {
+		for _, filter := range filters {
+			if topicMatches(filter, topic) {
+				s.send(publishPacket(topic, payload, 0, true, 0))
+				break
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SESSION
+
+func parseConnect(p *packet) (*session, error) {
+	r := &reader{data: p.data}
+	s := new(session)
+	if name := r.string(); name != "MQTT" {
+		return nil, gopi.ErrUnexpectedResponse.WithPrefix("Protocol: ", name)
+	}
+	if level := r.byte(); level != protocolLevel {
+		return nil, gopi.ErrUnexpectedResponse.WithPrefix("Protocol level: ", level)
+	}
+	flags := r.byte()
+	r.uint16()
+	r.string()
+	if flags&0x04 != 0 {
+		s.will = &will{
+			topic:   r.string(),
+			payload: r.bytes(),
+			qos:     (flags >> 3) & 0x03,
+			retain:  flags&0x20 != 0,
+		}
+	}
+	if flags&0x80 != 0 {
+		r.string()
+	}
+	if flags&0x40 != 0 {
+		r.string()
+	}
+	return s, r.err
+}
+
+func (s *session) send(p *packet) {
+	s.Lock()
+	defer s.Unlock()
+	p.write(s.conn)
+}
+
+func (s *session) matches(topic string) bool {
+	s.Lock()
+	defer s.Unlock()
+	for _, filter := range s.filters {
+		if topicMatches(filter, topic) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,324 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// client is a connection to a broker. Received messages are passed
+// to a handler, which is called from the read loop and so should not
+// block or wait for acknowledgements
+type client struct {
+	sync.Mutex
+	conn      net.Conn
+	r         *bufio.Reader
+	timeout   time.Duration
+	keepalive time.Duration
+	handler   func(*publish)
+
+	id   uint16
+	acks map[uint16]chan *packet
+	ping time.Time // Time of PINGREQ without a PINGRESP
+	done chan struct{}
+	err  error
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	schemeTCP  = "tcp"
+	schemeMQTT = "mqtt"
+	schemeSSL  = "ssl"
+	schemeTLS  = "tls"
+	schemeMQTS = "mqtts"
+)
+
+const (
+	defaultPort    = "1883"
+	defaultTLSPort = "8883"
+)
+
+/////////////////////////////////////////////////////////////////////
+// NEW
+
+// dial connects to a broker with a URL of the form tcp://host:port
+// or ssl://host:port, where TLS is used for ssl, tls and mqtts schemes
+func dial(ctx context.Context, broker *url.URL, config *tls.Config) (net.Conn, error) {
+	var dialer net.Dialer
+	switch broker.Scheme {
+	case schemeTCP, schemeMQTT, "":
+		return dialer.DialContext(ctx, "tcp", hostPort(broker.Host, defaultPort))
+	case schemeSSL, schemeTLS, schemeMQTS:
+		conn, err := dialer.DialContext(ctx, "tcp", hostPort(broker.Host, defaultTLSPort))
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			config = &tls.Config{}
+		} else {
+			config = config.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName = broker.Hostname()
+		}
+		tlsconn := tls.Client(conn, config)
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		if err := tlsconn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn.SetDeadline(time.Time{})
+		return tlsconn, nil
+	default:
+		return nil, gopi.ErrBadParameter.WithPrefix("Unsupported scheme: ", broker.Scheme)
+	}
+}
+
+// newClient sends CONNECT and waits for CONNACK, then starts reading
+// packets in the background
+func newClient(conn net.Conn, c *connect, timeout time.Duration, handler func(*publish)) (*client, error) {
+	this := new(client)
+	this.conn = conn
+	this.r = bufio.NewReader(conn)
+	this.timeout = timeout
+	this.keepalive = time.Duration(c.keepalive) * time.Second
+	this.handler = handler
+	this.acks = make(map[uint16]chan *packet)
+	this.done = make(chan struct{})
+
+	// Connect
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := connectPacket(c).write(conn); err != nil {
+		return nil, err
+	} else if p, err := readPacket(this.r); err != nil {
+		return nil, err
+	} else if code, err := parseConnack(p); err != nil {
+		return nil, err
+	} else if code != 0 {
+		return nil, gopi.ErrUnexpectedResponse.WithPrefix("CONNACK: ", connackReason(code))
+	}
+	conn.SetDeadline(time.Time{})
+
+	// Read packets in the background
+	go this.read()
+
+	// Return success
+	return this, nil
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Done returns a channel which is closed when the connection ends
+func (this *client) Done() <-chan struct{} {
+	return this.done
+}
+
+// Err returns the error which ended the connection
+func (this *client) Err() error {
+	this.Lock()
+	defer this.Unlock()
+	return this.err
+}
+
+// Publish sends a message, and waits for the acknowledgement when
+// qos is 1
+func (this *client) Publish(topic string, payload []byte, qos byte, retain bool) error {
+	if qos == 0 {
+		return this.write(publishPacket(topic, payload, 0, retain, 0))
+	}
+	id, ch := this.newId()
+	defer this.releaseId(id)
+	if err := this.write(publishPacket(topic, payload, qos, retain, id)); err != nil {
+		return err
+	}
+	_, err := this.wait(ch)
+	return err
+}
+
+// Subscribe to topics, and wait for the acknowledgement
+func (this *client) Subscribe(topics []string, qos byte) error {
+	if len(topics) == 0 {
+		return nil
+	}
+	id, ch := this.newId()
+	defer this.releaseId(id)
+	if err := this.write(subscribePacket(id, topics, qos)); err != nil {
+		return err
+	} else if p, err := this.wait(ch); err != nil {
+		return err
+	} else {
+		return parseSuback(p)
+	}
+}
+
+// Ping sends a keepalive. The connection is closed when there is no
+// response within one and a half times the keepalive interval, so that
+// a connection which has been lost without being closed is detected
+func (this *client) Ping() error {
+	if err := this.write(pingPacket()); err != nil {
+		return err
+	}
+
+	this.Lock()
+	defer this.Unlock()
+	if this.ping.IsZero() && this.keepalive > 0 {
+		this.ping = time.Now()
+		time.AfterFunc(this.keepalive*3/2, this.expire)
+	}
+
+	// Return success
+	return nil
+}
+
+// Close sends DISCONNECT, so the broker does not publish the will
+// message, and closes the connection
+func (this *client) Close() error {
+	this.write(disconnectPacket())
+	err := this.conn.Close()
+	<-this.done
+	return err
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (this *client) write(p *packet) error {
+	this.Lock()
+	defer this.Unlock()
+	if this.err != nil {
+		return this.err
+	}
+	this.conn.SetWriteDeadline(time.Now().Add(this.timeout))
+	return p.write(this.conn)
+}
+
+// newId returns an unused packet identifier and a channel on which
+// the acknowledgement is received
+func (this *client) newId() (uint16, chan *packet) {
+	this.Lock()
+	defer this.Unlock()
+	for {
+		this.id++
+		if this.id == 0 {
+			continue
+		} else if _, exists := this.acks[this.id]; exists {
+			continue
+		}
+		ch := make(chan *packet, 1)
+		this.acks[this.id] = ch
+		return this.id, ch
+	}
+}
+
+func (this *client) releaseId(id uint16) {
+	this.Lock()
+	defer this.Unlock()
+	delete(this.acks, id)
+}
+
+// expire closes the connection if there has been no response to a
+// keepalive within one and a half times the keepalive interval
+func (this *client) expire() {
+	this.Lock()
+	expired := this.ping.IsZero() == false && time.Since(this.ping) >= this.keepalive*3/2
+	if expired && this.err == nil {
+		this.err = gopi.ErrUnexpectedResponse.WithPrefix("No PINGRESP within ", this.keepalive*3/2)
+	}
+	this.Unlock()
+	if expired {
+		this.conn.Close()
+	}
+}
+
+func (this *client) wait(ch chan *packet) (*packet, error) {
+	timer := time.NewTimer(this.timeout)
+	defer timer.Stop()
+	select {
+	case p := <-ch:
+		return p, nil
+	case <-this.done:
+		return nil, this.Err()
+	case <-timer.C:
+		return nil, context.DeadlineExceeded
+	}
+}
+
+// read receives packets until the connection is closed
+func (this *client) read() {
+	defer close(this.done)
+	for {
+		p, err := readPacket(this.r)
+		if err != nil {
+			this.Lock()
+			if this.err == nil {
+				this.err = err
+			}
+			this.Unlock()
+			return
+		}
+		switch p.t {
+		case pktPublish:
+			if msg, err := parsePublish(p); err == nil {
+				if msg.qos > 0 {
+					this.write(pubackPacket(msg.id))
+				}
+				this.handler(msg)
+			}
+		case pktPuback, pktSuback, pktUnsuback:
+			if id, err := parseAck(p); err == nil {
+				this.Lock()
+				if ch, exists := this.acks[id]; exists {
+					select {
+					case ch <- p:
+					default:
+					}
+				}
+				this.Unlock()
+			}
+		case pktPingresp:
+			this.Lock()
+			this.ping = time.Time{}
+			this.Unlock()
+		}
+	}
+}
+
+func hostPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	} else {
+		return net.JoinHostPort(host, port)
+	}
+}
+
+// connackReason returns a description of a CONNACK return code
+func connackReason(code byte) string {
+	switch code {
+	case 0x01:
+		return "Unsupported protocol version"
+	case 0x02:
+		return "Client identifier not valid"
+	case 0x03:
+		return "Server unavailable"
+	case 0x04:
+		return "Bad user name or password"
+	case 0x05:
+		return "Not authorized"
+	default:
+		return "Connection refused"
+	}
+}
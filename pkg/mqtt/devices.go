@@ -0,0 +1,327 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// rotelState is implemented by the amplifier manager and by events
+// which embed amplifier state
+type rotelState interface {
+	Power() bool
+	Source() string
+	Freq() string
+	Volume() uint
+	Muted() bool
+	Bypass() bool
+	Bass() int
+	Treble() int
+	Balance() (string, uint)
+	Speakers() []string
+	Dimmer() uint
+}
+
+// measurementData is the JSON payload published for a measurement
+type measurementData struct {
+	Ts      time.Time              `json:"ts"`
+	Tags    map[string]interface{} `json:"tags,omitempty"`
+	Metrics map[string]interface{} `json:"metrics"`
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	topicRotel    = "rotel"
+	topicCast     = "cast"
+	topicArgonOne = "argonone"
+	topicSet      = "set"
+)
+
+/////////////////////////////////////////////////////////////////////
+// COMMANDS
+
+// handleDevices registers command handlers for devices which are
+// included in the application
+func (this *bridge) handleDevices() {
+	if this.RotelManager != nil {
+		this.Handle(topicRotel+"/+/"+topicSet, this.rotelCommand)
+	}
+	if this.CastManager != nil {
+		this.Handle(topicCast+"/+/+/"+topicSet, this.castCommand)
+	}
+	if this.ArgonOne != nil {
+		this.Handle(topicArgonOne+"/fan/"+topicSet, this.fanCommand)
+	}
+}
+
+// rotelCommand handles rotel/<property>/set
+func (this *bridge) rotelCommand(_ context.Context, topic string, payload []byte) error {
+	value := strings.TrimSpace(string(payload))
+	switch prop := strings.Split(topic, "/")[1]; prop {
+	case "power":
+		if v, err := parseBool(value); err != nil {
+			return err
+		} else {
+			return this.RotelManager.SetPower(v)
+		}
+	case "volume":
+		if v, err := strconv.ParseUint(value, 10, 32); err != nil {
+			return gopi.ErrBadParameter.WithPrefix(prop, ": ", value)
+		} else {
+			return this.RotelManager.SetVolume(uint(v))
+		}
+	case "mute":
+		if v, err := parseBool(value); err != nil {
+			return err
+		} else {
+			return this.RotelManager.SetMute(v)
+		}
+	case "bypass":
+		if v, err := parseBool(value); err != nil {
+			return err
+		} else {
+			return this.RotelManager.SetBypass(v)
+		}
+	case "bass":
+		if v, err := strconv.ParseInt(value, 10, 32); err != nil {
+			return gopi.ErrBadParameter.WithPrefix(prop, ": ", value)
+		} else {
+			return this.RotelManager.SetBass(int(v))
+		}
+	case "treble":
+		if v, err := strconv.ParseInt(value, 10, 32); err != nil {
+			return gopi.ErrBadParameter.WithPrefix(prop, ": ", value)
+		} else {
+			return this.RotelManager.SetTreble(int(v))
+		}
+	case "dimmer":
+		if v, err := strconv.ParseUint(value, 10, 32); err != nil {
+			return gopi.ErrBadParameter.WithPrefix(prop, ": ", value)
+		} else {
+			return this.RotelManager.SetDimmer(uint(v))
+		}
+	case "source":
+		return this.RotelManager.SetSource(value)
+	case "balance":
+		return this.RotelManager.SetBalance(value)
+	default:
+		return gopi.ErrNotImplemented.WithPrefix(topic)
+	}
+}
+
+// castCommand handles cast/<name>/volume/set and cast/<name>/muted/set
+func (this *bridge) castCommand(ctx context.Context, topic string, payload []byte) error {
+	parts := strings.Split(topic, "/")
+	cast, err := this.castWithName(ctx, parts[1])
+	if err != nil {
+		return err
+	}
+	value := strings.TrimSpace(string(payload))
+	switch parts[2] {
+	case "volume":
+		if v, err := strconv.ParseFloat(value, 32); err != nil || v < 0 || v > 1 {
+			return gopi.ErrBadParameter.WithPrefix(parts[2], ": ", value)
+		} else {
+			return this.CastManager.SetVolume(ctx, cast, float32(v))
+		}
+	case "muted":
+		if v, err := parseBool(value); err != nil {
+			return err
+		} else {
+			return this.CastManager.SetMuted(ctx, cast, v)
+		}
+	default:
+		return gopi.ErrNotImplemented.WithPrefix(topic)
+	}
+}
+
+// fanCommand handles argonone/fan/set with a duty cycle between 0 and 100
+func (this *bridge) fanCommand(_ context.Context, topic string, payload []byte) error {
+	value := strings.TrimSpace(string(payload))
+	if v, err := strconv.ParseUint(value, 10, 8); err != nil || v > 100 {
+		return gopi.ErrBadParameter.WithPrefix("fan: ", value)
+	} else {
+		return this.ArgonOne.SetFan(uint8(v))
+	}
+}
+
+// castWithName returns a cast device by id or name, or by the name
+// used in topics
+func (this *bridge) castWithName(ctx context.Context, name string) (gopi.Cast, error) {
+	if cast := this.CastManager.Get(name); cast != nil {
+		return cast, nil
+	}
+	casts, err := this.CastManager.Devices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, cast := range casts {
+		if castName(cast) == name {
+			return cast, nil
+		}
+	}
+	return nil, gopi.ErrNotFound.WithPrefix(name)
+}
+
+/////////////////////////////////////////////////////////////////////
+// STATE
+
+// publishState publishes the current state of devices as
+// retained messages
+func (this *bridge) publishState(ctx context.Context) {
+	if this.RotelManager != nil {
+		if err := this.publishRotel(this.RotelManager, ^gopi.ROTEL_FLAG_NONE); err != nil {
+			this.Print("MQTT: ", err)
+		}
+	}
+	if this.CastManager != nil {
+		casts, err := this.CastManager.Devices(ctx)
+		if err != nil {
+			this.Print("MQTT: ", err)
+		}
+		for _, cast := range casts {
+			if err := this.publishCast(cast, ^gopi.CAST_FLAG_NONE); err != nil {
+				this.Print("MQTT: ", err)
+			}
+		}
+	}
+}
+
+// publishRotel publishes amplifier properties which have changed. State
+// is read from the event when it includes it, or else from the manager
+func (this *bridge) publishRotel(evt interface{}, flags gopi.RotelFlag) error {
+	state, ok := evt.(rotelState)
+	if !ok && this.RotelManager != nil {
+		state = this.RotelManager
+	} else if !ok {
+		return nil
+	}
+
+	for f := gopi.ROTEL_FLAG_MIN; f <= gopi.ROTEL_FLAG_MAX; f <<= 1 {
+		if flags&f == 0 {
+			continue
+		}
+		var prop, value string
+		switch f {
+		case gopi.ROTEL_FLAG_POWER:
+			prop, value = "power", formatBool(state.Power())
+		case gopi.ROTEL_FLAG_VOLUME:
+			prop, value = "volume", fmt.Sprint(state.Volume())
+		case gopi.ROTEL_FLAG_MUTE:
+			prop, value = "mute", formatBool(state.Muted())
+		case gopi.ROTEL_FLAG_BASS:
+			prop, value = "bass", fmt.Sprint(state.Bass())
+		case gopi.ROTEL_FLAG_TREBLE:
+			prop, value = "treble", fmt.Sprint(state.Treble())
+		case gopi.ROTEL_FLAG_BALANCE:
+			if balance, scalar := state.Balance(); balance == "" || scalar == 0 {
+				prop, value = "balance", "0"
+			} else {
+				prop, value = "balance", fmt.Sprint(balance, scalar)
+			}
+		case gopi.ROTEL_FLAG_SOURCE:
+			prop, value = "source", state.Source()
+		case gopi.ROTEL_FLAG_FREQ:
+			prop, value = "freq", state.Freq()
+		case gopi.ROTEL_FLAG_BYPASS:
+			prop, value = "bypass", formatBool(state.Bypass())
+		case gopi.ROTEL_FLAG_SPEAKER:
+			prop, value = "speakers", strings.Join(state.Speakers(), ",")
+		case gopi.ROTEL_FLAG_DIMMER:
+			prop, value = "dimmer", fmt.Sprint(state.Dimmer())
+		default:
+			continue
+		}
+		if err := this.Publish(topicRotel+"/"+prop, []byte(value), true); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
+// publishCast publishes the application, volume and mute state for
+// a cast device
+func (this *bridge) publishCast(cast gopi.Cast, flags gopi.CastFlag) error {
+	if cast == nil {
+		return nil
+	}
+	prefix := topicCast + "/" + castName(cast) + "/"
+	volume, muted := cast.Volume()
+	if flags&(gopi.CAST_FLAG_APP|gopi.CAST_FLAG_CONNECT) != 0 {
+		if err := this.Publish(prefix+"app", []byte(cast.Service()), true); err != nil {
+			return err
+		}
+	}
+	if flags&(gopi.CAST_FLAG_VOLUME|gopi.CAST_FLAG_CONNECT) != 0 {
+		if err := this.Publish(prefix+"volume", []byte(fmt.Sprint(volume)), true); err != nil {
+			return err
+		}
+	}
+	if flags&(gopi.CAST_FLAG_MUTE|gopi.CAST_FLAG_CONNECT) != 0 {
+		if err := this.Publish(prefix+"muted", []byte(formatBool(muted)), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// measurementPayload returns a measurement as JSON
+func measurementPayload(m gopi.Measurement) ([]byte, error) {
+	result := measurementData{
+		Ts:      m.Time(),
+		Metrics: make(map[string]interface{}),
+	}
+	if tags := m.Tags(); len(tags) > 0 {
+		result.Tags = make(map[string]interface{}, len(tags))
+		for _, tag := range tags {
+			result.Tags[tag.Name()] = tag.Value()
+		}
+	}
+	for _, metric := range m.Metrics() {
+		result.Metrics[metric.Name()] = metric.Value()
+	}
+	return json.Marshal(result)
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// castName returns the name of a cast device for use in topics
+func castName(cast gopi.Cast) string {
+	if name := cast.Name(); name != "" {
+		return topicName(name)
+	} else {
+		return topicName(cast.Id())
+	}
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true", "1", "yes":
+		return true, nil
+	case "off", "false", "0", "no":
+		return false, nil
+	default:
+		return false, gopi.ErrBadParameter.WithPrefix(value)
+	}
+}
+
+func formatBool(value bool) string {
+	if value {
+		return "on"
+	} else {
+		return "off"
+	}
+}
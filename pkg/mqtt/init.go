@@ -0,0 +1,13 @@
+package mqtt
+
+import (
+	"reflect"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	_ "github.com/djthorpe/gopi/v3/pkg/event"
+	graph "github.com/djthorpe/gopi/v3/pkg/graph"
+)
+
+func init() {
+	graph.RegisterUnit(reflect.TypeOf(&bridge{}), reflect.TypeOf((*gopi.MQTTBridge)(nil)))
+}
@@ -0,0 +1,361 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// packetType is the control packet type in the fixed header
+type packetType byte
+
+// packet is a control packet, where data is the variable header
+// and payload
+type packet struct {
+	t     packetType
+	flags byte
+	data  []byte
+}
+
+// will is the message published by the broker when the client
+// disconnects without sending DISCONNECT
+type will struct {
+	topic   string
+	payload []byte
+	qos     byte
+	retain  bool
+}
+
+// connect are the parameters of the CONNECT packet
+type connect struct {
+	clientId  string
+	keepalive uint16
+	user      string
+	password  string
+	will      *will
+}
+
+// publish is a received PUBLISH packet
+type publish struct {
+	topic   string
+	payload []byte
+	qos     byte
+	retain  bool
+	id      uint16
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	pktConnect     packetType = 1
+	pktConnack     packetType = 2
+	pktPublish     packetType = 3
+	pktPuback      packetType = 4
+	pktSubscribe   packetType = 8
+	pktSuback      packetType = 9
+	pktUnsubscribe packetType = 10
+	pktUnsuback    packetType = 11
+	pktPingreq     packetType = 12
+	pktPingresp    packetType = 13
+	pktDisconnect  packetType = 14
+)
+
+const (
+	// Protocol level for MQTT 3.1.1, sent in the CONNECT packet
+	protocolLevel = 4
+
+	// Maximum remaining length of a packet
+	maxPacketSize = 268435455
+)
+
+/////////////////////////////////////////////////////////////////////
+// READ AND WRITE
+
+// readPacket reads a control packet
+func readPacket(r *bufio.Reader) (*packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length, err := readVarint(r)
+	if err != nil {
+		return nil, err
+	} else if length > maxPacketSize {
+		return nil, gopi.ErrUnexpectedResponse.WithPrefix("Packet too large")
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return &packet{packetType(header >> 4), header & 0x0F, data}, nil
+}
+
+// write writes a control packet with the fixed header
+func (p *packet) write(w io.Writer) error {
+	buf := make([]byte, 0, len(p.data)+5)
+	buf = append(buf, byte(p.t)<<4|p.flags&0x0F)
+	buf = appendVarint(buf, uint32(len(p.data)))
+	buf = append(buf, p.data...)
+	_, err := w.Write(buf)
+	return err
+}
+
+/////////////////////////////////////////////////////////////////////
+// ENCODE
+
+func connectPacket(c *connect) *packet {
+	var flags byte = 0x02 // Clean session
+	if c.will != nil {
+		flags |= 0x04 | (c.will.qos&0x03)<<3
+		if c.will.retain {
+			flags |= 0x20
+		}
+	}
+	if c.password != "" {
+		flags |= 0x40
+	}
+	if c.user != "" {
+		flags |= 0x80
+	}
+
+	data := appendString(nil, "MQTT")
+	data = append(data, protocolLevel, flags)
+	data = appendUint16(data, c.keepalive)
+	data = appendString(data, c.clientId)
+	if c.will != nil {
+		data = appendString(data, c.will.topic)
+		data = appendBytes(data, c.will.payload)
+	}
+	if c.user != "" {
+		data = appendString(data, c.user)
+	}
+	if c.password != "" {
+		data = appendString(data, c.password)
+	}
+	return &packet{pktConnect, 0, data}
+}
+
+func publishPacket(topic string, payload []byte, qos byte, retain bool, id uint16) *packet {
+	flags := (qos & 0x03) << 1
+	if retain {
+		flags |= 0x01
+	}
+	data := appendString(nil, topic)
+	if qos > 0 {
+		data = appendUint16(data, id)
+	}
+	data = append(data, payload...)
+	return &packet{pktPublish, flags, data}
+}
+
+// pubackPacket acknowledges a QoS 1 message
+func pubackPacket(id uint16) *packet {
+	return &packet{pktPuback, 0, appendUint16(nil, id)}
+}
+
+func subscribePacket(id uint16, topics []string, qos byte) *packet {
+	data := appendUint16(nil, id)
+	for _, topic := range topics {
+		data = appendString(data, topic)
+		data = append(data, qos&0x03)
+	}
+	return &packet{pktSubscribe, 0x02, data}
+}
+
+func pingPacket() *packet {
+	return &packet{pktPingreq, 0, nil}
+}
+
+func disconnectPacket() *packet {
+	return &packet{pktDisconnect, 0, nil}
+}
+
+/////////////////////////////////////////////////////////////////////
+// DECODE
+
+// parseConnack returns the return code, which is zero on success
+func parseConnack(p *packet) (byte, error) {
+	if p.t != pktConnack || len(p.data) < 2 {
+		return 0, gopi.ErrUnexpectedResponse.WithPrefix("CONNACK")
+	}
+	return p.data[1], nil
+}
+
+func parsePublish(p *packet) (*publish, error) {
+	r := &reader{data: p.data}
+	msg := &publish{
+		qos:    (p.flags >> 1) & 0x03,
+		retain: p.flags&0x01 != 0,
+	}
+	msg.topic = r.string()
+	if msg.qos > 0 {
+		msg.id = r.uint16()
+	}
+	if r.err != nil {
+		return nil, gopi.ErrUnexpectedResponse.WithPrefix("PUBLISH: ", r.err)
+	}
+	msg.payload = r.rest()
+	return msg, nil
+}
+
+// parseAck returns the packet identifier for PUBACK, SUBACK and
+// UNSUBACK packets
+func parseAck(p *packet) (uint16, error) {
+	if len(p.data) < 2 {
+		return 0, gopi.ErrUnexpectedResponse.WithPrefix(p.t)
+	}
+	return binary.BigEndian.Uint16(p.data), nil
+}
+
+// parseSuback returns an error if any subscription was refused
+func parseSuback(p *packet) error {
+	r := &reader{data: p.data}
+	r.uint16()
+	if r.err != nil {
+		return gopi.ErrUnexpectedResponse.WithPrefix("SUBACK: ", r.err)
+	}
+	for _, code := range r.rest() {
+		if code >= 0x80 {
+			return gopi.ErrUnexpectedResponse.WithPrefix("SUBACK: ", fmt.Sprintf("0x%02X", code))
+		}
+	}
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// reader decodes fields from packet data, and records the first error
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) uint16() uint16 {
+	if r.err != nil {
+		return 0
+	} else if len(r.data) < 2 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	v := binary.BigEndian.Uint16(r.data)
+	r.data = r.data[2:]
+	return v
+}
+
+func (r *reader) bytes() []byte {
+	n := int(r.uint16())
+	if r.err != nil {
+		return nil
+	} else if len(r.data) < n {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	v := r.data[:n]
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	} else if len(r.data) < 1 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	v := r.data[0]
+	r.data = r.data[1:]
+	return v
+}
+
+func (r *reader) string() string {
+	return string(r.bytes())
+}
+
+func (r *reader) rest() []byte {
+	v := r.data
+	r.data = nil
+	return v
+}
+
+// readVarint reads a variable byte integer of up to four bytes
+func readVarint(r io.ByteReader) (uint32, error) {
+	var value uint32
+	for i := uint(0); i < 4; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, gopi.ErrUnexpectedResponse.WithPrefix("Malformed variable byte integer")
+}
+
+func appendVarint(buf []byte, v uint32) []byte {
+	for {
+		b := byte(v & 0x7F)
+		v >>= 7
+		if v > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if v == 0 {
+			return buf
+		}
+	}
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v>>8), byte(v))
+}
+
+func appendBytes(buf []byte, v []byte) []byte {
+	buf = appendUint16(buf, uint16(len(v)))
+	return append(buf, v...)
+}
+
+func appendString(buf []byte, v string) []byte {
+	return appendBytes(buf, []byte(v))
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (t packetType) String() string {
+	switch t {
+	case pktConnect:
+		return "CONNECT"
+	case pktConnack:
+		return "CONNACK"
+	case pktPublish:
+		return "PUBLISH"
+	case pktPuback:
+		return "PUBACK"
+	case pktSubscribe:
+		return "SUBSCRIBE"
+	case pktSuback:
+		return "SUBACK"
+	case pktUnsubscribe:
+		return "UNSUBSCRIBE"
+	case pktUnsuback:
+		return "UNSUBACK"
+	case pktPingreq:
+		return "PINGREQ"
+	case pktPingresp:
+		return "PINGRESP"
+	case pktDisconnect:
+		return "DISCONNECT"
+	default:
+		return "[?? Invalid packetType value]"
+	}
+}
@@ -0,0 +1,43 @@
+package mqtt
+
+import (
+	"strings"
+)
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// topicMatches returns true if a topic matches a filter, where + matches
+// a single level and # matches any remaining levels
+func topicMatches(filter, topic string) bool {
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+	for i, level := range f {
+		switch {
+		case level == "#":
+			return true
+		case i >= len(t):
+			return false
+		case level != "+" && level != t[i]:
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+// topicName returns a name for use as a topic level, replacing
+// separators and wildcards
+func topicName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '+', '#', 0:
+			return '_'
+		default:
+			return r
+		}
+	}, name)
+}
@@ -0,0 +1,42 @@
+package events
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// filter matches events by name and codec type, where an empty
+// set of names or types matches all events
+type filter struct {
+	names map[string]bool
+	types map[string]bool
+}
+
+/////////////////////////////////////////////////////////////////////
+// NEW
+
+func newFilter(names, types []string) *filter {
+	this := new(filter)
+	this.names = make(map[string]bool, len(names))
+	for _, name := range names {
+		this.names[name] = true
+	}
+	this.types = make(map[string]bool, len(types))
+	for _, t := range types {
+		this.types[t] = true
+	}
+	return this
+}
+
+/////////////////////////////////////////////////////////////////////
+// METHODS
+
+// Match returns true if an event with a name and codec type
+// is selected by the filter
+func (this *filter) Match(name, t string) bool {
+	if len(this.names) > 0 && this.names[name] == false {
+		return false
+	} else if len(this.types) > 0 && this.types[t] == false {
+		return false
+	} else {
+		return true
+	}
+}
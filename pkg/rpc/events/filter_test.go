@@ -0,0 +1,28 @@
+package events
+
+import (
+	"testing"
+)
+
+func Test_Filter_001(t *testing.T) {
+	tests := []struct {
+		names, types []string
+		name, t      string
+		match        bool
+	}{
+		{nil, nil, "gpio0", "gpio", true},
+		{[]string{"gpio0"}, nil, "gpio0", "gpio", true},
+		{[]string{"gpio0"}, nil, "lirc0", "lirc", false},
+		{nil, []string{"gpio"}, "gpio0", "gpio", true},
+		{nil, []string{"gpio", "lirc"}, "lirc0", "lirc", true},
+		{nil, []string{"gpio"}, "lirc0", "lirc", false},
+		{[]string{"gpio0"}, []string{"gpio"}, "gpio0", "gpio", true},
+		{[]string{"gpio0"}, []string{"lirc"}, "gpio0", "gpio", false},
+		{[]string{"gpio1"}, []string{"gpio"}, "gpio0", "gpio", false},
+	}
+	for i, test := range tests {
+		if match := newFilter(test.names, test.types).Match(test.name, test.t); match != test.match {
+			t.Errorf("Test %v: Unexpected match %v for %q %q", i, match, test.name, test.t)
+		}
+	}
+}
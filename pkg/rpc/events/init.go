@@ -0,0 +1,14 @@
+package events
+
+import (
+	"reflect"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	graph "github.com/djthorpe/gopi/v3/pkg/graph"
+)
+
+func init() {
+	// Register gopi.EventsService and gopi.EventsStub
+	graph.RegisterUnit(reflect.TypeOf(&service{}), reflect.TypeOf((*gopi.EventsService)(nil)))
+	graph.RegisterServiceStub(Events_ServiceDesc.ServiceName, reflect.TypeOf(&stub{}))
+}
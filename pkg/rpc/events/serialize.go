@@ -0,0 +1,51 @@
+package events
+
+import (
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	codec "github.com/djthorpe/gopi/v3/pkg/event/codec"
+	ptypes "github.com/golang/protobuf/ptypes"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+)
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func toProtoNull() *Event {
+	return &Event{}
+}
+
+// toProtoEvent serialises an event with a registered codec, and
+// returns ErrNotImplemented if there is no codec for the event
+func toProtoEvent(evt gopi.Event, ts time.Time) (*Event, error) {
+	if name, data, err := codec.Encode(evt); err != nil {
+		return nil, err
+	} else {
+		return &Event{
+			Name: evt.Name(),
+			Type: name,
+			Ts:   toProtoTimestamp(ts),
+			Data: data,
+		}, nil
+	}
+}
+
+// fromProtoEvent returns nil for a null event
+func fromProtoEvent(pb *Event) (gopi.Event, error) {
+	if pb == nil || pb.GetType() == "" {
+		return nil, nil
+	} else {
+		return codec.Decode(pb.GetType(), pb.GetData())
+	}
+}
+
+func toProtoTimestamp(ts time.Time) *timestamp.Timestamp {
+	if ts.IsZero() {
+		return nil
+	} else if proto, err := ptypes.TimestampProto(ts); err == nil {
+		return proto
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	codec "github.com/djthorpe/gopi/v3/pkg/event/codec"
+	gpio "github.com/djthorpe/gopi/v3/pkg/hw/gpio"
+	lirc "github.com/djthorpe/gopi/v3/pkg/hw/lirc"
+	ptypes "github.com/golang/protobuf/ptypes"
+)
+
+// nullEvent has no codec
+type nullEvent struct{}
+
+func (nullEvent) Name() string { return "null" }
+
+func Test_Serialize_001(t *testing.T) {
+	ts := time.Date(2021, 1, 1, 12, 0, 0, 500, time.UTC)
+	pb, err := toProtoEvent(gpio.NewEvent("gpio0", gopi.GPIOPin(17), gopi.GPIO_EDGE_RISING), ts)
+	if err != nil {
+		t.Fatal(err)
+	} else if pb.Name != "gpio0" || pb.Type != codec.GPIO || len(pb.Data) == 0 {
+		t.Error("Unexpected event", pb)
+	} else if ts_, err := ptypes.Timestamp(pb.Ts); err != nil || ts_.Equal(ts) == false {
+		t.Error("Unexpected timestamp", pb.Ts)
+	}
+
+	evt, err := fromProtoEvent(pb)
+	if err != nil {
+		t.Fatal(err)
+	} else if evt_, ok := evt.(gopi.GPIOEvent); ok == false {
+		t.Error("Unexpected event", evt)
+	} else if evt_.Name() != "gpio0" || evt_.Pin() != gopi.GPIOPin(17) || evt_.Edge() != gopi.GPIO_EDGE_RISING {
+		t.Error("Unexpected event", evt_)
+	}
+}
+
+func Test_Serialize_002(t *testing.T) {
+	// A zero time is not serialised
+	if pb, err := toProtoEvent(lirc.NewEvent("lirc0", gopi.LIRC_MODE_MODE2, 9000), time.Time{}); err != nil {
+		t.Error(err)
+	} else if pb.Ts != nil {
+		t.Error("Unexpected timestamp", pb.Ts)
+	}
+
+	// Null events decode as nil
+	if evt, err := fromProtoEvent(toProtoNull()); err != nil || evt != nil {
+		t.Error("Unexpected event", evt, err)
+	}
+	if evt, err := fromProtoEvent(nil); err != nil || evt != nil {
+		t.Error("Unexpected event", evt, err)
+	}
+
+	// Events without a codec are not serialised
+	if _, err := toProtoEvent(nullEvent{}, time.Now()); errors.Is(err, gopi.ErrNotImplemented) == false {
+		t.Error("Expected not implemented, got", err)
+	}
+
+	// Events with an unknown codec are not decoded
+	if _, err := fromProtoEvent(&Event{Name: "x", Type: "unknown"}); errors.Is(err, gopi.ErrNotFound) == false {
+		t.Error("Expected not found, got", err)
+	}
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	codec "github.com/djthorpe/gopi/v3/pkg/event/codec"
+	empty "github.com/golang/protobuf/ptypes/empty"
+)
+
+type service struct {
+	gopi.Unit
+	gopi.Logger
+	gopi.Publisher
+	gopi.Server
+}
+
+/////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func (this *service) New(cfg gopi.Config) error {
+	this.Require(this.Logger, this.Publisher, this.Server)
+	return this.Server.RegisterService(RegisterEventsServer, this)
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *service) mustEmbedUnimplementedEventsServer() {}
+
+/////////////////////////////////////////////////////////////////////
+// RPC METHODS
+
+// Types returns the names of registered codecs
+func (this *service) Types(context.Context, *empty.Empty) (*Types, error) {
+	this.Logger.Debug("<Types>")
+	return &Types{Type: codec.Names()}, nil
+}
+
+// Stream events which match the filter to the client. Events without
+// a codec are not sent
+func (this *service) Stream(req *Filter, stream Events_StreamServer) error {
+	this.Logger.Debug("<Stream ", req, ">")
+
+	// Send a null event once a second
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	// Subscribe to events, filtered by name
+	var ch <-chan gopi.Event
+	if names := req.GetName(); len(names) > 0 {
+		ch = this.Publisher.SubscribeName(names...)
+	} else {
+		ch = this.Publisher.Subscribe()
+	}
+	defer this.Publisher.Unsubscribe(ch)
+
+	// Filter by name and codec type
+	filter := newFilter(req.GetName(), req.GetType())
+
+	// Obtain server cancel context
+	ctx := this.Server.NewStreamContext()
+
+	// Loop which streams until server context cancels
+	// or an error occurs sending a null event
+	for {
+		select {
+		case evt := <-ch:
+			if evt == nil {
+				continue
+			} else if pb, err := toProtoEvent(evt, time.Now()); errors.Is(err, gopi.ErrNotImplemented) {
+				continue
+			} else if err != nil {
+				this.Print(err)
+			} else if filter.Match(pb.Name, pb.Type) == false {
+				continue
+			} else if err := stream.Send(pb); err != nil {
+				this.Print(err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := stream.Send(toProtoNull()); err != nil {
+				this.Logger.Debug("Error sending null event, ending stream")
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	grpc "google.golang.org/grpc"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+type stub struct {
+	gopi.Conn
+	EventsClient
+}
+
+/////////////////////////////////////////////////////////////////////
+// INIT
+
+func (this *stub) New(conn gopi.Conn) {
+	this.Conn = conn
+	this.EventsClient = NewEventsClient(conn.(grpc.ClientConnInterface))
+}
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (this *stub) Types(ctx context.Context) ([]string, error) {
+	// Ensure one call per connection
+	this.Conn.Lock()
+	defer this.Conn.Unlock()
+
+	if types, err := this.EventsClient.Types(ctx, &empty.Empty{}); err != nil {
+		return nil, this.Err(err)
+	} else {
+		return types.GetType(), nil
+	}
+}
+
+func (this *stub) Stream(ctx context.Context, names, types []string, ch chan<- gopi.Event) error {
+	this.Conn.Lock()
+	defer this.Conn.Unlock()
+
+	stream, err := this.EventsClient.Stream(ctx, &Filter{Name: names, Type: types})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			if msg, err := stream.Recv(); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return this.Err(err)
+			} else if evt, err := fromProtoEvent(msg); err != nil {
+				// Skip events which cannot be decoded, for example
+				// when the server has a codec which the client does not
+				continue
+			} else if evt != nil {
+				ch <- evt
+			}
+		}
+	}
+}
+
+func (this *stub) Emit(ctx context.Context, publisher gopi.Publisher, names, types []string) error {
+	ch := make(chan gopi.Event)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- this.Stream(ctx, names, types, ch)
+		close(ch)
+	}()
+	for evt := range ch {
+		publisher.Emit(evt, true)
+	}
+	return <-errs
+}
+
+/////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (this *stub) String() string {
+	str := "<rpc.stub.events"
+	str += " addr=" + strconv.Quote(this.Addr())
+	return str + ">"
+}
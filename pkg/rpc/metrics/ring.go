@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ring retains the most recent measurements, overwriting the
+// oldest measurement when full
+type ring struct {
+	values []gopi.Measurement
+	next   int
+	full   bool
+}
+
+// stamped is a measurement with the time it was received, for
+// measurements which were emitted without a timestamp
+type stamped struct {
+	gopi.Measurement
+	ts time.Time
+}
+
+/////////////////////////////////////////////////////////////////////
+// NEW
+
+func newRing(size uint) *ring {
+	return &ring{values: make([]gopi.Measurement, size)}
+}
+
+/////////////////////////////////////////////////////////////////////
+// METHODS
+
+// Add a measurement, overwriting the oldest measurement when full.
+// Measurements without a timestamp are retained with the time ts
+func (this *ring) Add(m gopi.Measurement, ts time.Time) {
+	if len(this.values) == 0 {
+		return
+	}
+	if m.Time().IsZero() {
+		m = &stamped{m, ts}
+	}
+	this.values[this.next] = m
+	if this.next = (this.next + 1) % len(this.values); this.next == 0 {
+		this.full = true
+	}
+}
+
+// Between returns measurements from the first time until, but not
+// including, the second time which match a filter function, in the
+// order they were added. A zero time is unbounded
+func (this *ring) Between(from, to time.Time, fn func(gopi.Measurement) bool) []gopi.Measurement {
+	result := []gopi.Measurement{}
+	start, count := 0, this.next
+	if this.full {
+		start, count = this.next, len(this.values)
+	}
+	for i := 0; i < count; i++ {
+		m := this.values[(start+i)%len(this.values)]
+		if ts := m.Time(); from.IsZero() == false && ts.Before(from) {
+			continue
+		} else if to.IsZero() == false && ts.Before(to) == false {
+			continue
+		} else if fn(m) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+/////////////////////////////////////////////////////////////////////
+// STAMPED
+
+func (this *stamped) Time() time.Time {
+	return this.ts
+}
@@ -63,6 +63,23 @@ func toProtoFields(fields []gopi.Field) []*Field {
 	return result
 }
 
+func toProtoFilter(name string, tags []gopi.Field) *Filter {
+	return &Filter{
+		Name: name,
+		Tags: toProtoFields(tags),
+	}
+}
+
+func fromProtoTimestamp(pb *timestamp.Timestamp) time.Time {
+	if pb == nil {
+		return time.Time{}
+	} else if ts, err := ptypes.Timestamp(pb); err != nil {
+		return time.Time{}
+	} else {
+		return ts
+	}
+}
+
 func toProtoTimestamp(ts time.Time) *timestamp.Timestamp {
 	if ts.IsZero() {
 		return nil
@@ -102,6 +119,20 @@ func toProtoFieldValue(field gopi.Field) isField_Value {
 		return &Field_Bool{value}
 	case time.Time:
 		return &Field_Time{toProtoTimestamp(value)}
+	case gopi.MetricCounter:
+		return &Field_Counter{&Counter{Total: value.Total, Rate: value.Rate}}
+	case gopi.MetricHistogram:
+		buckets := make([]*Bucket, len(value.Buckets))
+		for i, bucket := range value.Buckets {
+			buckets[i] = &Bucket{Le: bucket.UpperBound, Count: bucket.Count}
+		}
+		return &Field_Histogram{&Histogram{Count: value.Count, Sum: value.Sum, Buckets: buckets}}
+	case gopi.MetricSummary:
+		quantiles := make([]*Quantile, len(value.Quantiles))
+		for i, q := range value.Quantiles {
+			quantiles[i] = &Quantile{Quantile: q.Quantile, Value: q.Value}
+		}
+		return &Field_Summary{&Summary{Count: value.Count, Sum: value.Sum, Quantiles: quantiles}}
 	default:
 		return nil
 	}
@@ -141,7 +172,16 @@ func (this *measurement) Metrics() []gopi.Field {
 }
 
 func (this *measurement) Get(name string) interface{} {
-	// TODO
+	for _, pb := range this.pb.GetTags() {
+		if pb.GetName() == name {
+			return (&field{pb}).Value()
+		}
+	}
+	for _, pb := range this.pb.GetMetrics() {
+		if pb.GetName() == name {
+			return (&field{pb}).Value()
+		}
+	}
 	return nil
 }
 
@@ -202,7 +242,7 @@ func (this *field) Value() interface{} {
 		return int64(this.pb.GetInt())
 	case "float32":
 		return float32(this.pb.GetFloat())
-	case "float64":
+	case "float64", "gauge":
 		return float64(this.pb.GetFloat())
 	case "bool":
 		return this.pb.GetBool()
@@ -212,6 +252,23 @@ func (this *field) Value() interface{} {
 		} else {
 			return ts
 		}
+	case "counter":
+		counter := this.pb.GetCounter()
+		return gopi.MetricCounter{Total: counter.GetTotal(), Rate: counter.GetRate()}
+	case "histogram":
+		histogram := this.pb.GetHistogram()
+		result := gopi.MetricHistogram{Count: histogram.GetCount(), Sum: histogram.GetSum()}
+		for _, bucket := range histogram.GetBuckets() {
+			result.Buckets = append(result.Buckets, gopi.MetricBucket{UpperBound: bucket.GetLe(), Count: bucket.GetCount()})
+		}
+		return result
+	case "summary":
+		summary := this.pb.GetSummary()
+		result := gopi.MetricSummary{Count: summary.GetCount(), Sum: summary.GetSum()}
+		for _, q := range summary.GetQuantiles() {
+			result.Quantiles = append(result.Quantiles, gopi.MetricQuantile{Quantile: q.GetQuantile(), Value: q.GetValue()})
+		}
+		return result
 	default:
 		return nil
 	}
@@ -2,6 +2,9 @@ package metrics
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -16,12 +19,35 @@ type service struct {
 	gopi.Server
 	gopi.Unit
 	gopi.Metrics
+
+	history *uint
+	ring    map[string]*ring
+}
+
+// filter matches measurements by name and tag values
+type filter struct {
+	name string
+	tags map[string]string
 }
 
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Default number of measurements retained for each name
+	defaultHistory = 100
+)
+
 /////////////////////////////////////////////////////////////////////
 // LIFECYCLE
 
+func (this *service) Define(cfg gopi.Config) error {
+	this.history = cfg.FlagUint("metrics.history", defaultHistory, "Number of recent measurements retained for each measurement name, for queries")
+	return nil
+}
+
 func (this *service) New(cfg gopi.Config) error {
+	this.ring = make(map[string]*ring)
 	if this.Server == nil {
 		return gopi.ErrInternalAppError.WithPrefix("RegisterService: ", "(Server == nil)")
 	} else if this.Metrics == nil {
@@ -31,6 +57,27 @@ func (this *service) New(cfg gopi.Config) error {
 	}
 }
 
+func (this *service) Run(ctx context.Context) error {
+	if this.Publisher == nil {
+		return gopi.ErrInternalAppError.WithPrefix("Publisher")
+	}
+
+	// Retain measurements for queries
+	ch := this.Publisher.SubscribeType((*gopi.Measurement)(nil))
+	defer this.Publisher.Unsubscribe(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case evt := <-ch:
+			if m, ok := evt.(gopi.Measurement); ok {
+				this.add(m)
+			}
+		}
+	}
+}
+
 /////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
@@ -56,16 +103,60 @@ func (this *service) List(context.Context, *empty.Empty) (*Measurements, error)
 	return response, nil
 }
 
-// Stream measuremets to client
-func (this *service) Stream(req *Name, stream Metrics_StreamServer) error {
-	this.Logger.Debug("<Stream", req, ">")
+// Query returns retained measurements which match the filter, between
+// two times, ordered by time. It returns an error if the range ends
+// before it starts, or the measurement name is not known
+func (this *service) Query(_ context.Context, req *Query) (*Measurements, error) {
+	this.Logger.Debug("<Query ", req, ">")
+
+	filter := newFilter(req.GetFilter())
+	from, to := fromProtoTimestamp(req.GetFrom()), fromProtoTimestamp(req.GetTo())
+	if from.IsZero() == false && to.IsZero() == false && to.Before(from) {
+		return nil, gopi.ErrBadParameter.WithPrefix("Query: ", "From is after To")
+	} else if filter.name != "" && this.exists(filter.name) == false {
+		return nil, gopi.ErrNotFound.WithPrefix("Query: ", strconv.Quote(filter.name))
+	}
+
+	// Gather measurements from each ring
+	this.Mutex.Lock()
+	measurements := []gopi.Measurement{}
+	for name, ring := range this.ring {
+		if filter.name == "" || filter.name == name {
+			measurements = append(measurements, ring.Between(from, to, filter.Match)...)
+		}
+	}
+	this.Mutex.Unlock()
+
+	// Order by time
+	sort.SliceStable(measurements, func(i, j int) bool {
+		return measurements[i].Time().Before(measurements[j].Time())
+	})
+
+	response := &Measurements{
+		Metric: make([]*Measurement, 0, len(measurements)),
+	}
+	for _, measurement := range measurements {
+		if pb := toProtoMeasurement(measurement); pb != nil {
+			response.Metric = append(response.Metric, pb)
+		}
+	}
+
+	return response, nil
+}
+
+// Stream measurements which match the filter to client
+func (this *service) Stream(req *Filter, stream Metrics_StreamServer) error {
+	this.Logger.Debug("<Stream ", req, ">")
+
+	// Filter by name and tags
+	filter := newFilter(req)
 
 	// Send a null event once a second
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
-	// Subscribe to input events
-	ch := this.Publisher.Subscribe()
+	// Subscribe to measurements
+	ch := this.Publisher.SubscribeType((*gopi.Measurement)(nil))
 	defer this.Publisher.Unsubscribe(ch)
 
 	// Obtain server cancel context
@@ -76,8 +167,7 @@ func (this *service) Stream(req *Name, stream Metrics_StreamServer) error {
 	for {
 		select {
 		case evt := <-ch:
-			if measurement, ok := evt.(gopi.Measurement); ok {
-				// TODO FILTER MEAUSREMENTS
+			if measurement, ok := evt.(gopi.Measurement); ok && filter.Match(measurement) {
 				if err := stream.Send(toProtoMeasurement(measurement)); err != nil {
 					this.Print(err)
 				}
@@ -92,3 +182,69 @@ func (this *service) Stream(req *Name, stream Metrics_StreamServer) error {
 		}
 	}
 }
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// add retains a measurement, discarding the oldest measurement
+// with the same name
+func (this *service) add(m gopi.Measurement) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+
+	name := m.Name()
+	if _, exists := this.ring[name]; exists == false {
+		this.ring[name] = newRing(*this.history)
+	}
+	this.ring[name].Add(m, time.Now())
+}
+
+// exists returns true if a measurement name is defined, or
+// measurements with the name have been retained
+func (this *service) exists(name string) bool {
+	for _, m := range this.Metrics.Measurements() {
+		if m.Name() == name {
+			return true
+		}
+	}
+
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	_, exists := this.ring[name]
+	return exists
+}
+
+/////////////////////////////////////////////////////////////////////
+// FILTER
+
+func newFilter(pb *Filter) *filter {
+	this := &filter{name: pb.GetName()}
+	if tags := pb.GetTags(); len(tags) > 0 {
+		this.tags = make(map[string]string, len(tags))
+		for _, tag := range tags {
+			this.tags[tag.GetName()] = fmt.Sprint((&field{tag}).Value())
+		}
+	}
+	return this
+}
+
+// Match returns true if a measurement has the name of the filter,
+// and a tag value for every tag in the filter
+func (this *filter) Match(m gopi.Measurement) bool {
+	if this.name != "" && this.name != m.Name() {
+		return false
+	}
+	for name, value := range this.tags {
+		matched := false
+		for _, tag := range m.Tags() {
+			if tag.Name() == name && tag.IsNil() == false && fmt.Sprint(tag.Value()) == value {
+				matched = true
+				break
+			}
+		}
+		if matched == false {
+			return false
+		}
+	}
+	return true
+}
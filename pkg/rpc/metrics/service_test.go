@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	metrics "github.com/djthorpe/gopi/v3/pkg/metrics"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// fakeLogger discards output
+type fakeLogger struct {
+	gopi.Logger
+}
+
+// fakeMetrics returns defined measurements
+type fakeMetrics struct {
+	gopi.Metrics
+	names []string
+}
+
+// fakePublisher returns one channel for subscriptions
+type fakePublisher struct {
+	gopi.Publisher
+	ch chan gopi.Event
+}
+
+// fakeServer returns a stream context which is cancelled by the test
+type fakeServer struct {
+	gopi.Server
+	ctx context.Context
+}
+
+// fakeStream passes sent measurements to the test
+type fakeStream struct {
+	Metrics_StreamServer
+	ch chan *Measurement
+}
+
+func (this *fakeLogger) Debug(...interface{}) {}
+func (this *fakeLogger) Print(...interface{}) {}
+
+func (this *fakeMetrics) Measurements() []gopi.Measurement {
+	result := []gopi.Measurement{}
+	for _, name := range this.names {
+		result = append(result, newMeasurement(name, time.Time{}, ""))
+	}
+	return result
+}
+
+func (this *fakePublisher) SubscribeType(...interface{}) <-chan gopi.Event {
+	return this.ch
+}
+
+func (this *fakePublisher) Unsubscribe(<-chan gopi.Event) {}
+
+func (this *fakeServer) NewStreamContext() context.Context {
+	return this.ctx
+}
+
+func (this *fakeStream) Send(m *Measurement) error {
+	this.ch <- m
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Service_001(t *testing.T) {
+	svc := newService(&fakeMetrics{names: []string{"cpu", "disk"}}, nil, nil)
+	ts := time.Now().Truncate(time.Second)
+	svc.add(newMeasurement("cpu", ts, "a"))
+	svc.add(newMeasurement("cpu", ts.Add(time.Second), "b"))
+	svc.add(newMeasurement("mem", ts.Add(2*time.Second), "a"))
+	svc.add(newMeasurement("cpu", ts.Add(3*time.Second), "a"))
+
+	tests := []struct {
+		name     string
+		host     string
+		from, to time.Time
+		result   []string
+	}{
+		{"", "", time.Time{}, time.Time{}, []string{"cpu", "cpu", "mem", "cpu"}},
+		{"cpu", "", time.Time{}, time.Time{}, []string{"cpu", "cpu", "cpu"}},
+		{"cpu", "a", time.Time{}, time.Time{}, []string{"cpu", "cpu"}},
+		{"", "a", ts.Add(time.Second), time.Time{}, []string{"mem", "cpu"}},
+		{"", "", ts.Add(time.Second), ts.Add(3 * time.Second), []string{"cpu", "mem"}},
+		{"", "", ts, ts, []string{}},
+		{"disk", "", time.Time{}, time.Time{}, []string{}},
+		{"mem", "", time.Time{}, time.Time{}, []string{"mem"}},
+	}
+	for i, test := range tests {
+		if response, err := svc.Query(context.Background(), newQuery(test.name, test.host, test.from, test.to)); err != nil {
+			t.Errorf("Test %v: %v", i, err)
+		} else if names := measurementNames(response.GetMetric()); len(names) != len(test.result) {
+			t.Errorf("Test %v: Unexpected result %v", i, names)
+		} else {
+			for j := range names {
+				if names[j] != test.result[j] {
+					t.Errorf("Test %v: Unexpected result %v", i, names)
+				}
+			}
+		}
+	}
+
+	// A range which ends before it starts is a bad parameter, and an
+	// unknown name is not found
+	if _, err := svc.Query(context.Background(), newQuery("", "", ts.Add(time.Second), ts)); errors.Is(err, gopi.ErrBadParameter) == false {
+		t.Error("Expected bad parameter error, got:", err)
+	}
+	if _, err := svc.Query(context.Background(), newQuery("gpu", "", time.Time{}, time.Time{})); errors.Is(err, gopi.ErrNotFound) == false {
+		t.Error("Expected not found error, got:", err)
+	}
+}
+
+func Test_Service_002(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher := &fakePublisher{ch: make(chan gopi.Event, 3)}
+	svc := newService(&fakeMetrics{}, publisher, &fakeServer{ctx: ctx})
+
+	// Measurements are sent in order, so the first measurement sent
+	// is the only one which matches the filter
+	ts := time.Now()
+	publisher.ch <- newMeasurement("cpu", ts, "b")
+	publisher.ch <- newMeasurement("mem", ts, "a")
+	publisher.ch <- newMeasurement("cpu", ts, "a")
+
+	stream := &fakeStream{ch: make(chan *Measurement)}
+	errs := make(chan error)
+	go func() {
+		errs <- svc.Stream(newQuery("cpu", "a", time.Time{}, time.Time{}).GetFilter(), stream)
+	}()
+
+	timer := time.NewTimer(2 * time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case m := <-stream.ch:
+			if m.GetName() == "" {
+				continue
+			} else if m.GetName() != "cpu" || len(m.GetTags()) != 1 || m.GetTags()[0].GetStr() != "a" {
+				t.Error("Unexpected measurement:", m)
+			}
+			cancel()
+		case err := <-errs:
+			if errors.Is(err, context.Canceled) == false {
+				t.Error("Expected cancelled stream, got:", err)
+			}
+			return
+		case <-timer.C:
+			t.Fatal("Timeout waiting for measurement")
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func newService(m gopi.Metrics, p gopi.Publisher, s gopi.Server) *service {
+	history := uint(10)
+	return &service{
+		Logger:    &fakeLogger{},
+		Metrics:   m,
+		Publisher: p,
+		Server:    s,
+		history:   &history,
+		ring:      make(map[string]*ring),
+	}
+}
+
+// newMeasurement returns a measurement with a host tag and a value
+func newMeasurement(name string, ts time.Time, host string) gopi.Measurement {
+	return metrics.NewMeasurementWithFields(name, ts, []gopi.Field{
+		metrics.NewField("host", host),
+	}, []gopi.Field{
+		metrics.NewField("value", uint64(1)),
+	})
+}
+
+func newQuery(name, host string, from, to time.Time) *Query {
+	var tags []gopi.Field
+	if host != "" {
+		tags = append(tags, metrics.NewField("host", host))
+	}
+	query := &Query{Filter: toProtoFilter(name, tags)}
+	if from.IsZero() == false {
+		query.From = toProtoTimestamp(from)
+	}
+	if to.IsZero() == false {
+		query.To = toProtoTimestamp(to)
+	}
+	return query
+}
+
+func measurementNames(pb []*Measurement) []string {
+	result := make([]string, len(pb))
+	for i, m := range pb {
+		result[i] = m.GetName()
+	}
+	return result
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"strconv"
+	"time"
 
 	gopi "github.com/djthorpe/gopi/v3"
 	empty "github.com/golang/protobuf/ptypes/empty"
@@ -45,11 +46,31 @@ func (this *stub) List(ctx context.Context) ([]gopi.Measurement, error) {
 	return results, nil
 }
 
-func (this *stub) Stream(ctx context.Context, name string, ch chan<- gopi.Measurement) error {
+func (this *stub) Query(ctx context.Context, name string, tags []gopi.Field, from, to time.Time) ([]gopi.Measurement, error) {
+	// Ensure one call per connection
+	this.Conn.Lock()
+	defer this.Conn.Unlock()
+
+	metrics, err := this.MetricsClient.Query(ctx, &Query{
+		Filter: toProtoFilter(name, tags),
+		From:   toProtoTimestamp(from),
+		To:     toProtoTimestamp(to),
+	})
+	if err != nil {
+		return nil, this.Err(err)
+	}
+	results := make([]gopi.Measurement, len(metrics.Metric))
+	for i, metric := range metrics.Metric {
+		results[i] = fromProtoMeasurement(metric)
+	}
+	return results, nil
+}
+
+func (this *stub) Stream(ctx context.Context, name string, tags []gopi.Field, ch chan<- gopi.Measurement) error {
 	this.Conn.Lock()
 	defer this.Conn.Unlock()
 
-	stream, err := this.MetricsClient.Stream(ctx, &Name{Name: name})
+	stream, err := this.MetricsClient.Stream(ctx, toProtoFilter(name, tags))
 	if err != nil {
 		return err
 	}
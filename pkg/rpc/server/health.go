@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	codes "google.golang.org/grpc/codes"
+	grpc_health_v1 "google.golang.org/grpc/health/grpc_health_v1"
+	status "google.golang.org/grpc/status"
+)
+
+/////////////////////////////////////////////////////////////////////
+// TYPES
+
+// health implements the grpc.health.v1 service. The empty service
+// name reports the aggregated health of all units, and each unit
+// can be queried by name
+type health struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	fn func() map[string]error
+}
+
+/////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Interval between sending status on Watch
+	healthWatchInterval = time.Second
+)
+
+/////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// RegisterHealth registers the grpc.health.v1 service with a function
+// which returns the health of each unit
+func (this *server) RegisterHealth(fn func() map[string]error) error {
+	if fn == nil {
+		return gopi.ErrBadParameter.WithPrefix("RegisterHealth")
+	} else if this.srv == nil {
+		return gopi.ErrOutOfOrder.WithPrefix("RegisterHealth")
+	}
+	grpc_health_v1.RegisterHealthServer(this.srv, &health{fn: fn})
+	return nil
+}
+
+func (this *health) Check(_ context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if s, exists := this.status(req.GetService()); exists == false {
+		return nil, status.Errorf(codes.NotFound, "Unknown service: %q", req.GetService())
+	} else {
+		return &grpc_health_v1.HealthCheckResponse{Status: s}, nil
+	}
+}
+
+func (this *health) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_ServingStatus(-1)
+	for {
+		// Send status when it changes
+		if s, _ := this.status(req.GetService()); s != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: s}); err != nil {
+				return err
+			}
+			last = s
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+			continue
+		}
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// status returns the serving status for a service name, and false if
+// the service is unknown
+func (this *health) status(service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool) {
+	units := this.fn()
+	if service == "" {
+		for _, err := range units {
+			if err != nil {
+				return grpc_health_v1.HealthCheckResponse_NOT_SERVING, true
+			}
+		}
+		return grpc_health_v1.HealthCheckResponse_SERVING, true
+	} else if err, exists := units[service]; exists == false {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, false
+	} else if err != nil {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, true
+	} else {
+		return grpc_health_v1.HealthCheckResponse_SERVING, true
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	gopi "github.com/djthorpe/gopi/v3"
+	codes "google.golang.org/grpc/codes"
+	grpc_health_v1 "google.golang.org/grpc/health/grpc_health_v1"
+	status "google.golang.org/grpc/status"
+)
+
+func Test_Health_001(t *testing.T) {
+	units := map[string]error{"a": nil, "b": nil}
+	h := &health{fn: func() map[string]error { return units }}
+	check := func(service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+		resp, err := h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		return resp.GetStatus(), err
+	}
+
+	// All units are healthy
+	for _, service := range []string{"", "a", "b"} {
+		if s, err := check(service); err != nil {
+			t.Error(err)
+		} else if s != grpc_health_v1.HealthCheckResponse_SERVING {
+			t.Errorf("Unexpected status for %q: %v", service, s)
+		}
+	}
+
+	// When a unit fails, it and the aggregate are not serving
+	units["b"] = gopi.ErrUnexpectedResponse
+	for service, want := range map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+		"":  grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		"a": grpc_health_v1.HealthCheckResponse_SERVING,
+		"b": grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+	} {
+		if s, err := check(service); err != nil {
+			t.Error(err)
+		} else if s != want {
+			t.Errorf("Unexpected status for %q: %v", service, s)
+		}
+	}
+
+	// Unknown units return not found
+	if _, err := check("c"); status.Code(err) != codes.NotFound {
+		t.Error("Expected not found error, got:", err)
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"os"
@@ -26,6 +27,8 @@ type server struct {
 	srv      *grpc.Server
 	listener net.Listener
 	ssl      bool
+	cert     *tls.Certificate
+	certlock sync.RWMutex // Additional lock just for cert value
 	cancels  []context.CancelFunc
 }
 
@@ -41,7 +44,7 @@ func (this *server) Define(cfg gopi.Config) error {
 
 func (this *server) New(cfg gopi.Config) error {
 	opts := []grpc.ServerOption{}
-	if opts, ssl, err := appendServerCredentialOption(cfg, opts); err != nil {
+	if opts, ssl, err := this.appendServerCredentialOption(cfg, opts); err != nil {
 		return err
 	} else if opts, err := appendConnectionTimeoutOption(cfg, opts); err != nil {
 		return err
@@ -77,6 +80,20 @@ func (this *server) Dispose() error {
 	return result
 }
 
+// Reload replaces the SSL certificate and key with the files named in
+// the configuration. SSL cannot be enabled or disabled without restarting
+func (this *server) Reload(cfg gopi.Config) error {
+	cert := cfg.GetString("ssl.cert")
+	key := cfg.GetString("ssl.key")
+	if this.ssl == false && cert == "" && key == "" {
+		return nil
+	} else if this.ssl == false || cert == "" || key == "" {
+		return gopi.ErrOutOfOrder.WithPrefix("Reload: SSL cannot be changed without restarting")
+	} else {
+		return this.loadCertificate(cert, key)
+	}
+}
+
 /////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
@@ -224,14 +241,18 @@ func (this *server) String() string {
 /////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
-func appendServerCredentialOption(cfg gopi.Config, opts []grpc.ServerOption) ([]grpc.ServerOption, bool, error) {
+// appendServerCredentialOption loads the certificate and key, which
+// are returned to clients through getCertificate so that they can be
+// replaced when the configuration is reloaded
+func (this *server) appendServerCredentialOption(cfg gopi.Config, opts []grpc.ServerOption) ([]grpc.ServerOption, bool, error) {
 	cert := cfg.GetString("ssl.cert")
 	key := cfg.GetString("ssl.key")
 	ssl := false
 	if cert != "" || key != "" {
-		if creds, err := credentials.NewServerTLSFromFile(cert, key); err != nil {
+		if err := this.loadCertificate(cert, key); err != nil {
 			return nil, false, err
 		} else {
+			creds := credentials.NewTLS(&tls.Config{GetCertificate: this.getCertificate})
 			opts = append(opts, grpc.Creds(creds))
 			ssl = true
 		}
@@ -239,6 +260,23 @@ func appendServerCredentialOption(cfg gopi.Config, opts []grpc.ServerOption) ([]
 	return opts, ssl, nil
 }
 
+func (this *server) loadCertificate(cert, key string) error {
+	if pair, err := tls.LoadX509KeyPair(cert, key); err != nil {
+		return err
+	} else {
+		this.certlock.Lock()
+		defer this.certlock.Unlock()
+		this.cert = &pair
+	}
+	return nil
+}
+
+func (this *server) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	this.certlock.RLock()
+	defer this.certlock.RUnlock()
+	return this.cert, nil
+}
+
 func appendConnectionTimeoutOption(cfg gopi.Config, opts []grpc.ServerOption) ([]grpc.ServerOption, error) {
 	if timeout := cfg.GetDuration("timeout"); timeout > 0 {
 		opts = append(opts, grpc.ConnectionTimeout(timeout))
@@ -0,0 +1,225 @@
+// +build linux
+// +build !darwin
+
+package linux
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Mount is a mounted filesystem
+type Mount struct {
+	Device string
+	Path   string
+	Type   string
+}
+
+// DiskUsage is the usage of a mounted filesystem, in bytes and inodes
+type DiskUsage struct {
+	Total, Free, Avail uint64
+	Files, FilesFree   uint64
+}
+
+// NetDevStat holds the counters for a network interface
+type NetDevStat struct {
+	Name                                    string
+	RxBytes, RxPackets, RxErrors, RxDropped uint64
+	TxBytes, TxPackets, TxErrors, TxDropped uint64
+}
+
+// ProcStat holds the status of a process
+type ProcStat struct {
+	Pid     int
+	Name    string
+	CPU     time.Duration // User and system time consumed
+	Start   time.Duration // Time the process started after boot
+	RSS     uint64        // Resident set size in bytes
+	Threads uint
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	PROC_PATH = "/proc"
+
+	// Clock ticks per second for process times, which is fixed at 100
+	// for userspace on all architectures
+	CLOCK_TICKS = 100
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// MemInfo returns memory statistics in bytes, keyed by name
+// (for example, MemTotal or MemAvailable)
+func MemInfo() (map[string]uint64, error) {
+	fh, err := os.Open(filepath.Join(PROC_PATH, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	result := make(map[string]uint64)
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		// Lines are in the form "MemTotal:  3884376 kB"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) == 3 && fields[2] == "kB" {
+			value *= 1024
+		}
+		result[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	return result, scanner.Err()
+}
+
+// Mounts returns mounted filesystems which are backed by a device
+func Mounts() ([]Mount, error) {
+	fh, err := os.Open(filepath.Join(PROC_PATH, "mounts"))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	result := []Mount{}
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "/dev/") == false {
+			continue
+		}
+		result = append(result, Mount{fields[0], unescapeMount(fields[1]), fields[2]})
+	}
+	return result, scanner.Err()
+}
+
+// Usage returns the usage of the filesystem mounted at a path
+func Usage(path string) (DiskUsage, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskUsage{}, err
+	}
+	bsize := uint64(stat.Bsize)
+	return DiskUsage{
+		Total:     stat.Blocks * bsize,
+		Free:      stat.Bfree * bsize,
+		Avail:     stat.Bavail * bsize,
+		Files:     stat.Files,
+		FilesFree: stat.Ffree,
+	}, nil
+}
+
+// NetDevStats returns counters for each network interface
+func NetDevStats() ([]NetDevStat, error) {
+	fh, err := os.Open(filepath.Join(PROC_PATH, "net", "dev"))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	result := []NetDevStat{}
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		// Skip header lines, which do not have an interface name
+		line := strings.SplitN(scanner.Text(), ":", 2)
+		if len(line) != 2 {
+			continue
+		}
+		fields := strings.Fields(line[1])
+		if len(fields) < 16 {
+			continue
+		}
+		values := make([]uint64, 16)
+		for i := range values {
+			values[i], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+		result = append(result, NetDevStat{
+			Name:    strings.TrimSpace(line[0]),
+			RxBytes: values[0], RxPackets: values[1], RxErrors: values[2], RxDropped: values[3],
+			TxBytes: values[8], TxPackets: values[9], TxErrors: values[10], TxDropped: values[11],
+		})
+	}
+	return result, scanner.Err()
+}
+
+// Processes returns the identifiers of running processes
+func Processes() ([]int, error) {
+	files, err := ioutil.ReadDir(PROC_PATH)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]int, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() == false {
+			continue
+		} else if pid, err := strconv.Atoi(file.Name()); err == nil {
+			result = append(result, pid)
+		}
+	}
+	return result, nil
+}
+
+// ProcessStat returns the status of a process
+func ProcessStat(pid int) (ProcStat, error) {
+	data, err := ioutil.ReadFile(filepath.Join(PROC_PATH, fmt.Sprint(pid), "stat"))
+	if err != nil {
+		return ProcStat{}, err
+	}
+
+	// The name is in parentheses and can contain spaces and parentheses,
+	// so fields are counted from the last closing parenthesis
+	str := string(data)
+	start, end := strings.IndexByte(str, '('), strings.LastIndexByte(str, ')')
+	if start < 0 || end < start {
+		return ProcStat{}, syscall.EINVAL
+	}
+	fields := strings.Fields(str[end+1:])
+	if len(fields) < 22 {
+		return ProcStat{}, syscall.EINVAL
+	}
+
+	// Fields are numbered from the state, which is the third field
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	threads, _ := strconv.ParseUint(fields[17], 10, 32)
+	start_, _ := strconv.ParseUint(fields[19], 10, 64)
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+	if rss < 0 {
+		rss = 0
+	}
+	return ProcStat{
+		Pid:     pid,
+		Name:    str[start+1 : end],
+		CPU:     time.Duration(utime+stime) * time.Second / CLOCK_TICKS,
+		Start:   time.Duration(start_) * time.Second / CLOCK_TICKS,
+		RSS:     uint64(rss) * uint64(os.Getpagesize()),
+		Threads: uint(threads),
+	}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// unescapeMount replaces octal escapes for spaces, tabs and
+// backslashes in mount paths
+func unescapeMount(path string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(path)
+}
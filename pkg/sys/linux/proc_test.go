@@ -0,0 +1,60 @@
+// +build linux
+
+package linux_test
+
+import (
+	"os"
+	"testing"
+
+	// Frameworks
+	"github.com/djthorpe/gopi/v3/pkg/sys/linux"
+)
+
+func Test_Proc_001(t *testing.T) {
+	if info, err := linux.MemInfo(); err != nil {
+		t.Error(err)
+	} else if info["MemTotal"] == 0 {
+		t.Error("Unexpected response from MemInfo")
+	} else {
+		t.Log("memtotal", info["MemTotal"])
+	}
+}
+
+func Test_Proc_002(t *testing.T) {
+	if mounts, err := linux.Mounts(); err != nil {
+		t.Error(err)
+	} else {
+		for _, mount := range mounts {
+			if usage, err := linux.Usage(mount.Path); err != nil {
+				t.Error(err)
+			} else {
+				t.Log(mount, usage)
+			}
+		}
+	}
+}
+
+func Test_Proc_003(t *testing.T) {
+	if stats, err := linux.NetDevStats(); err != nil {
+		t.Error(err)
+	} else if len(stats) == 0 {
+		t.Error("Unexpected response from NetDevStats")
+	} else {
+		t.Log(stats)
+	}
+}
+
+func Test_Proc_004(t *testing.T) {
+	if pids, err := linux.Processes(); err != nil {
+		t.Error(err)
+	} else if len(pids) == 0 {
+		t.Error("Unexpected response from Processes")
+	}
+	if stat, err := linux.ProcessStat(os.Getpid()); err != nil {
+		t.Error(err)
+	} else if stat.Pid != os.Getpid() || stat.Name == "" || stat.RSS == 0 || stat.Threads == 0 {
+		t.Error("Unexpected response from ProcessStat", stat)
+	} else {
+		t.Log(stat)
+	}
+}
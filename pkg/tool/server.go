@@ -22,6 +22,18 @@ type server struct {
 	version    string
 }
 
+// healthServer is implemented by servers which can report the
+// health of units to clients
+type healthServer interface {
+	RegisterHealth(func() map[string]error) error
+}
+
+// unitGraph returns the units in a graph and their health
+type unitGraph interface {
+	Units(interface{}) []interface{}
+	Health() map[string]error
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // BOOTSTRAP
 
@@ -56,6 +68,20 @@ func (this *server) New(cfg gopi.Config) error {
 	return nil
 }
 
+// registerHealth exposes unit health on every server in the graph which
+// supports it. It is called once all units have been created, before
+// the servers are started
+func registerHealth(g unitGraph) error {
+	for _, unit := range g.Units((*healthServer)(nil)) {
+		if err := unit.(healthServer).RegisterHealth(g.Health); err != nil {
+			return err
+		}
+	}
+
+	// Return success
+	return nil
+}
+
 func (this *server) Run(ctx context.Context) error {
 	// Start server in background
 	if err := this.Server.StartInBackground("", *this.addr); err != nil {
@@ -14,13 +14,49 @@ import (
 	"github.com/djthorpe/gopi/v3/pkg/log"
 )
 
-func Test(t *testing.T, args []string, obj, fn interface{}) int {
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// TestOption modifies the graph used for a test
+type TestOption func(testGraph) error
+
+type testGraph interface {
+	Override(interface{}, interface{}) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OPTIONS
+
+// WithUnit replaces the unit used for an interface with a fake, for
+// example WithUnit((*gopi.I2C)(nil), fakeI2C). The registered units
+// are not changed, so other tests are unaffected
+func WithUnit(iface, unit interface{}) TestOption {
+	return func(g testGraph) error {
+		return g.Override(iface, unit)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// TEST
+
+func Test(t *testing.T, args []string, obj, fn interface{}, opts ...TestOption) int {
 	// Create empty configuration and graph
 	cfg := config.New(t.Name(), args)
 	g := graph.NewGraph(t.Log)
 
-	// Create objects
-	if err := g.Create(obj); err != nil {
+	// Apply options
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			t.Error("New:", err)
+			return -1
+		}
+	}
+
+	// Select units and create objects
+	if err := g.Select(cfg); err != nil {
+		t.Error("New:", err)
+		return -1
+	} else if err := g.Create(obj); err != nil {
 		t.Error("New:", err)
 		return -1
 	}
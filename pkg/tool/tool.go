@@ -5,8 +5,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/djthorpe/gopi/v3"
 	"github.com/djthorpe/gopi/v3/pkg/config"
@@ -14,9 +17,36 @@ import (
 	_ "github.com/djthorpe/gopi/v3/pkg/log"
 )
 
+// reloader is implemented by configurations which can be re-read
+type reloader interface {
+	Reload() (gopi.Config, error)
+}
+
+// completer is implemented by configurations which generate shell
+// completion scripts and candidates
+type completer interface {
+	WriteCompletionScript(io.Writer) (bool, error)
+	WriteCompletions(context.Context, io.Writer, func() error) (bool, error)
+}
+
+// lifecycle creates and disposes of units
+type lifecycle interface {
+	New(gopi.Config) error
+	Dispose() error
+}
+
+const (
+	// Maximum time to wait for dynamic completion candidates
+	completeTimeout = 2 * time.Second
+)
+
 func CommandLine(name string, args []string, objs ...interface{}) int {
 	// Create empty configuration and graph
 	cfg := config.New(name, args)
+	if err := graph.Select(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "New:", err)
+		return -1
+	}
 	graph, err := graph.Create(objs...)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "New:", err)
@@ -40,6 +70,24 @@ func CommandLine(name string, args []string, objs ...interface{}) int {
 		return -1
 	}
 
+	// Write shell completion script or candidates and exit if requested
+	if done, err := complete(cfg, graph); done {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Completion:", err)
+			return -1
+		}
+		return 0
+	}
+
+	// Write the unit graph and exit if requested
+	if format := cfg.GetString("graph.dump"); format != "" {
+		if err := graph.Dump(os.Stdout, format); err != nil {
+			fmt.Fprintln(os.Stderr, "Dump:", err)
+			return -1
+		}
+		return 0
+	}
+
 	// Call New
 	if err := graph.New(cfg); errors.Is(err, gopi.ErrHelp) || errors.Is(err, flag.ErrHelp) {
 		cfg.Usage("")
@@ -49,6 +97,16 @@ func CommandLine(name string, args []string, objs ...interface{}) int {
 		return -1
 	}
 
+	// Expose unit health on every server when running as a server
+	for _, obj := range objs {
+		if _, ok := obj.(*server); ok {
+			if err := registerHealth(graph); err != nil {
+				fmt.Fprintln(os.Stderr, "New:", err)
+				return -1
+			}
+		}
+	}
+
 	// If there is a gopi.Logger object and debug is set then
 	// use the Debug method to output extra information
 	if logger != nil && logger.IsDebug() {
@@ -65,15 +123,23 @@ func CommandLine(name string, args []string, objs ...interface{}) int {
 	// Create context with a cancel
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Handle signals - call cancel when interrupt received
+	// Handle signals - call cancel when interrupt received, and reload
+	// configuration when hangup received
 	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, os.Interrupt)
+	signal.Notify(ch, os.Interrupt, syscall.SIGHUP)
+	defer signal.Stop(ch)
 	go func() {
-		s := <-ch
-		if logger != nil && logger.IsDebug() {
-			logger.Debug("Got signal: ", s)
+		for s := range ch {
+			if logger != nil && logger.IsDebug() {
+				logger.Debug("Got signal: ", s)
+			}
+			if s != syscall.SIGHUP {
+				cancel()
+				return
+			} else if err := reload(cfg, graph); err != nil {
+				fmt.Fprintln(os.Stderr, "Reload:", err)
+			}
 		}
-		cancel()
 	}()
 
 	// Call Run and end when all top-level object Run methods return
@@ -89,3 +155,44 @@ func CommandLine(name string, args []string, objs ...interface{}) int {
 	// Return success
 	return 0
 }
+
+// complete writes a completion script, or writes completion candidates
+// when the tool is called from a completion script, and returns true.
+// Units are only created when dynamic candidates are required, and
+// errors in creating them are ignored so nothing is written to the shell
+func complete(cfg gopi.Config, g lifecycle) (bool, error) {
+	cfg_, ok := cfg.(completer)
+	if ok == false {
+		return false, nil
+	} else if done, err := cfg_.WriteCompletionScript(os.Stdout); done {
+		return true, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completeTimeout)
+	defer cancel()
+
+	created := false
+	done, _ := cfg_.WriteCompletions(ctx, os.Stdout, func() error {
+		if err := g.New(cfg); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if created {
+		g.Dispose()
+	}
+	return done, nil
+}
+
+// reload re-reads the configuration and then calls Reload on any
+// units which implement it with the new configuration
+func reload(cfg gopi.Config, g gopi.Reloader) error {
+	if cfg_, ok := cfg.(reloader); ok == false {
+		return gopi.ErrNotImplemented.WithPrefix("Reload")
+	} else if cfg, err := cfg_.Reload(); err != nil {
+		return err
+	} else {
+		return g.Reload(cfg)
+	}
+}
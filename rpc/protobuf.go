@@ -7,6 +7,7 @@ package rpc
 //go:generate protoc --go_out=../pkg/rpc --go_opt=paths=source_relative --go-grpc_out=../pkg/rpc --go-grpc_opt=paths=source_relative chromecast/chromecast.proto
 //go:generate protoc --go_out=../pkg/rpc --go_opt=paths=source_relative castchannel/castchannel.proto
 //go:generate protoc --go_out=../pkg/rpc --go_opt=paths=source_relative --go-grpc_out=../pkg/rpc --go-grpc_opt=paths=source_relative rotel/rotel.proto
+//go:generate protoc --go_out=../pkg/rpc --go_opt=paths=source_relative --go-grpc_out=../pkg/rpc --go-grpc_opt=paths=source_relative events/events.proto
 
 /*
 	This folder contains all the protocol buffer definitions. You